@@ -0,0 +1,168 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io/fs"
+	"path"
+	"sort"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/jsondata"
+	"go-play-plane/internal/sim"
+)
+
+// Sprite 表示一个精灵及其所有命名动画，每个精灵至少包含待机动画
+type Sprite struct {
+	anims map[string]*Animation
+}
+
+// Animation 是按固定帧时长播放的一段动画
+type Animation struct {
+	frames    []*ebiten.Image // 各帧图像
+	frameTime int             // 每帧显示的模拟帧数
+	loop      bool            // 是否循环播放，不循环时停在最后一帧
+}
+
+// 以下为精灵图集元数据文件的JSON结构：精灵名称到动画的映射，动画名称见animation.go
+
+type atlasJSON struct {
+	Image   string                              `json:"image"` // 图集图像，相对于元数据文件所在目录
+	Sprites map[string]map[string]animationJSON `json:"sprites"`
+}
+
+type animationJSON struct {
+	Frames    [][4]int `json:"frames"`    // 每帧在图集图像中的[x, y, 宽, 高]
+	FrameTime int      `json:"frameTime"` // 每帧显示的模拟帧数
+	Loop      bool     `json:"loop"`      // 是否循环播放
+}
+
+// NewStaticSprite 用单张图像创建只有一帧待机动画的精灵
+func NewStaticSprite(img *ebiten.Image) *Sprite {
+	return &Sprite{anims: map[string]*Animation{
+		sim.AnimIdle: {frames: []*ebiten.Image{img}, frameTime: 1, loop: true},
+	}}
+}
+
+// animation 返回名为name的动画，精灵没有该动画时返回待机动画
+func (s *Sprite) animation(name string) *Animation {
+	if a, ok := s.anims[name]; ok {
+		return a
+	}
+	return s.anims[sim.AnimIdle]
+}
+
+// Frame 返回动画播放了t帧时应显示的图像
+func (a *Animation) Frame(t int) *ebiten.Image {
+	i := t / a.frameTime
+	if a.loop {
+		i %= len(a.frames)
+	} else {
+		i = min(i, len(a.frames)-1)
+	}
+	return a.frames[i]
+}
+
+// duration 返回动画播放一遍的模拟帧数
+func (a *Animation) duration() int {
+	return len(a.frames) * a.frameTime
+}
+
+// explodeAnimation 返回精灵sprite的爆炸动画，精灵没有爆炸动画时使用敌机的爆炸动画
+func explodeAnimation(sprite string) *Animation {
+	if s, ok := loadedSprites[sprite]; ok {
+		if anim := s.anims[sim.AnimExplode]; anim != nil {
+			return anim
+		}
+	}
+	return loadedSprites["enemy"].animation(sim.AnimExplode)
+}
+
+// LoadAtlas 从fsys读取精灵图集的元数据文件metaPath及其引用的PNG图像
+func LoadAtlas(fsys fs.FS, metaPath string) (map[string]*Sprite, error) {
+	data, err := fs.ReadFile(fsys, metaPath)
+	if err != nil {
+		return nil, err
+	}
+	var meta atlasJSON
+	if err := jsondata.Decode(data, &meta); err != nil {
+		return nil, fmt.Errorf("%s: 解析精灵图集失败: %w", metaPath, err)
+	}
+
+	imagePath := path.Join(path.Dir(metaPath), meta.Image)
+	f, err := fsys.Open(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: 解码图集图像失败: %w", imagePath, err)
+	}
+
+	sprites, err := buildSprites(&meta, img)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", metaPath, err)
+	}
+	return sprites, nil
+}
+
+// buildSprites 校验元数据并从图集图像中切出各帧，校验失败时一次性返回所有问题
+func buildSprites(meta *atlasJSON, img image.Image) (map[string]*Sprite, error) {
+	// 按名称顺序校验，保证错误信息的顺序稳定
+	names := make([]string, 0, len(meta.Sprites))
+	for name := range meta.Sprites {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	sheet := ebiten.NewImageFromImage(img)
+	bounds := img.Bounds()
+	sprites := make(map[string]*Sprite, len(names))
+	var errs []error
+	for _, name := range names {
+		anims := meta.Sprites[name]
+		if _, ok := anims[sim.AnimIdle]; !ok {
+			errs = append(errs, fmt.Errorf("精灵%s: 缺少%s动画", name, sim.AnimIdle))
+		}
+
+		animNames := make([]string, 0, len(anims))
+		for animName := range anims {
+			animNames = append(animNames, animName)
+		}
+		sort.Strings(animNames)
+
+		sprite := &Sprite{anims: make(map[string]*Animation, len(anims))}
+		for _, animName := range animNames {
+			raw := anims[animName]
+			fail := func(format string, args ...any) {
+				errs = append(errs, fmt.Errorf("精灵%s的%s动画: %s", name, animName, fmt.Sprintf(format, args...)))
+			}
+			if len(raw.Frames) == 0 {
+				fail("至少需要一帧")
+				continue
+			}
+			if raw.FrameTime <= 0 {
+				fail("frameTime必须大于0")
+				continue
+			}
+			anim := &Animation{frameTime: raw.FrameTime, loop: raw.Loop}
+			for i, r := range raw.Frames {
+				rect := image.Rect(r[0], r[1], r[0]+r[2], r[1]+r[3]).Add(bounds.Min)
+				if r[2] <= 0 || r[3] <= 0 || !rect.In(bounds) {
+					fail("frames[%d]超出图集图像范围", i)
+					continue
+				}
+				anim.frames = append(anim.frames, sheet.SubImage(rect).(*ebiten.Image))
+			}
+			sprite.anims[animName] = anim
+		}
+		sprites[name] = sprite
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return sprites, nil
+}
@@ -0,0 +1,360 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/audio"
+	"github.com/hajimehoshi/ebiten/v2/audio/vorbis"
+	"github.com/hajimehoshi/ebiten/v2/audio/wav"
+	"go-play-plane/internal/sim"
+)
+
+// soundVoices 每种音效最多同时播放的声部数，达到上限后新的播放替换最早的一个。
+// 射击、击中和BOSS弹幕非常频繁，只保留很少的声部，避免一轮弹幕叠加出刺耳的噪音
+var soundVoices = [sim.SoundCount]int{
+	sim.SoundShot:              2,
+	sim.SoundEnemyHit:          3,
+	sim.SoundEnemyDestroyed:    4,
+	sim.SoundBossDestroyed:     1,
+	sim.SoundPlayerDeath:       1,
+	sim.SoundPowerUpMultiShot:  2,
+	sim.SoundPowerUpScreenShot: 2,
+	sim.SoundPowerUpAttack:     2,
+	sim.SoundPowerUpClear:      2,
+	sim.SoundBossWarning:       1,
+	sim.SoundBossPhase:         1,
+	sim.SoundVolley:            2,
+	sim.SoundVolleyRing:        2,
+	sim.SoundVolleyCross:       2,
+	sim.SoundVolleyHoming:      2,
+	sim.SoundVolleyChaos:       2,
+}
+
+// maxVoices 所有音效合计最多同时播放的声部数
+const maxVoices = 12
+
+// audioSampleRate 音频设备的采样率，音频文件解码时重采样到该采样率
+const audioSampleRate = 44100
+
+// musicDir 音乐文件在资源中的目录，文件可以是OGG或WAV格式
+const musicDir = "resources/audio/music"
+
+// AudioBackend 实际发出声音的后端。AudioManager通过它播放音效和音乐，
+// 无窗口运行和测试时可以换成NullAudio
+type AudioBackend interface {
+	// PlaySound 以音量volume播放一次音效sound，返回播放中的声部
+	PlaySound(sound sim.Sound, volume float64) Voice
+	// PlayMusic 停止正在播放的音乐，以音量volume从头循环播放曲目track，track为空时只停止
+	PlayMusic(track string, volume float64)
+	// SetMusicVolume 调整正在播放的音乐的音量
+	SetMusicVolume(volume float64)
+}
+
+// Voice 表示一个正在播放的音效
+type Voice interface {
+	// IsPlaying 返回音效是否还在播放
+	IsPlaying() bool
+	// Stop 停止播放
+	Stop()
+}
+
+// soundVolume 返回音效的实际音量，范围[0, 1]
+func soundVolume() float64 {
+	return float64(settings.MasterVolume*settings.SoundVolume) / 10000
+}
+
+// musicVolume 返回音乐的实际音量，范围[0, 1]
+func musicVolume() float64 {
+	return float64(settings.MasterVolume*settings.MusicVolume) / 10000
+}
+
+// AudioManager 按设置中的音量播放音效和背景音乐，并限制同时播放的声部数，
+// 避免一轮弹幕或连续击中时大量相同的音效叠加在一起
+type AudioManager struct {
+	backend AudioBackend
+	voices  [sim.SoundCount][]Voice // 每种音效正在播放的声部，按开始播放的先后排列
+	played  [sim.SoundCount]bool    // 本帧已经播放过的音效
+	music   string                  // 正在播放的曲目
+}
+
+// NewAudioManager 创建一个通过音频设备播放合成音效和内置音乐的音频管理器
+func NewAudioManager() (*AudioManager, error) {
+	backend, err := newEbitenAudio(audioFS)
+	if err != nil {
+		return nil, err
+	}
+	return NewAudioManagerFrom(backend), nil
+}
+
+// NewAudioManagerFrom 创建一个通过backend发出声音的音频管理器
+func NewAudioManagerFrom(backend AudioBackend) *AudioManager {
+	return &AudioManager{backend: backend}
+}
+
+// Update 在每帧开始时调用：清理已经播放完毕的声部，并使音乐音量跟随设置
+func (a *AudioManager) Update() {
+	a.played = [sim.SoundCount]bool{}
+	for i := range a.voices {
+		a.voices[i] = slices.DeleteFunc(a.voices[i], func(v Voice) bool { return !v.IsPlaying() })
+	}
+	a.backend.SetMusicVolume(musicVolume())
+}
+
+// Play 播放音效sound。同一帧内同一种音效只播放一次；该音效或所有音效的声部数
+// 达到上限时，先停止最早开始播放的声部
+func (a *AudioManager) Play(sound sim.Sound) {
+	if a.played[sound] {
+		return
+	}
+	a.played[sound] = true
+	volume := soundVolume()
+	if volume == 0 {
+		return
+	}
+
+	if len(a.voices[sound]) >= soundVoices[sound] {
+		a.stopOldest(sound)
+	} else if a.voiceCount() >= maxVoices {
+		a.stopOldest(a.busiest())
+	}
+	a.voices[sound] = append(a.voices[sound], a.backend.PlaySound(sound, volume))
+}
+
+// PlayAll 依次播放sounds中的音效，通常是模拟一帧中产生的所有音效
+func (a *AudioManager) PlayAll(sounds []sim.Sound) {
+	for _, sound := range sounds {
+		a.Play(sound)
+	}
+}
+
+// PlayMusic 切换背景音乐，与正在播放的曲目相同时继续播放，track为空时停止音乐
+func (a *AudioManager) PlayMusic(track string) {
+	if track == a.music {
+		return
+	}
+	a.music = track
+	a.backend.PlayMusic(track, musicVolume())
+}
+
+// voiceCount 返回所有音效正在播放的声部总数
+func (a *AudioManager) voiceCount() int {
+	n := 0
+	for _, voices := range a.voices {
+		n += len(voices)
+	}
+	return n
+}
+
+// busiest 返回正在播放的声部最多的音效
+func (a *AudioManager) busiest() sim.Sound {
+	busiest := sim.Sound(0)
+	for sound := range a.voices {
+		if len(a.voices[sound]) > len(a.voices[busiest]) {
+			busiest = sim.Sound(sound)
+		}
+	}
+	return busiest
+}
+
+// stopOldest 停止音效sound最早开始播放的声部
+func (a *AudioManager) stopOldest(sound sim.Sound) {
+	if len(a.voices[sound]) == 0 {
+		return
+	}
+	a.voices[sound][0].Stop()
+	a.voices[sound] = slices.Delete(a.voices[sound], 0, 1)
+}
+
+// NullAudio 不发出任何声音的后端，用于无窗口运行和测试。它记录每种音效被播放的次数、
+// 播放过的所有声部和当前的曲目；音效的声部在被停止之前一直处于播放中，便于检查声部限制
+type NullAudio struct {
+	plays   [sim.SoundCount]int
+	started int          // 所有音效合计播放的次数
+	voices  []*nullVoice // 尚未停止的声部，按开始播放的先后排列
+	music   string
+}
+
+// NewNullAudio 创建一个不发出声音的后端
+func NewNullAudio() *NullAudio {
+	return &NullAudio{}
+}
+
+// Plays 返回音效sound被播放的次数
+func (n *NullAudio) Plays(sound sim.Sound) int {
+	return n.plays[sound]
+}
+
+// Music 返回当前的曲目，没有播放音乐时为空
+func (n *NullAudio) Music() string {
+	return n.music
+}
+
+// Playing 返回正在播放的声部各是第几次播放的音效，从0开始计数并按先后排列
+func (n *NullAudio) Playing() []int {
+	var playing []int
+	for _, v := range n.voices {
+		if v.playing {
+			playing = append(playing, v.order)
+		}
+	}
+	return playing
+}
+
+func (n *NullAudio) PlaySound(sound sim.Sound, volume float64) Voice {
+	n.plays[sound]++
+	n.voices = slices.DeleteFunc(n.voices, func(v *nullVoice) bool { return !v.playing })
+	v := &nullVoice{order: n.started, playing: true}
+	n.started++
+	n.voices = append(n.voices, v)
+	return v
+}
+
+func (n *NullAudio) PlayMusic(track string, volume float64) {
+	n.music = track
+}
+
+func (n *NullAudio) SetMusicVolume(volume float64) {}
+
+// nullVoice NullAudio播放的声部
+type nullVoice struct {
+	order   int // 第几次播放的音效
+	playing bool
+}
+
+func (v *nullVoice) IsPlaying() bool {
+	return v.playing
+}
+
+func (v *nullVoice) Stop() {
+	v.playing = false
+}
+
+// audioStream 解码后的音频流，OGG和WAV解码器的返回值都满足该接口
+type audioStream interface {
+	io.ReadSeeker
+	Length() int64
+}
+
+// decodeAudio 按文件扩展名解码OGG或WAV数据，重采样到audioSampleRate
+func decodeAudio(name string, data []byte) (audioStream, error) {
+	switch path.Ext(name) {
+	case ".ogg":
+		return vorbis.DecodeWithSampleRate(audioSampleRate, bytes.NewReader(data))
+	case ".wav":
+		return wav.DecodeWithSampleRate(audioSampleRate, bytes.NewReader(data))
+	}
+	return nil, fmt.Errorf("不支持的音频格式%q", path.Ext(name))
+}
+
+// readAudioDir 读取目录dir中的所有音频文件，返回去掉扩展名的文件名到文件路径和数据的映射
+func readAudioDir(fsys fs.FS, dir string) (map[string]string, map[string][]byte, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, nil, err
+	}
+	paths := make(map[string]string)
+	files := make(map[string][]byte)
+	for _, e := range entries {
+		ext := path.Ext(e.Name())
+		if e.IsDir() || (ext != ".ogg" && ext != ".wav") {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ext)
+		if _, ok := files[name]; ok {
+			return nil, nil, fmt.Errorf("%s: %s同时有多个格式的文件", dir, name)
+		}
+		p := path.Join(dir, e.Name())
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, nil, err
+		}
+		paths[name] = p
+		files[name] = data
+	}
+	return paths, files, nil
+}
+
+// ebitenAudio 通过Ebiten的音频设备发出声音
+type ebitenAudio struct {
+	context *audio.Context
+	sounds  [sim.SoundCount][]byte // 合成好的音效PCM数据
+	paths   map[string]string      // 曲目名称到音乐文件路径
+	tracks  map[string][]byte      // 曲目名称到音乐文件数据，播放时才解码
+	music   *audio.Player          // 正在播放的音乐
+}
+
+// newEbitenAudio 合成所有音效并从fsys中加载音乐，音乐在播放时才解码
+func newEbitenAudio(fsys fs.FS) (*ebitenAudio, error) {
+	a := &ebitenAudio{context: audio.NewContext(audioSampleRate)}
+	for sound, preset := range synthPresets {
+		a.sounds[sound] = preset.Render(audioSampleRate)
+	}
+
+	var err error
+	a.paths, a.tracks, err = readAudioDir(fsys, musicDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, track := range sim.MusicTracks {
+		if _, ok := a.tracks[track]; !ok {
+			return nil, fmt.Errorf("%s: 缺少曲目%s", musicDir, track)
+		}
+	}
+	return a, nil
+}
+
+func (a *ebitenAudio) PlaySound(sound sim.Sound, volume float64) Voice {
+	p := a.context.NewPlayerFromBytes(a.sounds[sound])
+	p.SetVolume(volume)
+	p.Play()
+	return ebitenVoice{p}
+}
+
+func (a *ebitenAudio) PlayMusic(track string, volume float64) {
+	if a.music != nil {
+		a.music.Close()
+		a.music = nil
+	}
+	if track == "" {
+		return
+	}
+	stream, err := decodeAudio(a.paths[track], a.tracks[track])
+	if err != nil {
+		log.Printf("播放音乐%s失败: %v", track, err)
+		return
+	}
+	p, err := a.context.NewPlayer(audio.NewInfiniteLoop(stream, stream.Length()))
+	if err != nil {
+		log.Printf("播放音乐%s失败: %v", track, err)
+		return
+	}
+	p.SetVolume(volume)
+	p.Play()
+	a.music = p
+}
+
+func (a *ebitenAudio) SetMusicVolume(volume float64) {
+	if a.music != nil {
+		a.music.SetVolume(volume)
+	}
+}
+
+// ebitenVoice ebitenAudio播放的声部
+type ebitenVoice struct {
+	player *audio.Player
+}
+
+func (v ebitenVoice) IsPlaying() bool {
+	return v.player.IsPlaying()
+}
+
+func (v ebitenVoice) Stop() {
+	v.player.Pause()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"go-play-plane/internal/sim"
+)
+
+// playFrames 每帧播放一次sound，共n帧，返回播放后仍在播放的声部
+func playFrames(a *AudioManager, backend *NullAudio, sound sim.Sound, n int) []int {
+	for i := 0; i < n; i++ {
+		a.Update()
+		a.Play(sound)
+	}
+	return backend.Playing()
+}
+
+// span 返回[from, to)中的所有整数
+func span(from, to int) []int {
+	var s []int
+	for i := from; i < to; i++ {
+		s = append(s, i)
+	}
+	return s
+}
+
+func TestAudioStealsOldestVoice(t *testing.T) {
+	useSettings(t, defaultSettings())
+
+	t.Run("所有音效的上限", func(t *testing.T) {
+		// 放宽射击音效自己的上限，只受所有音效合计的上限限制
+		old := soundVoices[sim.SoundShot]
+		soundVoices[sim.SoundShot] = maxVoices + 10
+		defer func() { soundVoices[sim.SoundShot] = old }()
+
+		backend := NewNullAudio()
+		a := NewAudioManagerFrom(backend)
+		if got, want := playFrames(a, backend, sim.SoundShot, maxVoices+3), span(3, maxVoices+3); !slices.Equal(got, want) {
+			t.Errorf("播放%d次后正在播放的声部为%v，应为最后%d次%v", maxVoices+3, got, maxVoices, want)
+		}
+	})
+
+	t.Run("每种音效的上限", func(t *testing.T) {
+		backend := NewNullAudio()
+		a := NewAudioManagerFrom(backend)
+		n, limit := maxVoices+3, soundVoices[sim.SoundShot]
+		if got, want := playFrames(a, backend, sim.SoundShot, n), span(n-limit, n); !slices.Equal(got, want) {
+			t.Errorf("播放%d次后正在播放的声部为%v，应为最后%d次%v", n, got, limit, want)
+		}
+	})
+
+	t.Run("替换最多的音效", func(t *testing.T) {
+		backend := NewNullAudio()
+		a := NewAudioManagerFrom(backend)
+		// 击中音效占满自己的声部，其余声部分给除击中和射击以外的音效各一个
+		playFrames(a, backend, sim.SoundEnemyHit, soundVoices[sim.SoundEnemyHit])
+		for sound := sim.Sound(0); sound < sim.SoundCount && len(backend.Playing()) < maxVoices; sound++ {
+			if sound != sim.SoundEnemyHit && sound != sim.SoundShot {
+				a.Play(sound)
+			}
+		}
+		if got := len(backend.Playing()); got != maxVoices {
+			t.Fatalf("正在播放%d个声部，应为%d个", got, maxVoices)
+		}
+
+		// 再播放射击音效时，停止声部最多的击中音效中最早的一个
+		a.Update()
+		a.Play(sim.SoundShot)
+		playing := backend.Playing()
+		if slices.Contains(playing, 0) || len(playing) != maxVoices {
+			t.Errorf("达到上限后正在播放的声部为%v，应停止最早的击中音效0", playing)
+		}
+	})
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/sim"
+)
+
+// effectLife 播放精灵sprite的爆炸，返回效果持续的帧数
+func effectLife(sprite string) int {
+	em := sim.NewEffectManager()
+	em.Explode(&sim.Body{Width: 32, Height: 32}, sprite)
+	ticks := 0
+	for len(em.Effects) > 0 {
+		em.Update()
+		ticks++
+	}
+	return ticks
+}
+
+func TestExplosionLastsForAnimation(t *testing.T) {
+	if got, want := effectLife("enemy"), loadedSprites["enemy"].anims[sim.AnimExplode].duration(); got != want {
+		t.Errorf("敌机的爆炸持续%d帧，应与爆炸动画一致为%d帧", got, want)
+	}
+
+	// 模拟开发模式重新加载图集后敌机的爆炸动画变为3帧、每帧5个模拟帧
+	old := maps.Clone(loadedSprites)
+	defer func() { loadedSprites = old }()
+	frame := ebiten.NewImage(1, 1)
+	loadedSprites = maps.Clone(old)
+	loadedSprites["enemy"] = &Sprite{anims: map[string]*Animation{
+		sim.AnimIdle:    {frames: []*ebiten.Image{frame}, frameTime: 1, loop: true},
+		sim.AnimExplode: {frames: []*ebiten.Image{frame, frame, frame}, frameTime: 5},
+	}}
+	if got := effectLife("enemy"); got != 15 {
+		t.Errorf("重新加载后敌机的爆炸持续%d帧，应为15帧", got)
+	}
+	// 没有爆炸动画的精灵使用敌机的爆炸动画
+	loadedSprites["static"] = NewStaticSprite(frame)
+	if got := effectLife("static"); got != 15 {
+		t.Errorf("没有爆炸动画的精灵爆炸持续%d帧，应为15帧", got)
+	}
+}
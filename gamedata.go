@@ -0,0 +1,43 @@
+package main
+
+import "go-play-plane/internal/sim"
+
+// builtinSprites 内置贴图名称与贴图键的对应关系，BOSS定义的sprite可以使用这些名称
+var builtinSprites = map[string]string{
+	"boss":   "boss",
+	"enemy":  "enemy",
+	"player": "player",
+}
+
+// bulletPatterns 所有可用的弹幕脚本，按名称索引
+var bulletPatterns = mustParse(sim.ParsePatterns(defaultPatternsJSON))
+
+// bosses 所有可用的BOSS定义，按ID索引
+var bosses = mustParse(sim.ParseBosses(defaultBossesJSON, builtinSprites, bulletPatterns))
+
+// levels 当前使用的关卡定义，默认为内置关卡文件，启动时可通过-levels参数替换
+var levels = mustParse(sim.ParseLevels(defaultLevelsJSON, bosses))
+
+// levelPacks 启动时从模组目录成功加载的关卡包，按模组目录名排序
+var levelPacks []*sim.LevelSet
+
+// mustParse 返回解析内置数据的结果，内置数据无效属于程序错误
+func mustParse[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+// findLevelPack 返回指定ID的关卡包，空ID表示当前使用的内置关卡，找不到时返回nil
+func findLevelPack(pack string) *sim.LevelSet {
+	if pack == "" {
+		return levels
+	}
+	for _, ls := range levelPacks {
+		if ls.Pack == pack {
+			return ls
+		}
+	}
+	return nil
+}
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"log"
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/sim"
+)
+
+// 摇杆死区，以摇杆最大偏移的百分比表示
+const (
+	defaultGamepadDeadzone = 25 // 默认死区
+	maxGamepadDeadzone     = 90 // 死区上限
+)
+
+// GamepadBackend 提供手柄的原始状态。GamepadInput通过它读取手柄，
+// 测试和机器人可以用VirtualGamepads代替真实手柄
+type GamepadBackend interface {
+	// AppendGamepadIDs 将所有已连接手柄的ID追加到ids后返回
+	AppendGamepadIDs(ids []ebiten.GamepadID) []ebiten.GamepadID
+	// IsStandardLayoutAvailable 返回手柄是否支持标准布局
+	IsStandardLayoutAvailable(id ebiten.GamepadID) bool
+	// SDLID 返回手柄型号的SDL GUID，同型号的手柄相同，用于保存每个手柄的设置
+	SDLID(id ebiten.GamepadID) string
+	// Name 返回手柄名称
+	Name(id ebiten.GamepadID) string
+	// Axis 返回标准布局下摇杆轴的值，范围[-1, 1]
+	Axis(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64
+	// Pressed 返回标准布局下的按钮是否按下
+	Pressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool
+}
+
+// ebitenGamepads 通过Ebiten读取真实手柄
+type ebitenGamepads struct{}
+
+func (ebitenGamepads) AppendGamepadIDs(ids []ebiten.GamepadID) []ebiten.GamepadID {
+	return ebiten.AppendGamepadIDs(ids)
+}
+
+func (ebitenGamepads) IsStandardLayoutAvailable(id ebiten.GamepadID) bool {
+	return ebiten.IsStandardGamepadLayoutAvailable(id)
+}
+
+func (ebitenGamepads) SDLID(id ebiten.GamepadID) string {
+	return ebiten.GamepadSDLID(id)
+}
+
+func (ebitenGamepads) Name(id ebiten.GamepadID) string {
+	return ebiten.GamepadName(id)
+}
+
+func (ebitenGamepads) Axis(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+	return ebiten.StandardGamepadAxisValue(id, axis)
+}
+
+func (ebitenGamepads) Pressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	return ebiten.IsStandardGamepadButtonPressed(id, button)
+}
+
+// GamepadEvent 表示一次手柄插入或拔出
+type GamepadEvent struct {
+	name      string // 手柄名称
+	connected bool   // 插入为true，拔出为false
+}
+
+// padButtons 一个手柄所有标准按钮的按下状态
+type padButtons [ebiten.StandardGamepadButtonMax + 1]bool
+
+// padState 一个已连接手柄的状态
+type padState struct {
+	name     string
+	sdlID    string
+	standard bool       // 是否支持标准布局，不支持的手柄不读取输入
+	now      padButtons // 本帧的按钮状态
+	prev     padButtons // 上一帧的按钮状态，用于判断按钮是否刚按下
+}
+
+// pressed 返回操作a绑定的按钮中是否有按下的
+func (p *padState) pressed(a Action) bool {
+	for _, button := range bindings.buttons[a] {
+		if p.now[button] {
+			return true
+		}
+	}
+	return false
+}
+
+// justPressed 返回操作a绑定的按钮中是否有本帧刚按下的
+func (p *padState) justPressed(a Action) bool {
+	for _, button := range bindings.buttons[a] {
+		if p.now[button] && !p.prev[button] {
+			return true
+		}
+	}
+	return false
+}
+
+// GamepadInput 从所有已连接的标准布局手柄读取输入并合并，同时检测手柄的插拔
+type GamepadInput struct {
+	backend GamepadBackend
+	ids     []ebiten.GamepadID
+	pads    map[ebiten.GamepadID]*padState // 已连接的手柄
+	events  []GamepadEvent                 // 本帧发生的插拔
+}
+
+// NewGamepadInput 创建一个读取真实手柄的输入源
+func NewGamepadInput() *GamepadInput {
+	return NewGamepadInputFrom(ebitenGamepads{})
+}
+
+// NewGamepadInputFrom 创建一个从backend读取手柄的输入源
+func NewGamepadInputFrom(backend GamepadBackend) *GamepadInput {
+	return &GamepadInput{
+		backend: backend,
+		pads:    make(map[ebiten.GamepadID]*padState),
+	}
+}
+
+// Events 返回最近一次Poll检测到的手柄插拔，返回的切片在下一次Poll之前有效
+func (gp *GamepadInput) Events() []GamepadEvent {
+	return gp.events
+}
+
+// AppendJustPressedButtons 将最近一次Poll时任一标准布局手柄上刚按下的按钮按编号顺序追加到buttons后返回，
+// 不经过键位绑定，供键位设置界面捕获新的按钮
+func (gp *GamepadInput) AppendJustPressedButtons(buttons []ebiten.StandardGamepadButton) []ebiten.StandardGamepadButton {
+	var just padButtons
+	for _, id := range gp.ids {
+		if pad := gp.pads[id]; pad.standard {
+			for button := range pad.now {
+				just[button] = just[button] || pad.now[button] && !pad.prev[button]
+			}
+		}
+	}
+	for button, ok := range just {
+		if ok {
+			buttons = append(buttons, ebiten.StandardGamepadButton(button))
+		}
+	}
+	return buttons
+}
+
+// Poll 读取当前帧所有手柄的状态并合并
+func (gp *GamepadInput) Poll() sim.InputState {
+	var in sim.InputState
+	gp.ids = gp.backend.AppendGamepadIDs(gp.ids[:0])
+	gp.detectHotplug()
+	for _, id := range gp.ids {
+		if pad := gp.pads[id]; pad.standard {
+			gp.pollPad(id, pad, &in)
+		}
+	}
+	return in
+}
+
+// detectHotplug 对比本帧和上一帧的手柄列表，记录插入和拔出的手柄。
+// 拔出的手柄的ID可能在同一帧分配给新插入的手柄，因此ID相同但SDL GUID不同时也视为拔出后插入了另一个手柄
+func (gp *GamepadInput) detectHotplug() {
+	gp.events = gp.events[:0]
+	for id, pad := range gp.pads {
+		if !slices.Contains(gp.ids, id) || gp.backend.SDLID(id) != pad.sdlID {
+			delete(gp.pads, id)
+			gp.events = append(gp.events, GamepadEvent{name: pad.name, connected: false})
+			log.Printf("手柄已断开: %s", pad.name)
+		}
+	}
+	for _, id := range gp.ids {
+		if _, ok := gp.pads[id]; ok {
+			continue
+		}
+		pad := &padState{
+			name:     gp.backend.Name(id),
+			sdlID:    gp.backend.SDLID(id),
+			standard: gp.backend.IsStandardLayoutAvailable(id),
+		}
+		gp.pads[id] = pad
+		gp.events = append(gp.events, GamepadEvent{name: pad.name, connected: true})
+		if pad.standard {
+			log.Printf("手柄已连接: %s (%s)", pad.name, pad.sdlID)
+		} else {
+			log.Printf("手柄%s (%s)不支持标准布局，将被忽略", pad.name, pad.sdlID)
+		}
+	}
+}
+
+// pollPad 读取一个手柄的状态并合并到in中
+func (gp *GamepadInput) pollPad(id ebiten.GamepadID, pad *padState, in *sim.InputState) {
+	// 左摇杆
+	ax := gp.backend.Axis(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+	ay := gp.backend.Axis(id, ebiten.StandardGamepadAxisLeftStickVertical)
+	x, y := applyDeadzone(ax, ay, padDeadzone(pad.sdlID))
+	in.MoveX += x
+	in.MoveY += y
+
+	// 按键位读取按钮，默认十字键移动，A开火/确认，B返回，X炸弹，Y或RB低速，Start暂停，Back重新开始
+	pad.prev = pad.now
+	for button := range pad.now {
+		pad.now[button] = gp.backend.Pressed(id, ebiten.StandardGamepadButton(button))
+	}
+	if pad.pressed(ActionLeft) {
+		in.MoveX--
+	}
+	if pad.pressed(ActionRight) {
+		in.MoveX++
+	}
+	if pad.pressed(ActionUp) {
+		in.MoveY--
+	}
+	if pad.pressed(ActionDown) {
+		in.MoveY++
+	}
+	in.Fire = in.Fire || pad.pressed(ActionFire)
+	in.Confirm = in.Confirm || pad.pressed(ActionConfirm)
+	in.Back = in.Back || pad.pressed(ActionBack)
+	in.Restart = in.Restart || pad.pressed(ActionRestart)
+	in.Focus = in.Focus || pad.pressed(ActionFocus)
+	in.Pause = in.Pause || pad.justPressed(ActionPause)
+	in.Bomb = in.Bomb || pad.justPressed(ActionBomb)
+}
+
+// padDeadzone 返回型号为sdlID的手柄使用的死区，设置中单独指定了该型号时使用指定的值
+func padDeadzone(sdlID string) float64 {
+	if dz, ok := settings.PadDeadzones[sdlID]; ok {
+		return float64(dz) / 100
+	}
+	return float64(settings.GamepadDeadzone) / 100
+}
+
+// applyDeadzone 对摇杆偏移应用圆形死区：偏移长度不超过deadzone时视为0，
+// 超出的部分重新映射到[0, 1]，使摇杆刚离开死区时移动也是平滑的
+func applyDeadzone(x, y, deadzone float64) (float64, float64) {
+	length := math.Hypot(x, y)
+	if length <= deadzone {
+		return 0, 0
+	}
+	scale := math.Min(1, (length-deadzone)/(1-deadzone)) / length
+	return x * scale, y * scale
+}
+
+// VirtualGamepads 是GamepadBackend的内存实现，可以随时插拔手柄、按下按钮和推动摇杆，
+// 用于在测试和机器人中代替真实手柄
+type VirtualGamepads struct {
+	pads []*VirtualGamepad // 已连接的手柄，按ID排序
+}
+
+// VirtualGamepad 一个虚拟的标准布局手柄
+type VirtualGamepad struct {
+	id      ebiten.GamepadID
+	name    string
+	sdlID   string
+	buttons padButtons
+	axes    [ebiten.StandardGamepadAxisMax + 1]float64
+}
+
+// NewVirtualGamepads 创建一组没有连接任何手柄的虚拟手柄
+func NewVirtualGamepads() *VirtualGamepads {
+	return &VirtualGamepads{}
+}
+
+// Connect 插入一个新的虚拟手柄并返回它。与真实手柄一样，新手柄使用最小的空闲ID，
+// 拔出的手柄的ID会分配给之后插入的手柄
+func (v *VirtualGamepads) Connect(name, sdlID string) *VirtualGamepad {
+	i := 0
+	for i < len(v.pads) && v.pads[i].id == ebiten.GamepadID(i) {
+		i++
+	}
+	pad := &VirtualGamepad{id: ebiten.GamepadID(i), name: name, sdlID: sdlID}
+	v.pads = slices.Insert(v.pads, i, pad)
+	return pad
+}
+
+// Disconnect 拔出虚拟手柄pad
+func (v *VirtualGamepads) Disconnect(pad *VirtualGamepad) {
+	v.pads = slices.DeleteFunc(v.pads, func(p *VirtualGamepad) bool { return p == pad })
+}
+
+// find 按ID查找已连接的虚拟手柄
+func (v *VirtualGamepads) find(id ebiten.GamepadID) *VirtualGamepad {
+	for _, pad := range v.pads {
+		if pad.id == id {
+			return pad
+		}
+	}
+	return nil
+}
+
+func (v *VirtualGamepads) AppendGamepadIDs(ids []ebiten.GamepadID) []ebiten.GamepadID {
+	for _, pad := range v.pads {
+		ids = append(ids, pad.id)
+	}
+	return ids
+}
+
+func (v *VirtualGamepads) IsStandardLayoutAvailable(id ebiten.GamepadID) bool {
+	return v.find(id) != nil
+}
+
+func (v *VirtualGamepads) SDLID(id ebiten.GamepadID) string {
+	if pad := v.find(id); pad != nil {
+		return pad.sdlID
+	}
+	return ""
+}
+
+func (v *VirtualGamepads) Name(id ebiten.GamepadID) string {
+	if pad := v.find(id); pad != nil {
+		return pad.name
+	}
+	return ""
+}
+
+func (v *VirtualGamepads) Axis(id ebiten.GamepadID, axis ebiten.StandardGamepadAxis) float64 {
+	if pad := v.find(id); pad != nil {
+		return pad.axes[axis]
+	}
+	return 0
+}
+
+func (v *VirtualGamepads) Pressed(id ebiten.GamepadID, button ebiten.StandardGamepadButton) bool {
+	if pad := v.find(id); pad != nil {
+		return pad.buttons[button]
+	}
+	return false
+}
+
+// Press 按下按钮
+func (p *VirtualGamepad) Press(button ebiten.StandardGamepadButton) {
+	p.buttons[button] = true
+}
+
+// Release 松开按钮
+func (p *VirtualGamepad) Release(button ebiten.StandardGamepadButton) {
+	p.buttons[button] = false
+}
+
+// SetAxis 将摇杆轴推到value，范围[-1, 1]
+func (p *VirtualGamepad) SetAxis(axis ebiten.StandardGamepadAxis, value float64) {
+	p.axes[axis] = math.Max(-1, math.Min(1, value))
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// useSettings 在测试期间使用设置s及其键位，测试结束后恢复原来的设置
+func useSettings(t *testing.T, s Settings) {
+	oldSettings, oldBindings := settings, bindings
+	t.Cleanup(func() { settings, bindings = oldSettings, oldBindings })
+	settings, bindings = s, s.toBindings()
+}
+
+// checkEvents 检查最近一次Poll检测到的插拔是否为want
+func checkEvents(t *testing.T, gp *GamepadInput, want ...GamepadEvent) {
+	t.Helper()
+	got := gp.Events()
+	if len(got) != len(want) {
+		t.Fatalf("检测到插拔%+v，应为%+v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Errorf("第%d个插拔为%+v，应为%+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestGamepadPerPadDeadzone(t *testing.T) {
+	s := defaultSettings()
+	s.GamepadDeadzone = 25
+	s.PadDeadzones = map[string]int{"sensitive": 10, "worn": 60}
+	useSettings(t, s)
+
+	tests := []struct {
+		sdlID string
+		want  float64 // 摇杆推到0.5时的移动量
+	}{
+		{"other", (0.5 - 0.25) / 0.75},
+		{"sensitive", (0.5 - 0.1) / 0.9},
+		{"worn", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sdlID, func(t *testing.T) {
+			pads := NewVirtualGamepads()
+			pad := pads.Connect("手柄", tt.sdlID)
+			pad.SetAxis(ebiten.StandardGamepadAxisLeftStickHorizontal, 0.5)
+			in := NewGamepadInputFrom(pads).Poll()
+			if math.Abs(in.MoveX-tt.want) > 1e-9 || in.MoveY != 0 {
+				t.Errorf("摇杆推到0.5时移动(%v, %v)，应为(%v, 0)", in.MoveX, in.MoveY, tt.want)
+			}
+		})
+	}
+}
+
+func TestGamepadHotplugEvents(t *testing.T) {
+	pads := NewVirtualGamepads()
+	gp := NewGamepadInputFrom(pads)
+	gp.Poll()
+	checkEvents(t, gp)
+
+	a := pads.Connect("手柄A", "guid-a")
+	gp.Poll()
+	checkEvents(t, gp, GamepadEvent{name: "手柄A", connected: true})
+	gp.Poll()
+	checkEvents(t, gp)
+
+	// 同一帧内拔出手柄A并插入手柄B，B使用A空出来的ID
+	pads.Disconnect(a)
+	b := pads.Connect("手柄B", "guid-b")
+	if b.id != a.id {
+		t.Fatalf("手柄B的ID为%d，应复用手柄A的ID %d", b.id, a.id)
+	}
+	gp.Poll()
+	checkEvents(t, gp,
+		GamepadEvent{name: "手柄A", connected: false},
+		GamepadEvent{name: "手柄B", connected: true})
+
+	pads.Disconnect(b)
+	gp.Poll()
+	checkEvents(t, gp, GamepadEvent{name: "手柄B", connected: false})
+	gp.Poll()
+	checkEvents(t, gp)
+}
+
+func TestGamepadSwapResetsButtons(t *testing.T) {
+	useSettings(t, defaultSettings())
+	pads := NewVirtualGamepads()
+	gp := NewGamepadInputFrom(pads)
+	a := pads.Connect("手柄A", "guid-a")
+	a.Press(ebiten.StandardGamepadButtonRightLeft)
+	gp.Poll()
+
+	// 换上的手柄B按住同一个按钮，对B来说这是刚按下，应当触发炸弹
+	pads.Disconnect(a)
+	b := pads.Connect("手柄B", "guid-b")
+	b.Press(ebiten.StandardGamepadButtonRightLeft)
+	if in := gp.Poll(); !in.Bomb {
+		t.Error("换上的手柄按下炸弹键没有触发炸弹")
+	}
+}
+
+func TestGamepadBombAndFocus(t *testing.T) {
+	useSettings(t, defaultSettings())
+	pads := NewVirtualGamepads()
+	pad := pads.Connect("手柄", "guid")
+	gp := NewGamepadInputFrom(pads)
+
+	// 默认X键炸弹：按住时只在按下的那一帧触发
+	pad.Press(ebiten.StandardGamepadButtonRightLeft)
+	for frame, want := range []bool{true, false, false} {
+		if in := gp.Poll(); in.Bomb != want {
+			t.Errorf("按住炸弹键第%d帧bomb=%v，应为%v", frame+1, in.Bomb, want)
+		}
+	}
+	pad.Release(ebiten.StandardGamepadButtonRightLeft)
+	gp.Poll()
+	pad.Press(ebiten.StandardGamepadButtonRightLeft)
+	if in := gp.Poll(); !in.Bomb {
+		t.Error("松开后再次按下炸弹键没有触发炸弹")
+	}
+	pad.Release(ebiten.StandardGamepadButtonRightLeft)
+
+	// 默认Y键和RB键低速：按住期间每一帧都生效
+	for _, button := range []ebiten.StandardGamepadButton{
+		ebiten.StandardGamepadButtonRightTop,
+		ebiten.StandardGamepadButtonFrontTopRight,
+	} {
+		pad.Press(button)
+		for frame := 1; frame <= 3; frame++ {
+			if in := gp.Poll(); !in.Focus {
+				t.Errorf("按住%s第%d帧没有低速移动", padButtonNames[button], frame)
+			}
+		}
+		pad.Release(button)
+		if in := gp.Poll(); in.Focus {
+			t.Errorf("松开%s后仍在低速移动", padButtonNames[button])
+		}
+	}
+}
+
+func TestGamepadRebinding(t *testing.T) {
+	s := defaultSettings()
+	s.PadBindings = cloneBindingNames(s.PadBindings)
+	s.PadBindings["bomb"] = []string{padButtonNames[ebiten.StandardGamepadButtonFrontTopLeft]}
+	s.PadBindings["focus"] = []string{padButtonNames[ebiten.StandardGamepadButtonFrontBottomRight]}
+	useSettings(t, s)
+
+	pads := NewVirtualGamepads()
+	pad := pads.Connect("手柄", "guid")
+	gp := NewGamepadInputFrom(pads)
+
+	pad.Press(ebiten.StandardGamepadButtonRightLeft)
+	pad.Press(ebiten.StandardGamepadButtonRightTop)
+	if in := gp.Poll(); in.Bomb || in.Focus {
+		t.Errorf("改键后原来的X键和Y键仍然生效: bomb=%v focus=%v", in.Bomb, in.Focus)
+	}
+	pad.Press(ebiten.StandardGamepadButtonFrontTopLeft)
+	pad.Press(ebiten.StandardGamepadButtonFrontBottomRight)
+	if in := gp.Poll(); !in.Bomb || !in.Focus {
+		t.Errorf("改键后新绑定的按钮没有生效: bomb=%v focus=%v", in.Bomb, in.Focus)
+	}
+}
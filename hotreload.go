@@ -0,0 +1,184 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"go-play-plane/internal/sim"
+)
+
+// resourcesDir 开发模式下从磁盘加载资源的目录，目录结构与嵌入的资源相同
+const resourcesDir = "resources"
+
+// assetPollInterval 开发模式下检查资源文件改动的间隔帧数
+const assetPollInterval = 30
+
+// 各类资源文件在资源目录中的位置
+const (
+	assetFontFile     = "fonts/SourceHanSansCN-Regular.ttf"
+	assetAtlasFile    = "sprites/atlas.json"
+	assetPatternsFile = "patterns/boss.json"
+	assetBossesFile   = "bosses/bosses.json"
+	assetLevelsFile   = "levels/levels.json"
+)
+
+// AssetWatcher 在开发模式下监视磁盘上的资源目录，文件改动后把对应的资源重新加载到运行中的游戏。
+// 为了不引入额外的依赖，它按固定间隔比较文件的修改时间，而不使用系统的文件通知。
+// 重新加载失败时保留原来的资源，只在日志中报告错误，改正文件后会再次加载
+type AssetWatcher struct {
+	dir        string               // 资源目录
+	levelsPath string               // 关卡文件，默认为资源目录中的关卡文件，可由-levels参数指定
+	modTimes   map[string]time.Time // 监视的文件到上次看到的修改时间
+	timer      int                  // 距离上次检查的帧数
+}
+
+// NewAssetWatcher 创建资源监视器，并立即从磁盘加载全部资源代替嵌入的资源
+func NewAssetWatcher(dir, levelsPath string) *AssetWatcher {
+	if levelsPath == "" {
+		levelsPath = filepath.Join(dir, assetLevelsFile)
+	}
+	w := &AssetWatcher{dir: dir, levelsPath: levelsPath}
+	w.modTimes = w.scan()
+	log.Printf("开发模式：从%s加载资源并监视改动", dir)
+	w.reloadFonts()
+	w.reloadSprites()
+	w.reloadData()
+	return w
+}
+
+// Update 每隔assetPollInterval帧检查一次资源文件，重新加载有改动的资源；
+// 关卡数据被重新加载时返回true，调用者需要丢弃依据旧关卡创建的界面
+func (w *AssetWatcher) Update() (levelsChanged bool) {
+	w.timer++
+	if w.timer < assetPollInterval {
+		return false
+	}
+	w.timer = 0
+
+	times := w.scan()
+	changed := make(map[string]bool)
+	for path, t := range times {
+		if old, ok := w.modTimes[path]; !ok || !old.Equal(t) {
+			changed[w.kind(path)] = true
+		}
+	}
+	for path := range w.modTimes {
+		if _, ok := times[path]; !ok {
+			changed[w.kind(path)] = true // 文件被删除
+		}
+	}
+	w.modTimes = times
+
+	if changed["fonts"] {
+		w.reloadFonts()
+	}
+	if changed["sprites"] {
+		w.reloadSprites()
+	}
+	if changed["patterns"] || changed["bosses"] || changed["levels"] {
+		return w.reloadData()
+	}
+	return false
+}
+
+// scan 返回资源目录中所有文件和关卡文件的修改时间，无法访问的文件视为不存在
+func (w *AssetWatcher) scan() map[string]time.Time {
+	times := make(map[string]time.Time)
+	filepath.WalkDir(w.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			times[path] = info.ModTime()
+		}
+		return nil
+	})
+	if info, err := os.Stat(w.levelsPath); err == nil {
+		times[w.levelsPath] = info.ModTime()
+	}
+	return times
+}
+
+// kind 返回文件所属的资源类别，即它在资源目录中的第一级子目录名
+func (w *AssetWatcher) kind(path string) string {
+	if path == w.levelsPath {
+		return "levels"
+	}
+	rel, err := filepath.Rel(w.dir, path)
+	if err != nil {
+		return ""
+	}
+	kind, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return kind
+}
+
+// report 在日志中记录一类资源的重新加载结果
+func (w *AssetWatcher) report(what string, err error) {
+	if err != nil {
+		log.Printf("重新加载%s失败，继续使用原来的资源: %v", what, err)
+		return
+	}
+	log.Printf("已重新加载%s", what)
+}
+
+// reloadFonts 重新加载中文字体，之后绘制的文字立即使用新字体
+func (w *AssetWatcher) reloadFonts() {
+	data, err := os.ReadFile(filepath.Join(w.dir, assetFontFile))
+	if err == nil {
+		err = loadChineseFonts(data)
+	}
+	w.report("字体", err)
+}
+
+// reloadSprites 重新加载精灵图集，模组自带的贴图保持不变
+func (w *AssetWatcher) reloadSprites() {
+	sprites, err := LoadAtlas(os.DirFS(w.dir), assetAtlasFile)
+	if err == nil {
+		maps.Copy(loadedSprites, sprites)
+	}
+	w.report("精灵图集", err)
+}
+
+// reloadData 按依赖顺序重新加载弹幕脚本、BOSS定义和关卡，三者全部有效时才一起替换。
+// BOSS定义在解析时已经引用了当时的弹幕脚本，正在进行的对局继续使用原来的关卡、BOSS定义和弹幕脚本，
+// 保证开发模式下录制的录像也能重现
+func (w *AssetWatcher) reloadData() bool {
+	err := w.loadData()
+	w.report("弹幕脚本、BOSS定义和关卡", err)
+	return err == nil
+}
+
+// loadData 读取并校验弹幕脚本、BOSS定义和关卡文件，成功时替换全局数据
+func (w *AssetWatcher) loadData() error {
+	path := filepath.Join(w.dir, assetPatternsFile)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	patterns, err := sim.ParsePatterns(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	path = filepath.Join(w.dir, assetBossesFile)
+	data, err = os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	defs, err := sim.ParseBosses(data, builtinSprites, patterns)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	ls, err := sim.LoadLevels(w.levelsPath, defs)
+	if err != nil {
+		return err
+	}
+	bulletPatterns, bosses, levels = patterns, defs, ls
+	return nil
+}
@@ -0,0 +1,425 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"go-play-plane/internal/sim"
+)
+
+// Action 表示一个可以绑定按键的操作
+type Action int
+
+const (
+	ActionLeft Action = iota
+	ActionRight
+	ActionUp
+	ActionDown
+	ActionFire
+	ActionConfirm
+	ActionBack
+	ActionPause
+	ActionRestart
+	ActionBomb
+	ActionFocus
+	actionCount
+)
+
+// actionNames 操作在设置文件中的名称
+var actionNames = [actionCount]string{
+	ActionLeft:    "left",
+	ActionRight:   "right",
+	ActionUp:      "up",
+	ActionDown:    "down",
+	ActionFire:    "fire",
+	ActionConfirm: "confirm",
+	ActionBack:    "back",
+	ActionPause:   "pause",
+	ActionRestart: "restart",
+	ActionBomb:    "bomb",
+	ActionFocus:   "focus",
+}
+
+// actionLabels 操作在键位设置界面中显示的名称
+var actionLabels = [actionCount]string{
+	ActionLeft:    "左移",
+	ActionRight:   "右移",
+	ActionUp:      "上移",
+	ActionDown:    "下移",
+	ActionFire:    "开火",
+	ActionConfirm: "确认",
+	ActionBack:    "返回",
+	ActionPause:   "暂停",
+	ActionRestart: "重新开始",
+	ActionBomb:    "炸弹",
+	ActionFocus:   "低速移动",
+}
+
+// findAction 按名称查找操作
+func findAction(name string) (Action, bool) {
+	for a, n := range actionNames {
+		if n == name {
+			return Action(a), true
+		}
+	}
+	return 0, false
+}
+
+// InputContext 表示读取操作的场合，同一场合中使用的两个操作不能绑定同一个按键
+type InputContext int
+
+const (
+	ContextPlay InputContext = 1 << iota // 对局中，包括暂停和游戏结束画面
+	ContextMenu                          // 菜单中，包括通关结算画面（读取确认和返回）
+)
+
+// actionContexts 各操作在哪些场合中使用，必须与各场景实际读取的操作一致。开火和确认默认都绑定空格，
+// 但一个只在对局中、一个只在菜单和结算画面中使用，因此不算冲突
+var actionContexts = [actionCount]InputContext{
+	ActionLeft:    ContextPlay | ContextMenu,
+	ActionRight:   ContextPlay | ContextMenu,
+	ActionUp:      ContextPlay | ContextMenu,
+	ActionDown:    ContextPlay | ContextMenu,
+	ActionFire:    ContextPlay,
+	ActionConfirm: ContextMenu,
+	ActionBack:    ContextPlay | ContextMenu,
+	ActionPause:   ContextPlay,
+	ActionRestart: ContextPlay,
+	ActionBomb:    ContextPlay,
+	ActionFocus:   ContextPlay,
+}
+
+// padButtonNames 标准布局手柄按钮在设置文件和界面中的名称，按Xbox手柄的习惯命名
+var padButtonNames = map[ebiten.StandardGamepadButton]string{
+	ebiten.StandardGamepadButtonRightBottom:      "A",
+	ebiten.StandardGamepadButtonRightRight:       "B",
+	ebiten.StandardGamepadButtonRightLeft:        "X",
+	ebiten.StandardGamepadButtonRightTop:         "Y",
+	ebiten.StandardGamepadButtonFrontTopLeft:     "LB",
+	ebiten.StandardGamepadButtonFrontTopRight:    "RB",
+	ebiten.StandardGamepadButtonFrontBottomLeft:  "LT",
+	ebiten.StandardGamepadButtonFrontBottomRight: "RT",
+	ebiten.StandardGamepadButtonCenterLeft:       "Back",
+	ebiten.StandardGamepadButtonCenterRight:      "Start",
+	ebiten.StandardGamepadButtonCenterCenter:     "Home",
+	ebiten.StandardGamepadButtonLeftStick:        "LS",
+	ebiten.StandardGamepadButtonRightStick:       "RS",
+	ebiten.StandardGamepadButtonLeftTop:          "DpadUp",
+	ebiten.StandardGamepadButtonLeftBottom:       "DpadDown",
+	ebiten.StandardGamepadButtonLeftLeft:         "DpadLeft",
+	ebiten.StandardGamepadButtonLeftRight:        "DpadRight",
+}
+
+// findPadButton 按名称查找手柄按钮，不区分大小写
+func findPadButton(name string) (ebiten.StandardGamepadButton, bool) {
+	for b, n := range padButtonNames {
+		if strings.EqualFold(n, name) {
+			return b, true
+		}
+	}
+	return 0, false
+}
+
+// Bindings 每个操作绑定的键盘按键和手柄按钮，一个操作可以绑定多个按键和按钮
+type Bindings struct {
+	keys    [actionCount][]ebiten.Key
+	buttons [actionCount][]ebiten.StandardGamepadButton
+}
+
+// defaultBindings 默认键位
+var defaultBindings = Bindings{
+	keys: [actionCount][]ebiten.Key{
+		ActionLeft:    {ebiten.KeyLeft},
+		ActionRight:   {ebiten.KeyRight},
+		ActionUp:      {ebiten.KeyUp},
+		ActionDown:    {ebiten.KeyDown},
+		ActionFire:    {ebiten.KeySpace},
+		ActionConfirm: {ebiten.KeySpace, ebiten.KeyEnter},
+		ActionBack:    {ebiten.KeyEscape},
+		ActionPause:   {ebiten.KeyP},
+		ActionRestart: {ebiten.KeyR},
+		ActionBomb:    {ebiten.KeyX},
+		ActionFocus:   {ebiten.KeyShiftLeft},
+	},
+	buttons: [actionCount][]ebiten.StandardGamepadButton{
+		ActionLeft:    {ebiten.StandardGamepadButtonLeftLeft},
+		ActionRight:   {ebiten.StandardGamepadButtonLeftRight},
+		ActionUp:      {ebiten.StandardGamepadButtonLeftTop},
+		ActionDown:    {ebiten.StandardGamepadButtonLeftBottom},
+		ActionFire:    {ebiten.StandardGamepadButtonRightBottom},
+		ActionConfirm: {ebiten.StandardGamepadButtonRightBottom},
+		ActionBack:    {ebiten.StandardGamepadButtonRightRight},
+		ActionPause:   {ebiten.StandardGamepadButtonCenterRight},
+		ActionRestart: {ebiten.StandardGamepadButtonCenterLeft},
+		ActionBomb:    {ebiten.StandardGamepadButtonRightLeft},
+		ActionFocus:   {ebiten.StandardGamepadButtonRightTop, ebiten.StandardGamepadButtonFrontTopRight},
+	},
+}
+
+// bindings 当前使用的键位，由applySettings设置
+var bindings = defaultBindings
+
+// bindingLabel 返回操作a的第一个键盘按键的名称，用于操作提示；没有绑定按键时返回手柄按钮名称
+func bindingLabel(a Action) string {
+	if keys := bindings.keys[a]; len(keys) > 0 {
+		return keys[0].String()
+	}
+	if buttons := bindings.buttons[a]; len(buttons) > 0 {
+		return padButtonNames[buttons[0]]
+	}
+	return "?"
+}
+
+// BindingConflict 描述两个在同一场合中使用的操作绑定了同一个按键或按钮，
+// 或者菜单中使用的操作绑定了固定用于选择菜单项的数字键
+type BindingConflict struct {
+	a, b       Action
+	menuChoice bool   // 为true时a与数字键选择菜单项冲突，b无效
+	binding    string // 冲突的按键或按钮名称
+}
+
+// Error 返回冲突的描述
+func (c BindingConflict) Error() string {
+	if c.menuChoice {
+		return fmt.Sprintf("%s绑定了用于选择菜单项的%s", actionLabels[c.a], c.binding)
+	}
+	return fmt.Sprintf("%s和%s都绑定了%s", actionLabels[c.a], actionLabels[c.b], c.binding)
+}
+
+// Conflicts 返回所有冲突，按操作顺序排列
+func (b *Bindings) Conflicts() []BindingConflict {
+	var conflicts []BindingConflict
+	for x := Action(0); x < actionCount; x++ {
+		for y := x + 1; y < actionCount; y++ {
+			if actionContexts[x]&actionContexts[y] == 0 {
+				continue
+			}
+			for _, key := range b.keys[x] {
+				if slices.Contains(b.keys[y], key) {
+					conflicts = append(conflicts, BindingConflict{a: x, b: y, binding: key.String()})
+				}
+			}
+			for _, button := range b.buttons[x] {
+				if slices.Contains(b.buttons[y], button) {
+					conflicts = append(conflicts, BindingConflict{a: x, b: y, binding: padButtonNames[button]})
+				}
+			}
+		}
+		if actionContexts[x]&ContextMenu == 0 {
+			continue
+		}
+		for _, key := range b.keys[x] {
+			if slices.Contains(menuChoiceKeys[:], key) {
+				conflicts = append(conflicts, BindingConflict{a: x, menuChoice: true, binding: key.String()})
+			}
+		}
+	}
+	return conflicts
+}
+
+// KeyboardBackend 提供键盘的原始状态。KeyboardInput通过它读取键盘，
+// 测试和机器人可以用VirtualKeyboard代替真实键盘
+type KeyboardBackend interface {
+	// AppendPressedKeys 将所有按下的按键追加到keys后返回
+	AppendPressedKeys(keys []ebiten.Key) []ebiten.Key
+}
+
+// ebitenKeyboard 通过Ebiten读取真实键盘
+type ebitenKeyboard struct{}
+
+func (ebitenKeyboard) AppendPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	return inpututil.AppendPressedKeys(keys)
+}
+
+// keyStates 所有按键的按下状态
+type keyStates [ebiten.KeyMax + 1]bool
+
+// menuChoiceKeys 固定用于选择菜单项的数字键，第i个键选择第i+1项，不参与改键
+var menuChoiceKeys = [...]ebiten.Key{ebiten.Key1, ebiten.Key2, ebiten.Key3}
+
+// KeyboardInput 按当前键位从键盘读取输入
+type KeyboardInput struct {
+	backend KeyboardBackend
+	keys    []ebiten.Key
+	now     keyStates // 本帧的按键状态
+	prev    keyStates // 上一帧的按键状态，用于判断按键是否刚按下
+}
+
+// NewKeyboardInput 创建一个读取真实键盘的输入源
+func NewKeyboardInput() *KeyboardInput {
+	return NewKeyboardInputFrom(ebitenKeyboard{})
+}
+
+// NewKeyboardInputFrom 创建一个从backend读取键盘的输入源
+func NewKeyboardInputFrom(backend KeyboardBackend) *KeyboardInput {
+	return &KeyboardInput{backend: backend}
+}
+
+// pressed 返回操作a绑定的按键中是否有按下的
+func (k *KeyboardInput) pressed(a Action) bool {
+	for _, key := range bindings.keys[a] {
+		if k.now[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// justPressed 返回操作a绑定的按键中是否有本帧刚按下的
+func (k *KeyboardInput) justPressed(a Action) bool {
+	for _, key := range bindings.keys[a] {
+		if k.now[key] && !k.prev[key] {
+			return true
+		}
+	}
+	return false
+}
+
+// AppendJustPressedKeys 将最近一次Poll时刚按下的按键按编号顺序追加到keys后返回，
+// 不经过键位绑定，供键位设置界面捕获新的按键
+func (k *KeyboardInput) AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	for key := range k.now {
+		if k.now[key] && !k.prev[key] {
+			keys = append(keys, ebiten.Key(key))
+		}
+	}
+	return keys
+}
+
+// Poll 读取当前帧的键盘状态
+func (k *KeyboardInput) Poll() sim.InputState {
+	k.prev = k.now
+	k.now = keyStates{}
+	k.keys = k.backend.AppendPressedKeys(k.keys[:0])
+	for _, key := range k.keys {
+		if key >= 0 && key <= ebiten.KeyMax {
+			k.now[key] = true
+		}
+	}
+
+	var in sim.InputState
+	if k.pressed(ActionLeft) {
+		in.MoveX--
+	}
+	if k.pressed(ActionRight) {
+		in.MoveX++
+	}
+	if k.pressed(ActionUp) {
+		in.MoveY--
+	}
+	if k.pressed(ActionDown) {
+		in.MoveY++
+	}
+	in.Fire = k.pressed(ActionFire)
+	in.Confirm = k.pressed(ActionConfirm)
+	in.Back = k.pressed(ActionBack)
+	in.Pause = k.justPressed(ActionPause)
+	in.Restart = k.pressed(ActionRestart)
+	in.Bomb = k.justPressed(ActionBomb)
+	in.Focus = k.pressed(ActionFocus)
+	// 数字键固定用于选择菜单项，不参与改键
+	for i, key := range menuChoiceKeys {
+		if k.now[key] {
+			in.MenuChoice = i + 1
+			break
+		}
+	}
+	return in
+}
+
+// VirtualKeyboard 是KeyboardBackend的内存实现，用于在测试和机器人中代替真实键盘
+type VirtualKeyboard struct {
+	keys keyStates
+}
+
+// NewVirtualKeyboard 创建一个没有按下任何按键的虚拟键盘
+func NewVirtualKeyboard() *VirtualKeyboard {
+	return &VirtualKeyboard{}
+}
+
+func (v *VirtualKeyboard) AppendPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	for key, pressed := range v.keys {
+		if pressed {
+			keys = append(keys, ebiten.Key(key))
+		}
+	}
+	return keys
+}
+
+// Press 按下按键
+func (v *VirtualKeyboard) Press(key ebiten.Key) {
+	v.keys[key] = true
+}
+
+// Release 松开按键
+func (v *VirtualKeyboard) Release(key ebiten.Key) {
+	v.keys[key] = false
+}
+
+// MouseInput 从鼠标读取指针位置和点击
+type MouseInput struct{}
+
+// NewMouseInput 创建一个鼠标输入源
+func NewMouseInput() *MouseInput {
+	return &MouseInput{}
+}
+
+// Poll 读取当前帧的鼠标状态
+func (m *MouseInput) Poll() sim.InputState {
+	x, y := ebiten.CursorPosition()
+	return sim.InputState{
+		HasCursor: true,
+		CursorX:   x,
+		CursorY:   y,
+		Click:     ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft),
+	}
+}
+
+// MultiInput 将多个输入源合并为一个
+type MultiInput struct {
+	sources []sim.InputSource
+}
+
+// NewMultiInput 创建一个合并多个输入源的输入源
+func NewMultiInput(sources ...sim.InputSource) *MultiInput {
+	return &MultiInput{sources: sources}
+}
+
+// Poll 轮询所有输入源并合并结果，移动量会被限制在[-1, 1]范围内
+func (m *MultiInput) Poll() sim.InputState {
+	var in sim.InputState
+	for _, src := range m.sources {
+		in = mergeInput(in, src.Poll())
+	}
+	in.MoveX = math.Max(-1, math.Min(1, in.MoveX))
+	in.MoveY = math.Max(-1, math.Min(1, in.MoveY))
+	return in
+}
+
+// mergeInput 合并两份操作快照
+func mergeInput(a, b sim.InputState) sim.InputState {
+	a.MoveX += b.MoveX
+	a.MoveY += b.MoveY
+	a.Fire = a.Fire || b.Fire
+	a.Confirm = a.Confirm || b.Confirm
+	a.Back = a.Back || b.Back
+	a.Pause = a.Pause || b.Pause
+	a.Restart = a.Restart || b.Restart
+	a.Bomb = a.Bomb || b.Bomb
+	a.Focus = a.Focus || b.Focus
+	if a.MenuChoice == 0 {
+		a.MenuChoice = b.MenuChoice
+	}
+	if b.HasCursor {
+		a.HasCursor = true
+		a.CursorX = b.CursorX
+		a.CursorY = b.CursorY
+		a.Click = a.Click || b.Click
+		a.Touch = a.Touch || b.Touch
+	}
+	return a
+}
@@ -0,0 +1,47 @@
+// Package jsondata 解析游戏的JSON数据文件和配置文件，出错时给出出错位置
+package jsondata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Decode 严格解析数据文件，拼错的字段名会被当作错误报告，而不是被悄悄忽略；
+// 出错时在错误信息前标注出错位置的行号和列号
+func Decode(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+	if err == nil {
+		return nil
+	}
+
+	// 语法和类型错误自带准确的偏移；未知字段错误没有偏移，取该字段名第一次出现的位置；
+	// 其余错误以解码器停下的位置为准
+	offset := dec.InputOffset()
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	} else if field, ok := strings.CutPrefix(err.Error(), "json: unknown field "); ok {
+		if i := bytes.Index(data, []byte(field)); i >= 0 {
+			offset = int64(i)
+		}
+	}
+	line, col := textPosition(data, offset)
+	return fmt.Errorf("第%d行第%d列: %w", line, col, err)
+}
+
+// textPosition 将字节偏移转换为从1开始的行号和列号
+func textPosition(data []byte, offset int64) (line, col int) {
+	offset = max(0, min(offset, int64(len(data))))
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = len([]rune(string(before[bytes.LastIndexByte(before, '\n')+1:]))) + 1
+	return line, col
+}
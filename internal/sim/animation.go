@@ -0,0 +1,61 @@
+package sim
+
+// 精灵图集中约定的动画名称
+const (
+	AnimIdle      = "idle"      // 待机，循环播放
+	AnimBankLeft  = "bankLeft"  // 向左倾斜
+	AnimBankRight = "bankRight" // 向右倾斜
+	AnimHit       = "hit"       // 受击闪烁，播放完后回到当前的基础动画
+	AnimExplode   = "explode"   // 爆炸，由爆炸效果播放
+)
+
+// hitFlashTicks 受击闪烁的持续帧数
+const hitFlashTicks = 6
+
+// bankThreshold 水平输入超过该值时玩家飞机显示倾斜动画
+const bankThreshold = 0.3
+
+// Animator 记录实体正在播放的动画和播放进度。它只保存状态、随模拟逐帧推进，
+// 不涉及任何图像；渲染时按精灵图集中同名动画的帧信息选择要绘制的帧
+type Animator struct {
+	anim  string // 当前的基础动画，为空时视为待机
+	time  int    // 基础动画已播放的帧数
+	flash int    // 受击闪烁剩余的帧数
+}
+
+// Play 切换基础动画，切换到不同的动画时从第一帧开始播放
+func (a *Animator) Play(name string) {
+	if a.anim != name {
+		a.anim = name
+		a.time = 0
+	}
+}
+
+// Hit 开始播放受击闪烁，闪烁期间再次受击会重新开始
+func (a *Animator) Hit() {
+	a.flash = hitFlashTicks
+}
+
+// Update 将动画推进一帧
+func (a *Animator) Update() {
+	a.time++
+	if a.flash > 0 {
+		a.flash--
+	}
+}
+
+// Current 返回当前应显示的动画名称和该动画已播放的帧数
+func (a *Animator) Current() (string, int) {
+	if a.flash > 0 {
+		return AnimHit, hitFlashTicks - a.flash
+	}
+	return a.Base()
+}
+
+// Base 返回不考虑受击闪烁时的基础动画名称和已播放的帧数
+func (a *Animator) Base() (string, int) {
+	if a.anim == "" {
+		return AnimIdle, a.time
+	}
+	return a.anim, a.time
+}
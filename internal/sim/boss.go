@@ -0,0 +1,333 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+	"sort"
+
+	"go-play-plane/internal/jsondata"
+)
+
+// bossHomeY BOSS入场后停留的高度，正弦和追踪移动也围绕这个高度上下摆动
+const bossHomeY = 80
+
+// BossDef 描述一种BOSS的外观、判定、血量和各个阶段
+type BossDef struct {
+	Name   string      `json:"name"`   // BOSS名称
+	Sprite string      `json:"sprite"` // 贴图名称，可以使用的贴图由解析时传入的sprites决定
+	Tint   []float64   `json:"tint"`   // 贴图着色[r, g, b]，省略时不着色
+	Width  int         `json:"width"`  // 宽度
+	Height int         `json:"height"` // 高度
+	Hitbox string      `json:"hitbox"` // 判定形状：rect（默认）或circle
+	Health int         `json:"health"` // 血量
+	Phases []BossPhase `json:"phases"` // 按顺序进入的各个阶段
+	Volley string      `json:"volley"` // 发射弹幕的音效，见volleySounds，省略时使用通用的音效
+
+	SpriteKey string `json:"-"` // 由Sprite解析得到的贴图键，渲染时据此查找图像
+
+	id     string // BOSS的ID
+	shape  Shape  // 由Hitbox解析得到的判定形状
+	volley Sound  // 由Volley解析得到的音效
+}
+
+// BossPhase 描述BOSS的一个阶段，血量比例降到UntilHealth或持续Duration帧后进入下一阶段
+type BossPhase struct {
+	UntilHealth  float64      `json:"untilHealth"`  // 血量比例不高于该值时进入下一阶段，0表示不按血量切换
+	Duration     int          `json:"duration"`     // 持续该帧数后进入下一阶段，0表示不按时间切换
+	Movement     BossMovement `json:"movement"`     // 移动方式
+	Patterns     []string     `json:"patterns"`     // 弹幕脚本名称，多于一个时每次随机选择
+	FireInterval int          `json:"fireInterval"` // 射击间隔（帧）
+
+	patterns []*BulletPattern // 由Patterns解析得到的弹幕脚本，重新加载脚本不影响已经解析的BOSS定义
+}
+
+// BossMovement 描述BOSS的移动方式
+type BossMovement struct {
+	Type      string  `json:"type"`      // bounce、sine、track或dash
+	Speed     float64 `json:"speed"`     // 水平移动速度
+	Amplitude float64 `json:"amplitude"` // sine、track的上下摆动幅度；dash的最大水平速度
+	Period    float64 `json:"period"`    // sine、track的摆动周期系数；dash改变方向的间隔帧数
+}
+
+// bossMovementTypes BOSS可以使用的移动方式
+var bossMovementTypes = []string{"bounce", "sine", "track", "dash"}
+
+// ParseBosses 解析并校验BOSS定义文件，文件内容为BOSS ID到定义的映射，
+// sprites为可以使用的贴图名称与贴图键的对应关系，阶段中的弹幕脚本从patterns中查找
+func ParseBosses(data []byte, sprites map[string]string, patterns map[string]*BulletPattern) (map[string]*BossDef, error) {
+	var defs map[string]*BossDef
+	if err := jsondata.Decode(data, &defs); err != nil {
+		return nil, fmt.Errorf("解析BOSS定义失败: %w", err)
+	}
+
+	// 按ID顺序校验，保证错误信息的顺序稳定
+	ids := make([]string, 0, len(defs))
+	for id := range defs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var errs []error
+	for _, id := range ids {
+		if defs[id] == nil {
+			errs = append(errs, fmt.Errorf("BOSS %s: 定义为空", id))
+			continue
+		}
+		defs[id].id = id
+		errs = append(errs, defs[id].validate(sprites, patterns)...)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return defs, nil
+}
+
+// validate 校验BOSS定义并补全省略的字段，返回发现的所有问题
+func (d *BossDef) validate(sprites map[string]string, patterns map[string]*BulletPattern) []error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf("BOSS %s: %s", d.id, fmt.Sprintf(format, args...)))
+	}
+
+	if d.Name == "" {
+		fail("缺少name")
+	}
+	if d.Sprite == "" {
+		d.Sprite = "boss"
+	}
+	if key, ok := sprites[d.Sprite]; ok {
+		d.SpriteKey = key
+	} else {
+		fail("未知的sprite %q", d.Sprite)
+	}
+	if d.Tint != nil && len(d.Tint) != 3 {
+		fail("tint必须是[r, g, b]")
+	}
+	if d.Width <= 0 || d.Height <= 0 {
+		fail("width和height必须大于0")
+	}
+	switch d.Hitbox {
+	case "", "rect":
+		d.shape = ShapeRect
+	case "circle":
+		d.shape = ShapeCircle
+	default:
+		fail("未知的hitbox %q", d.Hitbox)
+	}
+	if d.Health <= 0 {
+		fail("health必须大于0")
+	}
+	if d.Volley == "" {
+		d.volley = SoundVolley
+	} else if sound, ok := volleySounds[d.Volley]; ok {
+		d.volley = sound
+	} else {
+		fail("未知的volley %q", d.Volley)
+	}
+	if len(d.Phases) == 0 {
+		fail("至少需要一个阶段")
+	}
+
+	for i := range d.Phases {
+		phase := &d.Phases[i]
+		last := i == len(d.Phases)-1
+		if phase.UntilHealth < 0 || phase.UntilHealth >= 1 {
+			fail("phases[%d]: untilHealth必须在0到1之间", i)
+		}
+		if phase.Duration < 0 {
+			fail("phases[%d]: duration不能为负数", i)
+		}
+		if !last && phase.UntilHealth == 0 && phase.Duration == 0 {
+			fail("phases[%d]: 需要untilHealth或duration才能进入下一阶段", i)
+		}
+		if !slices.Contains(bossMovementTypes, phase.Movement.Type) {
+			fail("phases[%d]: 未知的movement.type %q", i, phase.Movement.Type)
+		} else if phase.Movement.Type != "bounce" && phase.Movement.Period <= 0 {
+			fail("phases[%d]: movement.period必须大于0", i)
+		}
+		if len(phase.Patterns) == 0 {
+			fail("phases[%d]: 至少需要一个弹幕脚本", i)
+		}
+		phase.patterns = phase.patterns[:0]
+		for _, name := range phase.Patterns {
+			if pattern := patterns[name]; pattern != nil {
+				phase.patterns = append(phase.patterns, pattern)
+			} else {
+				fail("phases[%d]: 弹幕脚本%q不存在", i, name)
+			}
+		}
+		if phase.FireInterval <= 0 {
+			fail("phases[%d]: fireInterval必须大于0", i)
+		}
+	}
+	return errs
+}
+
+// Boss 表示关卡BOSS
+type Boss struct {
+	Body
+	speedX      float64
+	speedY      float64
+	Health      int        // 当前血量
+	MaxHealth   int        // 最大血量
+	Def         *BossDef   // BOSS定义
+	Phase       int        // 当前阶段，从1开始，对应def.Phases[phase-1]
+	phaseTimer  int        // 进入当前阶段后经过的帧数
+	AnimTimer   int        // 动画计时器
+	shootTimer  int        // 射击计时器
+	patternTime int        // 突进移动改变方向的计时器
+	enterScene  bool       // 是否正在入场
+	rng         *rand.Rand // 本局共享的随机数生成器
+	Field       Playfield  // BOSS活动的场地
+}
+
+// NewBoss 按定义在场地field上方正中创建一个新的BOSS，rng用于随机移动和弹幕选择
+func NewBoss(def *BossDef, rng *rand.Rand, field Playfield) *Boss {
+	speed := def.Phases[0].Movement.Speed
+	return &Boss{
+		Body: Body{
+			X:      float64(field.Width/2 - def.Width/2),
+			Y:      -float64(def.Height), // 从屏幕上方进入
+			Width:  def.Width,
+			Height: def.Height,
+			active: true,
+			Shape:  def.shape,
+		},
+		speedX:      speed,
+		speedY:      speed,
+		Health:      def.Health,
+		MaxHealth:   def.Health,
+		Def:         def,
+		Phase:       1,
+		AnimTimer:   0,
+		shootTimer:  0,
+		patternTime: 0,
+		enterScene:  true,
+		rng:         rng,
+		Field:       field,
+	}
+}
+
+// Layer 返回BOSS所在的碰撞层
+func (b *Boss) Layer() EntityLayer {
+	return LayerBoss
+}
+
+// currentPhase 返回当前阶段的定义
+func (b *Boss) currentPhase() *BossPhase {
+	return &b.Def.Phases[b.Phase-1]
+}
+
+// Update 更新BOSS状态，返回本帧是否发射了弹幕
+func (b *Boss) Update(player *Player, bulletManager *EnemyBulletManager) bool {
+	b.Anim.Update()
+	b.AnimTimer++
+	b.patternTime++
+
+	// 入场动画
+	if b.enterScene {
+		if b.Y < bossHomeY {
+			b.Y += 2
+		} else {
+			b.enterScene = false
+		}
+		return false
+	}
+
+	// 血量或时间达到条件时进入下一阶段，每帧最多切换一次
+	b.phaseTimer++
+	phase := b.currentPhase()
+	healthPercent := float64(b.Health) / float64(b.MaxHealth)
+	if b.Phase < len(b.Def.Phases) &&
+		((phase.UntilHealth > 0 && healthPercent <= phase.UntilHealth) ||
+			(phase.Duration > 0 && b.phaseTimer >= phase.Duration)) {
+		b.Phase++
+		b.phaseTimer = 0
+		next := b.currentPhase()
+		// 移动方式改变时重新设置速度，相同时保持原有的移动状态
+		if next.Movement != phase.Movement {
+			b.speedX = math.Copysign(next.Movement.Speed, b.speedX)
+			b.speedY = next.Movement.Speed
+			b.patternTime = 0
+		}
+		phase = next
+	}
+
+	b.move(player, phase.Movement)
+
+	// 发射子弹
+	b.shootTimer++
+	if b.shootTimer >= phase.FireInterval {
+		// 有多个弹幕脚本时随机选择一个
+		pattern := phase.patterns[0]
+		if len(phase.patterns) > 1 {
+			pattern = phase.patterns[b.rng.Intn(len(phase.patterns))]
+		}
+		b.firePattern(bulletManager, pattern)
+		b.shootTimer = 0
+		return true
+	}
+	return false
+}
+
+// move 按移动方式m移动BOSS。其中的乘积都显式舍入，使各平台的结果相同，见simmath.go
+func (b *Boss) move(player *Player, m BossMovement) {
+	switch m.Type {
+	case "bounce":
+		// 在屏幕上方左右移动
+		b.X += b.speedX
+		if b.X <= 0 || b.X+float64(b.Width) >= float64(b.Field.Width) {
+			b.speedX = -b.speedX
+		}
+
+	case "sine":
+		// 左右移动的同时上下摆动
+		b.X += b.speedX
+		if b.X <= 0 || b.X+float64(b.Width) >= float64(b.Field.Width) {
+			b.speedX = -b.speedX
+		}
+		b.Y = bossHomeY + float64(simSin(float64(b.AnimTimer)/m.Period)*m.Amplitude)
+
+	case "track":
+		// 追踪玩家
+		targetX := player.X + float64(player.Width/2) - float64(b.Width/2)
+		targetX = math.Max(0, math.Min(targetX, float64(b.Field.Width-b.Width)))
+
+		if b.X < targetX {
+			b.X += b.speedX
+		} else if b.X > targetX {
+			b.X -= b.speedX
+		}
+
+		// 保持在一定距离内
+		b.Y = bossHomeY + float64(simSin(float64(b.AnimTimer)/m.Period)*m.Amplitude)
+
+	case "dash":
+		// 随机突进，每隔Period帧随机改变运动方向
+		if float64(b.patternTime) > m.Period {
+			b.speedX = float64(b.rng.Float64()*(2*m.Amplitude)) - m.Amplitude
+			b.speedY = float64(b.rng.Float64()*m.Amplitude) - m.Amplitude/2
+			b.patternTime = 0
+		}
+
+		b.X += b.speedX
+		b.Y += b.speedY
+
+		// 边界检查
+		if b.X <= 0 || b.X+float64(b.Width) >= float64(b.Field.Width) {
+			b.speedX = -b.speedX
+		}
+
+		if b.Y <= 30 || b.Y+float64(b.Height) >= float64(b.Field.Height)/2 {
+			b.speedY = -b.speedY
+		}
+	}
+}
+
+// firePattern 从BOSS中心执行弹幕脚本pattern
+func (b *Boss) firePattern(bulletManager *EnemyBulletManager, pattern *BulletPattern) {
+	bulletManager.RunPattern(pattern, b, b.Phase, b.AnimTimer)
+}
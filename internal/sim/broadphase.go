@@ -0,0 +1,72 @@
+package sim
+
+import (
+	"math"
+	"slices"
+)
+
+// gridCellSize 均匀网格的单元格边长，取与普通敌机相同的尺寸
+const gridCellSize = 32
+
+// UniformGrid 是覆盖整个屏幕的均匀网格，用于碰撞检测的粗筛阶段。
+// 屏幕外的实体会被归入边缘单元格，因此不会漏检。
+type UniformGrid struct {
+	cellSize float64
+	cols     int
+	rows     int
+	cells    [][]int32 // 每个单元格中实体在插入顺序中的下标
+}
+
+// NewUniformGrid 创建覆盖width×height区域的均匀网格
+func NewUniformGrid(width, height int, cellSize float64) *UniformGrid {
+	cols := int(math.Ceil(float64(width) / cellSize))
+	rows := int(math.Ceil(float64(height) / cellSize))
+	return &UniformGrid{
+		cellSize: cellSize,
+		cols:     cols,
+		rows:     rows,
+		cells:    make([][]int32, cols*rows),
+	}
+}
+
+// Clear 清空网格，保留单元格的底层数组以便复用
+func (g *UniformGrid) Clear() {
+	for i := range g.cells {
+		g.cells[i] = g.cells[i][:0]
+	}
+}
+
+// cellRange 返回矩形覆盖的单元格范围（闭区间），超出网格的部分会被截断到边缘
+func (g *UniformGrid) cellRange(r Rect) (x0, y0, x1, y1 int) {
+	clamp := func(v float64, n int) int {
+		c := int(math.Floor(v / g.cellSize))
+		return max(0, min(c, n-1))
+	}
+	return clamp(r.x, g.cols), clamp(r.y, g.rows), clamp(r.x+r.w, g.cols), clamp(r.y+r.h, g.rows)
+}
+
+// Insert 将下标为idx、区域为r的实体插入网格
+func (g *UniformGrid) Insert(idx int, r Rect) {
+	x0, y0, x1, y1 := g.cellRange(r)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			cell := y*g.cols + x
+			g.cells[cell] = append(g.cells[cell], int32(idx))
+		}
+	}
+}
+
+// Query 将与r可能相交的实体下标按升序、去重后追加到dst中
+func (g *UniformGrid) Query(r Rect, dst []int32) []int32 {
+	start := len(dst)
+	x0, y0, x1, y1 := g.cellRange(r)
+	for y := y0; y <= y1; y++ {
+		for x := x0; x <= x1; x++ {
+			dst = append(dst, g.cells[y*g.cols+x]...)
+		}
+	}
+	// 保持与逐一比较相同的处理顺序，保证模拟结果确定
+	found := dst[start:]
+	slices.Sort(found)
+	return append(dst[:start], slices.Compact(found)...)
+}
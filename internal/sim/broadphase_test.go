@@ -0,0 +1,114 @@
+package sim
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// testEntity 只有碰撞外形和所在层的测试实体
+type testEntity struct {
+	Body
+	layer EntityLayer
+}
+
+func (e *testEntity) Layer() EntityLayer {
+	return e.layer
+}
+
+// appendTestEntities 在场地内外随机放置n个边长为size的实体，约有一成落在场地外
+func appendTestEntities(entities []Entity, rng *rand.Rand, n int, layer EntityLayer, size int) []Entity {
+	for i := 0; i < n; i++ {
+		entities = append(entities, &testEntity{
+			Body: Body{
+				X:      rng.Float64()*704 - 32,
+				Y:      rng.Float64()*544 - 32,
+				Width:  size,
+				Height: size,
+				active: true,
+			},
+			layer: layer,
+		})
+	}
+	return entities
+}
+
+// denseScene 返回一个弹幕密集的场景：2000颗玩家子弹、2000颗敌机子弹、40架敌机和1架玩家飞机
+func denseScene() []Entity {
+	rng := rand.New(rand.NewSource(1))
+	var entities []Entity
+	entities = appendTestEntities(entities, rng, 2000, LayerPlayerBullet, 4)
+	entities = appendTestEntities(entities, rng, 40, LayerEnemy, 32)
+	entities = appendTestEntities(entities, rng, 2000, LayerEnemyBullet, 6)
+	entities = appendTestEntities(entities, rng, 1, LayerPlayer, 32)
+	return entities
+}
+
+// pairWorld 返回一个把每对相交实体追加到pairs的碰撞世界
+func pairWorld(pairs *[][2]Entity) *CollisionWorld {
+	w := NewCollisionWorld(640, 480)
+	record := func(a, b *testEntity) {
+		*pairs = append(*pairs, [2]Entity{a, b})
+	}
+	OnCollision(w, LayerPlayerBullet, LayerEnemy, record)
+	OnCollision(w, LayerEnemyBullet, LayerPlayer, record)
+	OnCollision(w, LayerPlayerBullet, LayerEnemyBullet, record)
+	return w
+}
+
+// dispatchBruteForce 与Dispatch相同，但不论实体多少都逐一比较每一对实体，作为网格粗筛的对照
+func dispatchBruteForce(w *CollisionWorld, entities []Entity) {
+	for layer, list := range w.layers {
+		w.layers[layer] = list[:0]
+	}
+	for _, e := range entities {
+		if e.Active() {
+			w.layers[e.Layer()] = append(w.layers[e.Layer()], e)
+		}
+	}
+	for _, rule := range w.rules {
+		for _, ea := range w.layers[rule.a] {
+			for _, eb := range w.layers[rule.b] {
+				w.test(rule, ea, eb)
+			}
+		}
+	}
+}
+
+func TestDispatchGridMatchesBruteForce(t *testing.T) {
+	entities := denseScene()
+
+	var grid, brute [][2]Entity
+	pairWorld(&grid).Dispatch(entities)
+	dispatchBruteForce(pairWorld(&brute), entities)
+
+	if len(brute) == 0 {
+		t.Fatal("测试场景中没有相交的实体")
+	}
+	// 网格粗筛必须与逐一比较得到相同的碰撞对，且调用处理函数的顺序也相同，否则模拟结果会改变
+	if !slices.Equal(grid, brute) {
+		t.Errorf("网格粗筛得到%d个碰撞对，逐一比较得到%d个，或两者顺序不同", len(grid), len(brute))
+	}
+}
+
+func BenchmarkDispatchGrid(b *testing.B) {
+	entities := denseScene()
+	var pairs [][2]Entity
+	w := pairWorld(&pairs)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pairs = pairs[:0]
+		w.Dispatch(entities)
+	}
+}
+
+func BenchmarkDispatchBruteForce(b *testing.B) {
+	entities := denseScene()
+	var pairs [][2]Entity
+	w := pairWorld(&pairs)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		pairs = pairs[:0]
+		dispatchBruteForce(w, entities)
+	}
+}
@@ -0,0 +1,42 @@
+package sim
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// 测试使用的内置数据，从仓库的resources目录读取，与游戏嵌入的文件相同
+
+// builtinSprites 内置贴图名称与贴图键的对应关系
+var builtinSprites = map[string]string{
+	"boss":   "boss",
+	"enemy":  "enemy",
+	"player": "player",
+}
+
+var (
+	defaultPatternsJSON = readResource("patterns/boss.json")
+	defaultBossesJSON   = readResource("bosses/bosses.json")
+	defaultLevelsJSON   = readResource("levels/levels.json")
+
+	bulletPatterns = mustParse(ParsePatterns(defaultPatternsJSON))
+	bosses         = mustParse(ParseBosses(defaultBossesJSON, builtinSprites, bulletPatterns))
+	levels         = mustParse(ParseLevels(defaultLevelsJSON, bosses))
+)
+
+// readResource 读取resources目录下的文件
+func readResource(name string) []byte {
+	data, err := os.ReadFile(filepath.Join("..", "..", "resources", filepath.FromSlash(name)))
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// mustParse 返回解析内置数据的结果，内置数据无效时测试无法进行
+func mustParse[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
@@ -0,0 +1,127 @@
+package sim
+
+// Bullet 表示玩家发射的子弹
+type Bullet struct {
+	Body
+	speed float64
+}
+
+// NewBullet 返回一颗新子弹的初始状态，由BulletManager放入对象池中的槽位
+func NewBullet(x, y float64) Bullet {
+	return Bullet{
+		Body: Body{
+			X:      x,
+			Y:      y,
+			Width:  4,
+			Height: 10,
+			active: true,
+		},
+		speed: 8,
+	}
+}
+
+// Update 更新子弹的状态
+func (b *Bullet) Update() {
+	b.Anim.Update()
+
+	// 向上移动
+	b.Y -= b.speed
+
+	// 如果飞出屏幕外，标记为非活动状态
+	if b.Y < -float64(b.Height) {
+		b.active = false
+	}
+}
+
+// Layer 返回玩家子弹所在的碰撞层
+func (b *Bullet) Layer() EntityLayer {
+	return LayerPlayerBullet
+}
+
+// BulletManager 管理所有子弹
+type BulletManager struct {
+	Bullets       []*Bullet
+	pool          *Pool[Bullet] // 子弹对象池
+	shootTimer    int
+	shootInterval int
+	field         Playfield // 全屏攻击覆盖的场地
+}
+
+// NewBulletManager 创建一个新的子弹管理器
+func NewBulletManager(field Playfield) *BulletManager {
+	return &BulletManager{
+		Bullets:       make([]*Bullet, 0, 256),
+		pool:          NewPool[Bullet](128, 256),
+		shootTimer:    0,
+		shootInterval: 10, // 每10帧可以发射一颗子弹
+		field:         field,
+	}
+}
+
+// Add 从对象池取出一个槽位存放子弹b并返回
+func (bm *BulletManager) Add(b Bullet) *Bullet {
+	slot := bm.pool.Get()
+	*slot = b
+	bm.Bullets = append(bm.Bullets, slot)
+	return slot
+}
+
+// Update 更新所有子弹的状态，fire表示本帧是否按下开火，返回本帧是否发射了新子弹
+func (bm *BulletManager) Update(player *Player, fire bool) bool {
+	// 更新现有子弹
+	for _, bullet := range bm.Bullets {
+		bullet.Update()
+	}
+	// 移除非活动子弹
+	bm.Compact()
+
+	// 发射新子弹
+	bm.shootTimer++
+	if fire && bm.shootTimer >= bm.shootInterval {
+		// 从玩家飞机的中心位置发射子弹
+		bulletX := player.X + float64(player.Width)/2 - 2
+		bulletY := player.Y
+
+		// 根据玩家能力状态决定发射的子弹
+		if player.screenShotEnabled {
+			// 全屏攻击：发射一排子弹
+			for x := float64(0); x < float64(bm.field.Width); x += 32 {
+				bm.Add(NewBullet(x, bulletY))
+			}
+		} else {
+			// 根据多弹道数量发射子弹
+			offset := 10.0
+			// 永久性多弹道：根据累积的数量发射多发子弹
+			for i := 0; i <= player.multiShotCount; i++ {
+				posX := bulletX + float64(i-(player.multiShotCount/2))*offset
+				bm.Add(NewBullet(posX, bulletY))
+			}
+		}
+		bm.shootTimer = 0
+		return true
+	}
+	return false
+}
+
+// Compact 原地移除所有非活动子弹并归还对象池，保持剩余子弹的顺序
+func (bm *BulletManager) Compact() {
+	n := 0
+	for _, bullet := range bm.Bullets {
+		if bullet.active {
+			bm.Bullets[n] = bullet
+			n++
+		} else {
+			bm.pool.Put(bullet)
+		}
+	}
+	clear(bm.Bullets[n:])
+	bm.Bullets = bm.Bullets[:n]
+}
+
+// AppendEntities 将所有子弹追加到实体列表中
+func (bm *BulletManager) AppendEntities(entities []Entity) []Entity {
+	for _, bullet := range bm.Bullets {
+		entities = append(entities, bullet)
+	}
+	return entities
+}
@@ -0,0 +1,75 @@
+package sim
+
+// Effect 表示不参与碰撞的视觉效果，例如实体被击毁时的爆炸
+type Effect struct {
+	Body
+	Sprite string // 播放动画所用的精灵名称
+	life   int    // 剩余帧数
+}
+
+// Update 推进效果的动画，播放完毕后标记为非活动状态
+func (e *Effect) Update() {
+	e.Anim.Update()
+	e.life--
+	if e.life <= 0 {
+		e.active = false
+	}
+}
+
+// EffectManager 管理所有视觉效果
+type EffectManager struct {
+	Effects []*Effect
+	pool    *Pool[Effect] // 效果对象池
+}
+
+// NewEffectManager 创建一个新的效果管理器
+func NewEffectManager() *EffectManager {
+	return &EffectManager{
+		Effects: make([]*Effect, 0, 32),
+		pool:    NewPool[Effect](32, 32),
+	}
+}
+
+// defaultExplodeTicks 没有设置ExplodeDuration时爆炸效果持续的帧数
+const defaultExplodeTicks = 30
+
+// ExplodeDuration 返回精灵sprite的爆炸动画播放一遍的帧数，爆炸效果持续到动画播放完毕。
+// 模拟不读取图像，由加载精灵图集的一方替换；爆炸只用于显示，持续时间不影响对局的其他状态
+var ExplodeDuration = func(sprite string) int {
+	return defaultExplodeTicks
+}
+
+// Explode 在实体b所在的位置播放精灵sprite的爆炸动画，效果持续到动画播放完毕。
+// 持续时间在创建时由ExplodeDuration计算
+func (em *EffectManager) Explode(b *Body, sprite string) {
+	e := em.pool.Get()
+	*e = Effect{
+		Body: Body{
+			X:      b.X,
+			Y:      b.Y,
+			Width:  b.Width,
+			Height: b.Height,
+			active: true,
+		},
+		Sprite: sprite,
+		life:   ExplodeDuration(sprite),
+	}
+	e.Anim.Play(AnimExplode)
+	em.Effects = append(em.Effects, e)
+}
+
+// Update 更新所有效果，移除已经播放完毕的效果
+func (em *EffectManager) Update() {
+	n := 0
+	for _, e := range em.Effects {
+		e.Update()
+		if e.active {
+			em.Effects[n] = e
+			n++
+		} else {
+			em.pool.Put(e)
+		}
+	}
+	clear(em.Effects[n:])
+	em.Effects = em.Effects[:n]
+}
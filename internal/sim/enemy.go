@@ -0,0 +1,186 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+)
+
+// sineAmplitude 正弦路径的左右摆动幅度
+const sineAmplitude = 48
+
+// Enemy 表示敌机
+type Enemy struct {
+	Body
+	speed     float64
+	Health    int         // 当前血量
+	MaxHealth int         // 最大血量
+	Kind      EnemyKind   // 敌机种类
+	path      EnemyPath   // 移动路径
+	fire      FirePattern // 射击方式
+	originX   float64     // 入场时的x坐标，正弦路径围绕它摆动
+	driftX    float64     // 斜向路径的水平速度
+	age       int         // 入场后经过的帧数
+	fireTimer int         // 瞄准射击的计时器
+}
+
+// NewEnemy 在场地field上方创建一个新的敌机，出现位置由rng决定
+func NewEnemy(rng *rand.Rand, field Playfield) *Enemy {
+	// 默认血量为2
+	defaultHealth := 2
+	return &Enemy{
+		Body: Body{
+			X:      float64(rng.Intn(field.Width - 32)),
+			Y:      -32,
+			Width:  32,
+			Height: 32,
+			active: true,
+		},
+		speed:     2,
+		Health:    defaultHealth, // 当前血量
+		MaxHealth: defaultHealth, // 最大血量与当前血量相同
+	}
+}
+
+// Layer 返回敌机所在的碰撞层
+func (e *Enemy) Layer() EntityLayer {
+	return LayerEnemy
+}
+
+// Update 更新敌机的状态，飞出场地field后标记为非活动状态
+func (e *Enemy) Update(field Playfield) {
+	e.age++
+	e.Anim.Update()
+
+	// 向下移动，再按路径调整水平位置
+	e.Y += e.speed
+	switch e.path {
+	case PathSine:
+		// 显式舍入乘积，避免arm64上合并为FMA后与amd64的结果不同，见simmath.go
+		e.X = e.originX + float64(simSin(float64(e.age)/20)*sineAmplitude)
+		e.X = math.Max(0, math.Min(e.X, float64(field.Width-e.Width)))
+	case PathDiagonal:
+		e.X += e.driftX
+	}
+
+	// 如果飞出场地外，标记为非活动状态
+	if e.Y > float64(field.Height) || e.X < -float64(e.Width) || e.X > float64(field.Width) {
+		e.active = false
+	}
+}
+
+// EnemyManager 管理所有敌机
+type EnemyManager struct {
+	Enemies    []*Enemy
+	spawnTimer int
+	gameTime   int              // 游戏时间计数器（以帧为单位）
+	spawn      SpawnParams      // 敌机生成参数，由当前关卡决定
+	timeline   []scheduledSpawn // 当前关卡的出怪时间轴
+	next       int              // 时间轴中下一个待生成敌机的下标
+	waveTime   int              // 进入当前关卡后经过的帧数
+	rng        *rand.Rand       // 本局共享的随机数生成器
+	field      Playfield        // 敌机活动的场地
+}
+
+// NewEnemyManager 创建一个新的敌机管理器，初始使用无尽模式的生成参数
+func NewEnemyManager(rng *rand.Rand, field Playfield) *EnemyManager {
+	return &EnemyManager{
+		Enemies:    make([]*Enemy, 0),
+		spawnTimer: 0,
+		gameTime:   0, // 初始游戏时间
+		spawn:      endlessSpawn,
+		rng:        rng,
+		field:      field,
+	}
+}
+
+// Update 更新所有敌机的状态
+func (em *EnemyManager) Update() {
+	// 更新游戏时间
+	em.gameTime++
+
+	// 更新现有敌机
+	for i := len(em.Enemies) - 1; i >= 0; i-- {
+		em.Enemies[i].Update(em.field)
+		// 移除非活动敌机
+		if !em.Enemies[i].active {
+			em.Enemies = append(em.Enemies[:i], em.Enemies[i+1:]...)
+		}
+	}
+
+	// 按时间轴生成到时的敌机。时间轴由关卡设计，不受spawn.MaxEnemies限制，数量上限在校验关卡时检查
+	for em.next < len(em.timeline) && em.timeline[em.next].tick <= em.waveTime {
+		em.Enemies = append(em.Enemies, em.newScheduledEnemy(em.timeline[em.next]))
+		em.next++
+	}
+	em.waveTime++
+
+	// 没有时间轴（如无尽模式）或时间轴已执行完时，按固定间隔随机生成敌机
+	if em.next < len(em.timeline) {
+		return
+	}
+	em.spawnTimer++
+	if em.spawnTimer >= em.spawn.Interval && len(em.Enemies) < em.spawn.MaxEnemies {
+		enemy := NewEnemy(em.rng, em.field)
+		em.applyLevelStats(enemy)
+		em.Enemies = append(em.Enemies, enemy)
+		em.spawnTimer = 0
+	}
+}
+
+// newScheduledEnemy 按时间轴中的描述创建敌机
+func (em *EnemyManager) newScheduledEnemy(spawn scheduledSpawn) *Enemy {
+	enemy := NewEnemy(em.rng, em.field)
+	if !spawn.randomX {
+		// 关卡中的坐标按designWidth宽的场地编写
+		enemy.X = math.Max(0, math.Min(em.field.scaleX(spawn.x), float64(em.field.Width-enemy.Width)))
+	}
+	enemy.Kind = spawn.kind
+	enemy.speed = enemyKinds[spawn.kind].speed
+	enemy.path = spawn.path
+	enemy.fire = spawn.fire
+	enemy.originX = enemy.X
+	// 斜向路径从哪一侧入场就飞向另一侧
+	if spawn.path == PathDiagonal {
+		enemy.driftX = enemy.speed * 0.6
+		if enemy.X+float64(enemy.Width)/2 > float64(em.field.Width)/2 {
+			enemy.driftX = -enemy.driftX
+		}
+	}
+	em.applyLevelStats(enemy)
+	return enemy
+}
+
+// applyLevelStats 根据关卡参数、游戏时间和敌机种类调整敌机的速度和血量
+func (em *EnemyManager) applyLevelStats(enemy *Enemy) {
+	// 根据关卡调整敌机速度
+	enemy.speed *= em.spawn.SpeedScale
+	// 根据时间和关卡调整敌机血量
+	timeBonus := int(em.gameTime / 600) // 时间加成，每10秒
+	calculatedHealth := em.spawn.BaseHealth + timeBonus + enemyKinds[enemy.Kind].healthBonus
+	// 限制血量范围，防止过高
+	calculatedHealth = max(1, min(calculatedHealth, em.spawn.MaxHealth))
+
+	// 设置当前血量和最大血量
+	enemy.Health = calculatedHealth
+	enemy.MaxHealth = calculatedHealth
+}
+
+// SetSpawn 切换到新关卡的敌机生成参数
+func (em *EnemyManager) SetSpawn(spawn SpawnParams) {
+	em.spawn = spawn
+}
+
+// SetWaves 切换到新关卡的出怪时间轴，时间轴从当前帧开始计时
+func (em *EnemyManager) SetWaves(waves []WaveEvent) {
+	em.timeline = buildTimeline(waves)
+	em.next = 0
+	em.waveTime = 0
+}
+
+// AppendEntities 将所有敌机追加到实体列表中
+func (em *EnemyManager) AppendEntities(entities []Entity) []Entity {
+	for _, enemy := range em.Enemies {
+		entities = append(entities, enemy)
+	}
+	return entities
+}
@@ -0,0 +1,335 @@
+package sim
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+)
+
+// EnemyBullet 表示敌机发射的子弹
+type EnemyBullet struct {
+	Body
+	speedX   float64
+	speedY   float64
+	Color    color.RGBA // 子弹颜色
+	IsHoming bool       // 是否为追踪子弹
+}
+
+// NewEnemyBullet 返回一颗随机角度敌机子弹的初始状态，发射角度由rng决定
+func NewEnemyBullet(x, y float64, rng *rand.Rand) EnemyBullet {
+	// 随机生成发射角度（-60度到60度之间），乘积显式舍入以便各平台结果相同，见simmath.go
+	angle := float64(rng.Float64()*120) - 60
+	// 将角度转换为弧度
+	radian := angle * math.Pi / 180
+	// 基础速度
+	baseSpeed := 4.0
+
+	return EnemyBullet{
+		Body: Body{
+			X:      x,
+			Y:      y,
+			Width:  4,
+			Height: 4, // 修改为正方形，便于旋转
+			active: true,
+		},
+		speedX:   baseSpeed * simSin(radian),
+		speedY:   baseSpeed * simCos(radian),
+		Color:    color.RGBA{255, 0, 0, 255}, // 默认红色
+		IsHoming: false,
+	}
+}
+
+// NewEnemyBulletCustom 返回一颗自定义方向和速度的敌机子弹的初始状态
+func NewEnemyBulletCustom(x, y, angle, speed float64, bulletColor color.RGBA) EnemyBullet {
+	return EnemyBullet{
+		Body: Body{
+			X:      x,
+			Y:      y,
+			Width:  6,
+			Height: 6,
+			active: true,
+			Shape:  ShapeCircle, // 弹幕使用圆形判定
+		},
+		speedX:   speed * simCos(angle),
+		speedY:   speed * simSin(angle),
+		Color:    bulletColor,
+		IsHoming: false,
+	}
+}
+
+// NewEnemyBulletHoming 返回一颗追踪玩家的敌机子弹的初始状态
+func NewEnemyBulletHoming(x, y, angle float64, bulletColor color.RGBA) EnemyBullet {
+	return EnemyBullet{
+		Body: Body{
+			X:      x,
+			Y:      y,
+			Width:  8,
+			Height: 8,
+			active: true,
+			Shape:  ShapeCircle,
+		},
+		speedX:   3.0 * simCos(angle),
+		speedY:   3.0 * simSin(angle),
+		Color:    bulletColor,
+		IsHoming: true,
+	}
+}
+
+// Update 更新敌机子弹的状态，子弹在场地field的左右和顶部边缘反弹
+func (b *EnemyBullet) Update(field Playfield) {
+	b.Anim.Update()
+
+	// 更新位置
+	b.X += b.speedX
+	b.Y += b.speedY
+
+	// 检查墙壁碰撞
+	if b.X <= 0 || b.X+float64(b.Width) >= float64(field.Width) {
+		// 水平反弹
+		b.speedX = -b.speedX
+		// 确保子弹不会卡在墙内
+		if b.X <= 0 {
+			b.X = 0
+		} else {
+			b.X = float64(field.Width) - float64(b.Width)
+		}
+	}
+
+	// 如果飞出场地底部，标记为非活动状态
+	if b.Y > float64(field.Height) {
+		b.active = false
+	}
+
+	// 如果飞到屏幕顶部，反弹
+	if b.Y <= 0 {
+		b.speedY = -b.speedY
+		b.Y = 0
+	}
+}
+
+// UpdateHoming 更新追踪子弹的状态，使其朝向指定玩家
+func (b *EnemyBullet) UpdateHoming(player *Player, field Playfield) {
+	// 只有追踪子弹才执行此逻辑
+	if b.IsHoming {
+		// 计算到玩家的方向
+		playerCenterX := player.X + float64(player.Width)/2
+		playerCenterY := player.Y + float64(player.Height)/2
+		bulletCenterX := b.X + float64(b.Width)/2
+		bulletCenterY := b.Y + float64(b.Height)/2
+
+		// 计算方向向量
+		dx := playerCenterX - bulletCenterX
+		dy := playerCenterY - bulletCenterY
+
+		// 计算距离。本函数中的乘积都显式舍入，避免arm64上合并为FMA后与amd64的结果不同，见simmath.go
+		distance := math.Sqrt(float64(dx*dx) + float64(dy*dy))
+
+		// 防止除以零
+		if distance > 0 {
+			// 归一化方向向量
+			dx /= distance
+			dy /= distance
+
+			// 计算当前速度大小
+			speed := math.Sqrt(float64(b.speedX*b.speedX) + float64(b.speedY*b.speedY))
+
+			// 缓慢转向玩家（增加追踪性）
+			turnFactor := 0.1 // 转向因子，越大转向越快
+			b.speedX = float64(b.speedX*(1-turnFactor)) + float64(dx*speed*turnFactor)
+			b.speedY = float64(b.speedY*(1-turnFactor)) + float64(dy*speed*turnFactor)
+		}
+	}
+
+	// 调用基本的更新方法
+	b.Update(field)
+}
+
+// nearestTarget 返回距离子弹最近的玩家，没有玩家时返回nil
+func (b *EnemyBullet) nearestTarget(targets []*Player) *Player {
+	return nearestPlayer(b.X+float64(b.Width)/2, b.Y+float64(b.Height)/2, targets)
+}
+
+// nearestPlayer 返回距离点(x, y)最近的玩家，没有玩家时返回nil
+func nearestPlayer(x, y float64, targets []*Player) *Player {
+	var nearest *Player
+	bestDist := math.MaxFloat64
+	for _, player := range targets {
+		dx := player.X + float64(player.Width)/2 - x
+		dy := player.Y + float64(player.Height)/2 - y
+		// 显式舍入乘积，使各平台选出同一个玩家，见simmath.go
+		if dist := float64(dx*dx) + float64(dy*dy); dist < bestDist {
+			nearest, bestDist = player, dist
+		}
+	}
+	return nearest
+}
+
+// Layer 返回敌机子弹所在的碰撞层
+func (b *EnemyBullet) Layer() EntityLayer {
+	return LayerEnemyBullet
+}
+
+// aimedFireInterval 瞄准射击敌机的射击间隔（帧）
+const aimedFireInterval = 90
+
+// EnemyBulletManager 管理所有敌机子弹
+type EnemyBulletManager struct {
+	Bullets   []*EnemyBullet
+	pool      *Pool[EnemyBullet] // 子弹对象池，BOSS弹幕密集时避免频繁分配
+	rng       *rand.Rand         // 本局共享的随机数生成器
+	targets   []*Player          // 瞄准的玩家，每帧由Update更新
+	tasks     []*patternTask     // 正在执行的弹幕脚本
+	freeTasks []*patternTask     // 已结束、等待复用的脚本任务
+	field     Playfield          // 子弹活动的场地
+}
+
+// NewEnemyBulletManager 创建一个新的敌机子弹管理器
+func NewEnemyBulletManager(rng *rand.Rand, field Playfield) *EnemyBulletManager {
+	return &EnemyBulletManager{
+		Bullets: make([]*EnemyBullet, 0, 512),
+		pool:    NewPool[EnemyBullet](256, 512),
+		rng:     rng,
+		field:   field,
+	}
+}
+
+// Add 从对象池取出一个槽位存放子弹b并返回
+func (bm *EnemyBulletManager) Add(b EnemyBullet) *EnemyBullet {
+	slot := bm.pool.Get()
+	*slot = b
+	bm.Bullets = append(bm.Bullets, slot)
+	return slot
+}
+
+// Compact 原地移除所有非活动子弹并归还对象池，保持剩余子弹的顺序
+func (bm *EnemyBulletManager) Compact() {
+	n := 0
+	for _, bullet := range bm.Bullets {
+		if bullet.active {
+			bm.Bullets[n] = bullet
+			n++
+		} else {
+			bm.pool.Put(bullet)
+		}
+	}
+	clear(bm.Bullets[n:])
+	bm.Bullets = bm.Bullets[:n]
+}
+
+// Clear 清除场上所有敌机子弹，由这些子弹执行的弹幕脚本随之结束
+func (bm *EnemyBulletManager) Clear() {
+	for _, bullet := range bm.Bullets {
+		bullet.active = false
+	}
+	bm.compactTasks()
+	bm.Compact()
+}
+
+// Update 更新所有敌机子弹的状态，追踪子弹会飞向targets中距离最近的玩家
+func (bm *EnemyBulletManager) Update(enemies []*Enemy, targets []*Player) {
+	bm.targets = targets
+
+	// 继续执行弹幕脚本，本帧发射的子弹随后一起移动
+	for _, task := range bm.tasks {
+		task.step()
+	}
+
+	// 更新现有子弹
+	for _, bullet := range bm.Bullets {
+		// 根据子弹类型调用不同的更新方法
+		if bullet.IsHoming {
+			if player := bullet.nearestTarget(targets); player != nil {
+				bullet.UpdateHoming(player, bm.field)
+				continue
+			}
+		}
+		bullet.Update(bm.field)
+	}
+	// 先结束失效子弹的脚本，再移除非活动子弹，避免脚本引用已归还对象池的子弹
+	bm.compactTasks()
+	bm.Compact()
+
+	// 按各敌机的射击方式发射子弹
+	for _, enemy := range enemies {
+		if !enemy.active {
+			continue
+		}
+		switch enemy.fire {
+		case FireRandom:
+			if bm.rng.Float64() < 0.01 { // 1%的概率发射子弹
+				// 从敌机的中心位置发射子弹
+				bulletX := enemy.X + float64(enemy.Width)/2 - 2
+				bulletY := enemy.Y + float64(enemy.Height)
+				bm.Add(NewEnemyBullet(bulletX, bulletY, bm.rng))
+			}
+		case FireAimed:
+			enemy.fireTimer++
+			if enemy.fireTimer < aimedFireInterval {
+				continue
+			}
+			enemy.fireTimer = 0
+			// 从敌机底部中心朝最近的玩家发射
+			fromX := enemy.X + float64(enemy.Width)/2
+			fromY := enemy.Y + float64(enemy.Height)
+			if player := nearestPlayer(fromX, fromY, targets); player != nil {
+				angle := simAtan2(player.Y+float64(player.Height)/2-fromY, player.X+float64(player.Width)/2-fromX)
+				bm.Add(NewEnemyBulletCustom(fromX-3, fromY, angle, 3.0, color.RGBA{255, 140, 0, 255}))
+			}
+		}
+	}
+}
+
+// RunPattern 以source为发射点执行弹幕脚本p，phase和anim对应脚本中的$phase和$anim。
+// 脚本立即开始执行，遇到wait后在之后每帧的Update中继续
+func (bm *EnemyBulletManager) RunPattern(p *BulletPattern, source patternSource, phase, anim int) {
+	if p == nil {
+		return
+	}
+	bm.startTask(p.actions, source, nil, phase, anim).step()
+}
+
+// startTask 创建脚本任务并加入执行列表，优先复用已结束的任务
+func (bm *EnemyBulletManager) startTask(actions []*patternAction, source patternSource, bullet *EnemyBullet, phase, anim int) *patternTask {
+	var task *patternTask
+	if n := len(bm.freeTasks); n > 0 {
+		task = bm.freeTasks[n-1]
+		bm.freeTasks = bm.freeTasks[:n-1]
+	} else {
+		task = &patternTask{}
+	}
+	*task = patternTask{
+		bm:     bm,
+		source: source,
+		bullet: bullet,
+		phase:  phase,
+		anim:   anim,
+		stack:  task.stack[:0],
+	}
+	task.push(actions, nil, 0)
+	bm.tasks = append(bm.tasks, task)
+	return task
+}
+
+// compactTasks 原地移除已结束或发射者已失效的脚本任务，保持剩余任务的顺序
+func (bm *EnemyBulletManager) compactTasks() {
+	n := 0
+	for _, task := range bm.tasks {
+		if !task.done && task.source.Active() {
+			bm.tasks[n] = task
+			n++
+		} else {
+			task.source, task.bullet = nil, nil
+			bm.freeTasks = append(bm.freeTasks, task)
+		}
+	}
+	clear(bm.tasks[n:])
+	bm.tasks = bm.tasks[:n]
+}
+
+// AppendEntities 将所有敌机子弹追加到实体列表中
+func (bm *EnemyBulletManager) AppendEntities(entities []Entity) []Entity {
+	for _, bullet := range bm.Bullets {
+		entities = append(entities, bullet)
+	}
+	return entities
+}
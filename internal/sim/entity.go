@@ -0,0 +1,174 @@
+package sim
+
+// Rect 表示轴对齐的矩形区域
+type Rect struct {
+	x, y, w, h float64
+}
+
+// Overlaps 判断两个矩形是否相交
+func (r Rect) Overlaps(o Rect) bool {
+	return r.x < o.x+o.w &&
+		r.x+r.w > o.x &&
+		r.y < o.y+o.h &&
+		r.y+r.h > o.y
+}
+
+// EntityLayer 表示实体所在的碰撞层
+type EntityLayer uint8
+
+const (
+	LayerPlayer       EntityLayer = 1 << iota // 玩家飞机
+	LayerPlayerBullet                         // 玩家子弹
+	LayerEnemy                                // 普通敌机
+	LayerEnemyBullet                          // 敌机子弹
+	LayerPowerUp                              // 道具
+	LayerBoss                                 // BOSS
+	LayerPlayerCore                           // 玩家核心判定点，只与敌机子弹碰撞
+)
+
+// Entity 是所有参与碰撞的游戏对象的公共接口
+type Entity interface {
+	// Hitbox 返回实体当前的精确判定区域
+	Hitbox() Hitbox
+	// Layer 返回实体所在的碰撞层
+	Layer() EntityLayer
+	// Active 返回实体是否仍然有效，无效实体不参与碰撞
+	Active() bool
+}
+
+// Body 是实体共享的位置、尺寸、存活状态和动画状态，嵌入到具体实体中使用
+type Body struct {
+	X      float64
+	Y      float64
+	Width  int
+	Height int
+	active bool
+	Shape  Shape    // 判定形状，圆形判定为内切于实体矩形的圆
+	Anim   Animator // 动画状态，由实体的Update逐帧推进
+}
+
+// Bounds 返回实体占据的矩形区域
+func (b *Body) Bounds() Rect {
+	return Rect{b.X, b.Y, float64(b.Width), float64(b.Height)}
+}
+
+// Hitbox 返回实体的判定区域
+func (b *Body) Hitbox() Hitbox {
+	if b.Shape == ShapeCircle {
+		radius := float64(min(b.Width, b.Height)) / 2
+		return CircleHitbox(b.X+float64(b.Width)/2, b.Y+float64(b.Height)/2, radius)
+	}
+	return RectHitbox(b.Bounds())
+}
+
+// Active 返回实体是否有效
+func (b *Body) Active() bool {
+	return b.active
+}
+
+// collisionRule 表示两个碰撞层之间的一条碰撞处理规则
+type collisionRule struct {
+	a, b    EntityLayer
+	handler func(a, b Entity)
+}
+
+// bruteForceLimit 两层实体配对数不超过该值时直接逐一比较，不构建网格
+const bruteForceLimit = 64
+
+// CollisionWorld 负责在所有实体间查找碰撞并分发给对应的处理函数
+type CollisionWorld struct {
+	rules      []collisionRule
+	layers     map[EntityLayer][]Entity
+	grids      map[EntityLayer]*UniformGrid // 每层实体的粗筛网格，每帧按需重建
+	gridBuilt  map[EntityLayer]bool         // 本帧该层网格是否已重建
+	candidates []int32                      // 网格查询结果，跨帧复用
+	width      int                          // 网格覆盖的区域宽度
+	height     int                          // 网格覆盖的区域高度
+}
+
+// NewCollisionWorld 创建一个空的碰撞世界，粗筛网格覆盖宽width高height的区域
+func NewCollisionWorld(width, height int) *CollisionWorld {
+	return &CollisionWorld{
+		rules:     make([]collisionRule, 0),
+		layers:    make(map[EntityLayer][]Entity),
+		grids:     make(map[EntityLayer]*UniformGrid),
+		gridBuilt: make(map[EntityLayer]bool),
+		width:     width,
+		height:    height,
+	}
+}
+
+// OnCollision 注册a层与b层实体之间的碰撞处理函数，处理函数按注册顺序执行
+func OnCollision[A, B Entity](w *CollisionWorld, a, b EntityLayer, handler func(A, B)) {
+	w.rules = append(w.rules, collisionRule{
+		a: a,
+		b: b,
+		handler: func(ea, eb Entity) {
+			handler(ea.(A), eb.(B))
+		},
+	})
+}
+
+// Dispatch 检测本帧所有实体的碰撞并调用处理函数
+func (w *CollisionWorld) Dispatch(entities []Entity) {
+	for layer, list := range w.layers {
+		w.layers[layer] = list[:0]
+	}
+	for layer := range w.gridBuilt {
+		w.gridBuilt[layer] = false
+	}
+	for _, e := range entities {
+		if e.Active() {
+			w.layers[e.Layer()] = append(w.layers[e.Layer()], e)
+		}
+	}
+
+	for _, rule := range w.rules {
+		listA, listB := w.layers[rule.a], w.layers[rule.b]
+		if len(listA)*len(listB) <= bruteForceLimit {
+			for _, ea := range listA {
+				for _, eb := range listB {
+					w.test(rule, ea, eb)
+				}
+			}
+			continue
+		}
+
+		// 实体较多时先用网格粗筛出可能相交的实体
+		grid := w.grid(rule.b)
+		for _, ea := range listA {
+			w.candidates = grid.Query(ea.Hitbox().Bounds(), w.candidates[:0])
+			for _, idx := range w.candidates {
+				w.test(rule, ea, listB[idx])
+			}
+		}
+	}
+}
+
+// test 精确检测一对实体，相交时调用处理函数
+func (w *CollisionWorld) test(rule collisionRule, ea, eb Entity) {
+	// 处理函数可能让实体失效，每次都需要重新检查
+	if !ea.Active() || !eb.Active() {
+		return
+	}
+	if ea.Hitbox().Overlaps(eb.Hitbox()) {
+		rule.handler(ea, eb)
+	}
+}
+
+// grid 返回本帧指定层的网格，首次使用时重建
+func (w *CollisionWorld) grid(layer EntityLayer) *UniformGrid {
+	grid, ok := w.grids[layer]
+	if !ok {
+		grid = NewUniformGrid(w.width, w.height, gridCellSize)
+		w.grids[layer] = grid
+	}
+	if !w.gridBuilt[layer] {
+		grid.Clear()
+		for i, e := range w.layers[layer] {
+			grid.Insert(i, e.Hitbox().Bounds())
+		}
+		w.gridBuilt[layer] = true
+	}
+	return grid
+}
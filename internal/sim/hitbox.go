@@ -0,0 +1,63 @@
+package sim
+
+import "math"
+
+// Shape 表示判定区域的形状
+type Shape uint8
+
+const (
+	ShapeRect   Shape = iota // 矩形判定
+	ShapeCircle              // 圆形判定
+)
+
+// Hitbox 表示实体的精确判定区域，矩形使用rect，圆形使用圆心和半径
+type Hitbox struct {
+	shape  Shape
+	rect   Rect    // 矩形判定区域
+	CX, CY float64 // 圆形判定的圆心
+	Radius float64 // 圆形判定的半径
+}
+
+// RectHitbox 创建矩形判定区域
+func RectHitbox(r Rect) Hitbox {
+	return Hitbox{shape: ShapeRect, rect: r}
+}
+
+// CircleHitbox 创建以(cx, cy)为圆心、radius为半径的圆形判定区域
+func CircleHitbox(cx, cy, radius float64) Hitbox {
+	return Hitbox{shape: ShapeCircle, CX: cx, CY: cy, Radius: radius}
+}
+
+// Bounds 返回判定区域的外接矩形，用于网格粗筛
+func (h Hitbox) Bounds() Rect {
+	if h.shape == ShapeCircle {
+		return Rect{h.CX - h.Radius, h.CY - h.Radius, h.Radius * 2, h.Radius * 2}
+	}
+	return h.rect
+}
+
+// Overlaps 判断两个判定区域是否相交
+func (h Hitbox) Overlaps(o Hitbox) bool {
+	switch {
+	case h.shape == ShapeRect && o.shape == ShapeRect:
+		return h.rect.Overlaps(o.rect)
+	case h.shape == ShapeCircle && o.shape == ShapeCircle:
+		dx, dy := h.CX-o.CX, h.CY-o.CY
+		r := h.Radius + o.Radius
+		// 显式舍入乘积，使刚好擦边的判定在各平台上相同，见simmath.go
+		return float64(dx*dx)+float64(dy*dy) < r*r
+	case h.shape == ShapeCircle:
+		return circleOverlapsRect(h.CX, h.CY, h.Radius, o.rect)
+	default:
+		return circleOverlapsRect(o.CX, o.CY, o.Radius, h.rect)
+	}
+}
+
+// circleOverlapsRect 判断圆与矩形是否相交：取矩形上距圆心最近的点，比较其与圆心的距离
+func circleOverlapsRect(cx, cy, radius float64, r Rect) bool {
+	nearestX := math.Max(r.x, math.Min(cx, r.x+r.w))
+	nearestY := math.Max(r.y, math.Min(cy, r.y+r.h))
+	dx, dy := cx-nearestX, cy-nearestY
+	// 显式舍入乘积，使刚好擦边的判定在各平台上相同，见simmath.go
+	return float64(dx*dx)+float64(dy*dy) < radius*radius
+}
@@ -0,0 +1,52 @@
+package sim
+
+// InputState 表示一帧内的操作快照，游戏逻辑只通过它读取输入
+type InputState struct {
+	MoveX      float64 // 水平移动量，-1为左，1为右
+	MoveY      float64 // 垂直移动量，-1为上，1为下
+	Fire       bool    // 是否开火
+	Confirm    bool    // 确认（菜单选择）
+	Back       bool    // 返回上一级
+	Pause      bool    // 本帧按下暂停
+	Restart    bool    // 重新开始
+	Bomb       bool    // 本帧按下炸弹
+	Focus      bool    // 低速移动
+	MenuChoice int     // 数字键选择的菜单项，0表示未选择
+	HasCursor  bool    // 是否包含指针信息
+	CursorX    int     // 指针X坐标
+	CursorY    int     // 指针Y坐标
+	Click      bool    // 指针主键是否按下
+	Touch      bool    // 指针是否来自触摸屏
+}
+
+// InputSource 表示一个可以逐帧产生操作快照的输入源
+type InputSource interface {
+	// Poll 返回当前帧的操作快照，每帧只应调用一次
+	Poll() InputState
+}
+
+// ScriptedInput 按预先写好的帧序列回放输入，用于测试和机器人
+type ScriptedInput struct {
+	frames []InputState
+	pos    int
+}
+
+// NewScriptedInput 创建一个脚本输入源，序列播放完毕后返回空输入
+func NewScriptedInput(frames ...InputState) *ScriptedInput {
+	return &ScriptedInput{frames: frames}
+}
+
+// Poll 返回脚本中的下一帧输入
+func (s *ScriptedInput) Poll() InputState {
+	if s.pos >= len(s.frames) {
+		return InputState{}
+	}
+	in := s.frames[s.pos]
+	s.pos++
+	return in
+}
+
+// Done 返回脚本是否已经播放完毕
+func (s *ScriptedInput) Done() bool {
+	return s.pos >= len(s.frames)
+}
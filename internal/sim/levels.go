@@ -0,0 +1,180 @@
+package sim
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"go-play-plane/internal/jsondata"
+)
+
+// LevelSet 表示一个关卡文件中按顺序排列的所有关卡
+type LevelSet struct {
+	Name   string      `json:"name"` // 关卡包名称，模组关卡包省略时使用目录名
+	Levels []*LevelDef `json:"levels"`
+
+	Pack string `json:"-"` // 关卡包ID，内置关卡为空，模组关卡包为模组目录名
+}
+
+// LevelDef 描述一个关卡的显示信息、敌机生成参数、出怪时间轴、BOSS、目标分数和解锁条件
+type LevelDef struct {
+	ID          string      `json:"id"`          // 关卡唯一标识，用于解锁条件引用
+	Name        string      `json:"name"`        // 关卡名称
+	Description string      `json:"description"` // 关卡描述
+	Difficulty  int         `json:"difficulty"`  // 难度（1-5星）
+	TargetScore int         `json:"targetScore"` // 通关目标分数
+	BossScore   int         `json:"bossScore"`   // 触发BOSS的分数，省略时为目标分数的一半
+	Boss        string      `json:"boss"`        // 关卡BOSS的ID，对应BOSS定义文件中的键
+	Spawn       SpawnParams `json:"spawn"`       // 普通敌机生成参数
+	Waves       []WaveEvent `json:"waves"`       // 出怪时间轴，执行完后改为随机生成
+	Requires    string      `json:"requires"`    // 需要先解锁的关卡ID，为空表示默认解锁
+	Music       string      `json:"music"`       // 背景音乐曲目，省略时按关卡顺序轮流使用内置的关卡音乐
+
+	BossDef *BossDef `json:"-"` // 由Boss解析得到的BOSS定义
+}
+
+// SpawnParams 描述普通敌机的生成参数
+type SpawnParams struct {
+	Interval   int     `json:"interval"`   // 生成间隔（帧）
+	MaxEnemies int     `json:"maxEnemies"` // 同时存在的最大敌机数量
+	SpeedScale float64 `json:"speedScale"` // 敌机速度倍率
+	BaseHealth int     `json:"baseHealth"` // 敌机基础血量，每10秒额外增加1点
+	MaxHealth  int     `json:"maxHealth"`  // 敌机血量上限
+}
+
+// endlessSpawn 无尽模式使用的敌机生成参数
+var endlessSpawn = SpawnParams{
+	Interval:   60,
+	MaxEnemies: 10,
+	SpeedScale: 1.0,
+	BaseHealth: 2,
+	MaxHealth:  20,
+}
+
+// Count 返回关卡数量
+func (ls *LevelSet) Count() int {
+	return len(ls.Levels)
+}
+
+// Level 返回第level关（从1开始）的定义，超出范围时返回最后一关
+func (ls *LevelSet) Level(level int) *LevelDef {
+	return ls.Levels[max(1, min(level, len(ls.Levels)))-1]
+}
+
+// LoadLevels 从磁盘读取关卡文件，文件格式与内置的resources/levels/levels.json相同，
+// 关卡中的BOSS从bossDefs中查找
+func LoadLevels(path string, bossDefs map[string]*BossDef) (*LevelSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ls, err := ParseLevels(data, bossDefs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ls, nil
+}
+
+// ParseLevels 解析并校验关卡文件内容，校验失败时一次性返回所有问题
+func ParseLevels(data []byte, bossDefs map[string]*BossDef) (*LevelSet, error) {
+	var ls LevelSet
+	if err := jsondata.Decode(data, &ls); err != nil {
+		return nil, fmt.Errorf("解析关卡文件失败: %w", err)
+	}
+	if err := ls.validate(bossDefs); err != nil {
+		return nil, err
+	}
+	return &ls, nil
+}
+
+// validate 校验所有关卡并补全省略的字段
+func (ls *LevelSet) validate(bossDefs map[string]*BossDef) error {
+	if len(ls.Levels) == 0 {
+		return errors.New("关卡文件中没有任何关卡")
+	}
+
+	var errs []error
+	seen := make(map[string]bool)
+	for i, def := range ls.Levels {
+		if def == nil {
+			errs = append(errs, fmt.Errorf("第%d关: 关卡定义为空", i+1))
+			continue
+		}
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("第%d关(%s): %s", i+1, def.ID, fmt.Sprintf(format, args...)))
+		}
+
+		if def.ID == "" {
+			fail("缺少id")
+		} else if seen[def.ID] {
+			fail("id重复")
+		}
+		if def.Name == "" {
+			fail("缺少name")
+		}
+		if def.Difficulty < 1 || def.Difficulty > 5 {
+			fail("difficulty必须在1到5之间，当前为%d", def.Difficulty)
+		}
+		if def.TargetScore <= 0 {
+			fail("targetScore必须大于0")
+		}
+		if def.BossScore == 0 {
+			def.BossScore = def.TargetScore / 2
+		} else if def.BossScore < 0 || def.BossScore > def.TargetScore {
+			fail("bossScore必须在0到targetScore之间，当前为%d", def.BossScore)
+		}
+		if bossDef, ok := bossDefs[def.Boss]; ok {
+			def.BossDef = bossDef
+		} else {
+			fail("未知的boss %q", def.Boss)
+		}
+		if def.Spawn.Interval <= 0 {
+			fail("spawn.interval必须大于0")
+		}
+		if def.Spawn.MaxEnemies <= 0 {
+			fail("spawn.maxEnemies必须大于0")
+		}
+		if def.Spawn.SpeedScale <= 0 {
+			fail("spawn.speedScale必须大于0")
+		}
+		if def.Spawn.BaseHealth <= 0 {
+			fail("spawn.baseHealth必须大于0")
+		}
+		if def.Spawn.MaxHealth < def.Spawn.BaseHealth {
+			fail("spawn.maxHealth不能小于spawn.baseHealth")
+		}
+		if def.Music == "" {
+			def.Music = levelMusic[i%len(levelMusic)]
+		} else if !slices.Contains(MusicTracks, def.Music) {
+			fail("未知的music %q", def.Music)
+		}
+		enemies := 0
+		for j := range def.Waves {
+			if err := def.Waves[j].validate(); err != nil {
+				fail("waves[%d]: %v", j, err)
+			} else {
+				enemies += def.Waves[j].enemyCount()
+			}
+		}
+		if enemies > maxTimelineEnemies {
+			fail("waves共有%d架敌机，最多%d架", enemies, maxTimelineEnemies)
+		}
+		// 只允许依赖前面的关卡，保证解锁条件不会形成循环
+		if def.Requires != "" && !seen[def.Requires] {
+			fail("requires引用的关卡%q不存在或不在本关之前", def.Requires)
+		}
+		seen[def.ID] = true
+	}
+	return errors.Join(errs...)
+}
+
+// Index 返回指定ID的关卡下标，不存在时返回-1
+func (ls *LevelSet) Index(id string) int {
+	for i, def := range ls.Levels {
+		if def.ID == id {
+			return i
+		}
+	}
+	return -1
+}
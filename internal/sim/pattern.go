@@ -0,0 +1,601 @@
+package sim
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/color"
+	"math"
+	"sort"
+	"strconv"
+
+	"go-play-plane/internal/jsondata"
+)
+
+// 弹幕脚本的执行上限，防止脚本错误导致卡死
+const (
+	maxPatternRepeat  = 1000                     // repeat的最大循环次数
+	maxPatternFrames  = 10 * 60 * TicksPerSecond // wait和渐变的最大帧数
+	maxPatternActions = 10000                    // 一个脚本每帧最多执行的指令数，超过时结束脚本
+)
+
+// BulletPattern 是一段编译后的弹幕脚本
+type BulletPattern struct {
+	name    string
+	actions []*patternAction
+}
+
+// actionKind 表示弹幕脚本中的指令类型
+type actionKind uint8
+
+const (
+	actionFire            actionKind = iota // 发射子弹
+	actionRepeat                            // 重复执行
+	actionWait                              // 等待若干帧
+	actionIf                                // 条件执行
+	actionVanish                            // 结束脚本，子弹的脚本会同时让子弹消失
+	actionChangeSpeed                       // 在若干帧内改变子弹速度
+	actionChangeDirection                   // 在若干帧内改变子弹方向
+)
+
+// directionType 表示方向值的参照
+type directionType uint8
+
+const (
+	dirAbsolute directionType = iota // 绝对方向，0指向右方，π/2指向下方
+	dirAim                           // 相对于指向最近玩家的方向
+	dirRelative                      // 相对于发射者（子弹）当前的方向
+	dirSequence                      // 相对于同一脚本上一次发射的方向
+)
+
+// speedType 表示速度值的参照
+type speedType uint8
+
+const (
+	speedAbsolute speedType = iota // 绝对速度
+	speedRelative                  // 相对于发射者（子弹）当前的速度
+	speedSequence                  // 相对于同一脚本上一次发射的速度
+)
+
+var (
+	directionTypeNames = map[string]directionType{
+		"absolute": dirAbsolute,
+		"aim":      dirAim,
+		"relative": dirRelative,
+		"sequence": dirSequence,
+	}
+	speedTypeNames = map[string]speedType{
+		"absolute": speedAbsolute,
+		"relative": speedRelative,
+		"sequence": speedSequence,
+	}
+)
+
+// patternAction 是编译后的一条指令
+type patternAction struct {
+	kind      actionKind
+	value     *patternExpr     // wait的帧数、if的条件、repeat的次数、change的目标值
+	frames    *patternExpr     // change的持续帧数
+	dirType   directionType    // fire和changeDirection的方向参照
+	speedType speedType        // fire和changeSpeed的速度参照
+	body      []*patternAction // repeat的循环体、if成立时执行的指令
+	orElse    []*patternAction // if不成立时执行的指令
+	fire      *fireSpec        // fire的子弹描述
+}
+
+// fireSpec 描述fire发射的子弹
+type fireSpec struct {
+	direction *patternExpr
+	speed     *patternExpr
+	color     color.RGBA
+	homing    bool             // 是否为追踪子弹
+	actions   []*patternAction // 子弹自身的脚本，可以继续发射子弹
+}
+
+// 以下为弹幕脚本文件的JSON结构，每条指令是只含一个键的对象，键名即指令类型
+
+type patternActionJSON struct {
+	Fire            *fireJSON   `json:"fire"`
+	Repeat          *repeatJSON `json:"repeat"`
+	Wait            *exprJSON   `json:"wait"`
+	If              *ifJSON     `json:"if"`
+	Vanish          bool        `json:"vanish"`
+	ChangeSpeed     *changeJSON `json:"changeSpeed"`
+	ChangeDirection *changeJSON `json:"changeDirection"`
+}
+
+type fireJSON struct {
+	Direction     exprJSON            `json:"direction"`
+	DirectionType string              `json:"directionType"`
+	Speed         exprJSON            `json:"speed"`
+	SpeedType     string              `json:"speedType"`
+	Color         []int               `json:"color"`
+	Homing        bool                `json:"homing"`
+	Actions       []patternActionJSON `json:"actions"`
+}
+
+type repeatJSON struct {
+	Times   exprJSON            `json:"times"`
+	Index   string              `json:"index"` // 循环下标的变量名
+	Count   string              `json:"count"` // 循环次数的变量名
+	Actions []patternActionJSON `json:"actions"`
+}
+
+type ifJSON struct {
+	Cond exprJSON            `json:"cond"`
+	Then []patternActionJSON `json:"then"`
+	Else []patternActionJSON `json:"else"`
+}
+
+type changeJSON struct {
+	Value  exprJSON `json:"value"`
+	Type   string   `json:"type"`
+	Frames exprJSON `json:"frames"`
+}
+
+// exprJSON 是脚本文件中的表达式，可以写成数字或字符串
+type exprJSON string
+
+// UnmarshalJSON 同时接受数字和字符串形式的表达式
+func (e *exprJSON) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err == nil {
+		*e = exprJSON(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return errors.New("表达式必须是数字或字符串")
+	}
+	*e = exprJSON(n)
+	return nil
+}
+
+// ParsePatterns 解析弹幕脚本文件，文件内容为脚本名称到指令列表的映射
+func ParsePatterns(data []byte) (map[string]*BulletPattern, error) {
+	var raw map[string][]patternActionJSON
+	if err := jsondata.Decode(data, &raw); err != nil {
+		return nil, fmt.Errorf("解析弹幕脚本失败: %w", err)
+	}
+
+	// 按名称顺序编译，保证错误信息的顺序稳定
+	names := make([]string, 0, len(raw))
+	for name := range raw {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	patterns := make(map[string]*BulletPattern, len(raw))
+	var errs []error
+	for _, name := range names {
+		c := &patternCompiler{}
+		actions := c.actions(name, raw[name], nil, false)
+		errs = append(errs, c.errs...)
+		patterns[name] = &BulletPattern{name: name, actions: actions}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return patterns, nil
+}
+
+// patternCompiler 将JSON指令编译为patternAction，并收集所有错误
+type patternCompiler struct {
+	errs []error
+}
+
+// fail 记录path位置的错误
+func (c *patternCompiler) fail(path string, format string, args ...any) {
+	c.errs = append(c.errs, fmt.Errorf("%s: %s", path, fmt.Sprintf(format, args...)))
+}
+
+// expr 编译path位置的表达式，src为空时使用默认值def
+func (c *patternCompiler) expr(path string, src exprJSON, def string, scope []patternVar) *patternExpr {
+	if src == "" {
+		src = exprJSON(def)
+	}
+	e, err := parseExpr(string(src), scope)
+	if err != nil {
+		c.fail(path, "%v", err)
+		return &patternExpr{op: exprConst}
+	}
+	return e
+}
+
+// actions 编译指令列表，inBullet表示指令是否属于子弹自身的脚本
+func (c *patternCompiler) actions(path string, list []patternActionJSON, scope []patternVar, inBullet bool) []*patternAction {
+	out := make([]*patternAction, 0, len(list))
+	for i := range list {
+		if a := c.action(path+"["+strconv.Itoa(i)+"]", &list[i], scope, inBullet); a != nil {
+			out = append(out, a)
+		}
+	}
+	return out
+}
+
+// action 编译单条指令
+func (c *patternCompiler) action(path string, raw *patternActionJSON, scope []patternVar, inBullet bool) *patternAction {
+	keys := 0
+	for _, set := range []bool{raw.Fire != nil, raw.Repeat != nil, raw.Wait != nil, raw.If != nil, raw.Vanish,
+		raw.ChangeSpeed != nil, raw.ChangeDirection != nil} {
+		if set {
+			keys++
+		}
+	}
+	if keys != 1 {
+		c.fail(path, "每条指令必须且只能包含fire、repeat、wait、if、vanish、changeSpeed、changeDirection中的一个")
+		return nil
+	}
+
+	a := &patternAction{}
+	switch {
+	case raw.Fire != nil:
+		a.kind = actionFire
+		a.fire = c.fire(path+".fire", raw.Fire, scope)
+		a.dirType = c.directionType(path+".fire.directionType", raw.Fire.DirectionType)
+		a.speedType = c.speedType(path+".fire.speedType", raw.Fire.SpeedType)
+		if !inBullet && (a.dirType == dirRelative || a.speedType == speedRelative) {
+			c.fail(path+".fire", "relative只能用于子弹自身的脚本")
+		}
+
+	case raw.Repeat != nil:
+		a.kind = actionRepeat
+		a.value = c.expr(path+".repeat.times", raw.Repeat.Times, "", scope)
+		inner := scope
+		if raw.Repeat.Index != "" {
+			inner = append(inner[:len(inner):len(inner)], patternVar{name: raw.Repeat.Index, loop: a})
+		}
+		if raw.Repeat.Count != "" {
+			inner = append(inner[:len(inner):len(inner)], patternVar{name: raw.Repeat.Count, loop: a, count: true})
+		}
+		a.body = c.actions(path+".repeat.actions", raw.Repeat.Actions, inner, inBullet)
+
+	case raw.Wait != nil:
+		a.kind = actionWait
+		a.value = c.expr(path+".wait", *raw.Wait, "", scope)
+
+	case raw.If != nil:
+		a.kind = actionIf
+		a.value = c.expr(path+".if.cond", raw.If.Cond, "", scope)
+		a.body = c.actions(path+".if.then", raw.If.Then, scope, inBullet)
+		a.orElse = c.actions(path+".if.else", raw.If.Else, scope, inBullet)
+
+	case raw.Vanish:
+		a.kind = actionVanish
+
+	case raw.ChangeSpeed != nil:
+		a.kind = actionChangeSpeed
+		a.value = c.expr(path+".changeSpeed.value", raw.ChangeSpeed.Value, "", scope)
+		a.frames = c.expr(path+".changeSpeed.frames", raw.ChangeSpeed.Frames, "0", scope)
+		a.speedType = c.speedType(path+".changeSpeed.type", raw.ChangeSpeed.Type)
+		if !inBullet {
+			c.fail(path, "changeSpeed只能用于子弹自身的脚本")
+		}
+
+	case raw.ChangeDirection != nil:
+		a.kind = actionChangeDirection
+		a.value = c.expr(path+".changeDirection.value", raw.ChangeDirection.Value, "", scope)
+		a.frames = c.expr(path+".changeDirection.frames", raw.ChangeDirection.Frames, "0", scope)
+		a.dirType = c.directionType(path+".changeDirection.type", raw.ChangeDirection.Type)
+		if !inBullet {
+			c.fail(path, "changeDirection只能用于子弹自身的脚本")
+		}
+	}
+	return a
+}
+
+// fire 编译fire指令的子弹描述
+func (c *patternCompiler) fire(path string, raw *fireJSON, scope []patternVar) *fireSpec {
+	spec := &fireSpec{
+		direction: c.expr(path+".direction", raw.Direction, "$pi / 2", scope),
+		speed:     c.expr(path+".speed", raw.Speed, "3", scope),
+		color:     color.RGBA{255, 0, 0, 255},
+		homing:    raw.Homing,
+	}
+	if raw.Color != nil {
+		if len(raw.Color) != 3 && len(raw.Color) != 4 {
+			c.fail(path+".color", "颜色必须是[r, g, b]或[r, g, b, a]")
+		}
+		rgba := [4]uint8{0, 0, 0, 255}
+		for i, v := range raw.Color[:min(len(raw.Color), 4)] {
+			if v < 0 || v > 255 {
+				c.fail(path+".color", "颜色分量必须在0到255之间")
+			}
+			rgba[i] = uint8(v)
+		}
+		spec.color = color.RGBA{rgba[0], rgba[1], rgba[2], rgba[3]}
+	}
+	// 子弹自身的脚本中看不到发射时的循环变量
+	spec.actions = c.actions(path+".actions", raw.Actions, nil, true)
+	return spec
+}
+
+// directionType 解析方向参照，省略时为absolute
+func (c *patternCompiler) directionType(path, name string) directionType {
+	if name == "" {
+		return dirAbsolute
+	}
+	t, ok := directionTypeNames[name]
+	if !ok {
+		c.fail(path, "未知的方向参照%q", name)
+	}
+	return t
+}
+
+// speedType 解析速度参照，省略时为absolute
+func (c *patternCompiler) speedType(path, name string) speedType {
+	if name == "" {
+		return speedAbsolute
+	}
+	t, ok := speedTypeNames[name]
+	if !ok {
+		c.fail(path, "未知的速度参照%q", name)
+	}
+	return t
+}
+
+// patternSource 是弹幕脚本的发射者，BOSS和子弹都可以执行脚本
+type patternSource interface {
+	Active() bool
+	// patternOrigin 返回发射点坐标
+	patternOrigin() (x, y float64)
+}
+
+// patternOrigin 返回BOSS的中心点
+func (b *Boss) patternOrigin() (x, y float64) {
+	return b.X + float64(b.Width)/2, b.Y + float64(b.Height)/2
+}
+
+// patternOrigin 返回子弹的中心点
+func (b *EnemyBullet) patternOrigin() (x, y float64) {
+	return b.X + float64(b.Width)/2, b.Y + float64(b.Height)/2
+}
+
+// patternFrame 是脚本执行栈中的一层，对应一段指令列表
+type patternFrame struct {
+	actions []*patternAction
+	pc      int            // 下一条要执行的指令
+	loop    *patternAction // 所属的repeat指令，if分支为nil
+	index   int            // 当前循环下标
+	count   int            // 循环总次数
+}
+
+// patternChange 表示一个正在进行的渐变
+type patternChange struct {
+	step   float64 // 每帧的变化量
+	frames int     // 剩余帧数
+}
+
+// patternTask 是一段正在执行的弹幕脚本，遇到wait时暂停，之后每帧继续执行
+type patternTask struct {
+	bm          *EnemyBulletManager
+	source      patternSource
+	bullet      *EnemyBullet // 执行脚本的子弹，BOSS的脚本为nil
+	phase       int          // 发射者的阶段，$phase
+	anim        int          // 发射者的动画计时器，$anim
+	stack       []patternFrame
+	wait        int     // 剩余等待帧数
+	lastDir     float64 // 上一次发射的方向
+	lastSpeed   float64 // 上一次发射的速度
+	speedChange patternChange
+	dirChange   patternChange
+	done        bool
+}
+
+// loopFrame 返回repeat指令loop当前所在的栈帧
+func (t *patternTask) loopFrame(loop *patternAction) *patternFrame {
+	for i := len(t.stack) - 1; i >= 0; i-- {
+		if t.stack[i].loop == loop {
+			return &t.stack[i]
+		}
+	}
+	// 编译时已检查变量作用域，正常不会执行到这里
+	return &patternFrame{}
+}
+
+// push 将一段指令压入执行栈
+func (t *patternTask) push(actions []*patternAction, loop *patternAction, count int) {
+	if len(actions) > 0 {
+		t.stack = append(t.stack, patternFrame{actions: actions, loop: loop, count: count})
+	}
+}
+
+// step 执行一帧脚本：先推进渐变，再执行指令直到遇到wait或脚本结束
+func (t *patternTask) step() {
+	if t.done {
+		return
+	}
+	if !t.source.Active() {
+		t.done = true
+		return
+	}
+	t.applyChanges()
+	if t.wait > 0 {
+		t.wait--
+		if t.wait > 0 {
+			return
+		}
+	}
+
+	// 嵌套的repeat每层都不超过上限，乘起来仍可能在一帧内执行数十亿条指令
+	budget := maxPatternActions
+	for len(t.stack) > 0 {
+		if budget == 0 {
+			t.stack = t.stack[:0]
+			t.done = true
+			return
+		}
+		budget--
+		f := &t.stack[len(t.stack)-1]
+		if f.pc >= len(f.actions) {
+			// 循环体执行完一遍，进入下一次循环或出栈
+			if f.loop != nil && f.index+1 < f.count {
+				f.index++
+				f.pc = 0
+			} else {
+				t.stack = t.stack[:len(t.stack)-1]
+			}
+			continue
+		}
+		a := f.actions[f.pc]
+		f.pc++
+
+		switch a.kind {
+		case actionFire:
+			t.fire(a)
+		case actionRepeat:
+			if n := a.value.evalCount(t, maxPatternRepeat); n > 0 {
+				t.push(a.body, a, n)
+			}
+		case actionWait:
+			if n := a.value.evalCount(t, maxPatternFrames); n > 0 {
+				t.wait = n
+				return
+			}
+		case actionIf:
+			if a.value.eval(t) != 0 {
+				t.push(a.body, nil, 0)
+			} else {
+				t.push(a.orElse, nil, 0)
+			}
+		case actionVanish:
+			if t.bullet != nil {
+				t.bullet.active = false
+			}
+			t.done = true
+			return
+		case actionChangeSpeed:
+			t.startSpeedChange(a)
+		case actionChangeDirection:
+			t.startDirectionChange(a)
+		}
+	}
+
+	// 指令执行完后，等渐变结束再结束脚本
+	t.done = t.speedChange.frames == 0 && t.dirChange.frames == 0
+}
+
+// fire 执行fire指令
+func (t *patternTask) fire(a *patternAction) {
+	spec := a.fire
+	x, y := t.source.patternOrigin()
+
+	dir := spec.direction.eval(t)
+	switch a.dirType {
+	case dirAim:
+		dir += t.aimDirection(x, y)
+	case dirRelative:
+		dir += t.bulletDirection()
+	case dirSequence:
+		dir += t.lastDir
+	}
+
+	speed := spec.speed.eval(t)
+	switch a.speedType {
+	case speedRelative:
+		speed += t.bulletSpeed()
+	case speedSequence:
+		speed += t.lastSpeed
+	}
+	t.lastDir, t.lastSpeed = dir, speed
+
+	var bullet EnemyBullet
+	if spec.homing {
+		bullet = NewEnemyBulletHoming(x, y, dir, spec.color)
+		bullet.speedX = speed * simCos(dir)
+		bullet.speedY = speed * simSin(dir)
+	} else {
+		bullet = NewEnemyBulletCustom(x, y, dir, speed, spec.color)
+	}
+	slot := t.bm.Add(bullet)
+	if len(spec.actions) > 0 {
+		t.bm.startTask(spec.actions, slot, slot, t.phase, t.anim)
+	}
+}
+
+// aimDirection 返回从(x, y)指向最近玩家的方向，没有玩家时朝下
+func (t *patternTask) aimDirection(x, y float64) float64 {
+	player := nearestPlayer(x, y, t.bm.targets)
+	if player == nil {
+		return math.Pi / 2
+	}
+	return simAtan2(player.Y+float64(player.Height)/2-y, player.X+float64(player.Width)/2-x)
+}
+
+// bulletDirection 返回执行脚本的子弹当前的方向
+func (t *patternTask) bulletDirection() float64 {
+	if t.bullet == nil {
+		return 0
+	}
+	return simAtan2(t.bullet.speedY, t.bullet.speedX)
+}
+
+// bulletSpeed 返回执行脚本的子弹当前的速度
+func (t *patternTask) bulletSpeed() float64 {
+	if t.bullet == nil {
+		return 0
+	}
+	return simHypot(t.bullet.speedX, t.bullet.speedY)
+}
+
+// setBulletVelocity 按方向和速度设置执行脚本的子弹的速度分量
+func (t *patternTask) setBulletVelocity(dir, speed float64) {
+	t.bullet.speedX = speed * simCos(dir)
+	t.bullet.speedY = speed * simSin(dir)
+}
+
+// startSpeedChange 开始changeSpeed渐变，帧数不大于0时立即生效
+func (t *patternTask) startSpeedChange(a *patternAction) {
+	current := t.bulletSpeed()
+	target := a.value.eval(t)
+	if a.speedType == speedRelative {
+		target += current
+	}
+	frames := a.frames.evalCount(t, maxPatternFrames)
+	if frames <= 0 {
+		t.setBulletVelocity(t.bulletDirection(), target)
+		t.speedChange = patternChange{}
+		return
+	}
+	t.speedChange = patternChange{step: (target - current) / float64(frames), frames: frames}
+}
+
+// startDirectionChange 开始changeDirection渐变，沿较小的角度转向，帧数不大于0时立即生效
+func (t *patternTask) startDirectionChange(a *patternAction) {
+	current := t.bulletDirection()
+	target := a.value.eval(t)
+	switch a.dirType {
+	case dirAim:
+		x, y := t.source.patternOrigin()
+		target += t.aimDirection(x, y)
+	case dirRelative:
+		target += current
+	case dirSequence:
+		target += t.lastDir
+	}
+	frames := a.frames.evalCount(t, maxPatternFrames)
+	if frames <= 0 {
+		t.setBulletVelocity(target, t.bulletSpeed())
+		t.dirChange = patternChange{}
+		return
+	}
+	diff := math.Remainder(target-current, 2*math.Pi)
+	t.dirChange = patternChange{step: diff / float64(frames), frames: frames}
+}
+
+// applyChanges 推进一帧正在进行的渐变
+func (t *patternTask) applyChanges() {
+	if t.bullet == nil || (t.speedChange.frames == 0 && t.dirChange.frames == 0) {
+		return
+	}
+	dir, speed := t.bulletDirection(), t.bulletSpeed()
+	if t.speedChange.frames > 0 {
+		speed += t.speedChange.step
+		t.speedChange.frames--
+	}
+	if t.dirChange.frames > 0 {
+		dir += t.dirChange.step
+		t.dirChange.frames--
+	}
+	t.setBulletVelocity(dir, speed)
+}
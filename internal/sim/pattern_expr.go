@@ -0,0 +1,383 @@
+package sim
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// exprOp 表示弹幕脚本表达式节点的运算类型
+type exprOp uint8
+
+const (
+	exprConst exprOp = iota // 常量
+	exprPhase               // $phase：发射者当前阶段
+	exprAnim                // $anim：发射者的动画计时器
+	exprRand                // $rand：[0,1)的随机数，每次求值都会消耗一次随机数
+	exprIndex               // repeat的循环下标
+	exprCount               // repeat的循环次数
+	exprNeg
+	exprNot
+	exprAdd
+	exprSub
+	exprMul
+	exprDiv
+	exprMod
+	exprLt
+	exprLe
+	exprGt
+	exprGe
+	exprEq
+	exprNe
+	exprAnd
+	exprOr
+	exprRad // rad(x)：角度转弧度
+	exprMin
+	exprMax
+	exprAbs
+	exprSin
+	exprCos
+)
+
+// patternExpr 是编译后的数值表达式，比较和逻辑运算的结果为1或0
+type patternExpr struct {
+	op    exprOp
+	value float64        // 常量的值
+	loop  *patternAction // 循环变量所属的repeat节点
+	args  []*patternExpr // 运算数
+}
+
+// exprFuncs 表达式中可用的函数及其参数个数
+var exprFuncs = map[string]struct {
+	op    exprOp
+	arity int
+}{
+	"rad": {exprRad, 1},
+	"min": {exprMin, 2},
+	"max": {exprMax, 2},
+	"abs": {exprAbs, 1},
+	"sin": {exprSin, 1},
+	"cos": {exprCos, 1},
+}
+
+// exprBinaryOps 二元运算符按优先级从低到高分组
+var exprBinaryOps = [][]struct {
+	token string
+	op    exprOp
+}{
+	{{"||", exprOr}},
+	{{"&&", exprAnd}},
+	{{"==", exprEq}, {"!=", exprNe}, {"<=", exprLe}, {">=", exprGe}, {"<", exprLt}, {">", exprGt}},
+	{{"+", exprAdd}, {"-", exprSub}},
+	{{"*", exprMul}, {"/", exprDiv}, {"%", exprMod}},
+}
+
+// exprTwoCharOps 由两个字符组成的运算符
+var exprTwoCharOps = []string{"<=", ">=", "==", "!=", "&&", "||"}
+
+// patternVar 表示表达式可以引用的一个循环变量
+type patternVar struct {
+	name  string
+	loop  *patternAction
+	count bool // 为true时引用循环次数，否则引用循环下标
+}
+
+// exprParser 是表达式的递归下降解析器
+type exprParser struct {
+	tokens []string
+	pos    int
+	scope  []patternVar
+}
+
+// parseExpr 解析表达式源码，scope为当前位置可见的循环变量
+func parseExpr(src string, scope []patternVar) (*patternExpr, error) {
+	tokens, err := tokenizeExpr(src)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("表达式为空")
+	}
+	p := &exprParser{tokens: tokens, scope: scope}
+	e, err := p.binary(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("表达式%q中有多余的%q", src, p.tokens[p.pos])
+	}
+	return e, nil
+}
+
+// tokenizeExpr 将表达式拆分为数字、变量、函数名和运算符
+func tokenizeExpr(src string) ([]string, error) {
+	var tokens []string
+	for i := 0; i < len(src); {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case unicode.IsDigit(c) || c == '.':
+			j := i
+			for j < len(src) && (unicode.IsDigit(rune(src[j])) || src[j] == '.') {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		case c == '$' || unicode.IsLetter(c):
+			j := i + 1
+			for j < len(src) && (unicode.IsLetter(rune(src[j])) || unicode.IsDigit(rune(src[j])) || src[j] == '_') {
+				j++
+			}
+			tokens = append(tokens, src[i:j])
+			i = j
+		case strings.ContainsRune("+-*/%(),", c):
+			tokens = append(tokens, src[i:i+1])
+			i++
+		case strings.ContainsRune("<>=!&|", c):
+			if i+1 < len(src) && slices.Contains(exprTwoCharOps, src[i:i+2]) {
+				tokens = append(tokens, src[i:i+2])
+				i += 2
+			} else if c == '<' || c == '>' || c == '!' {
+				tokens = append(tokens, src[i:i+1])
+				i++
+			} else {
+				return nil, fmt.Errorf("表达式%q中有无效的运算符%q", src, c)
+			}
+		default:
+			return nil, fmt.Errorf("表达式%q中有无效的字符%q", src, c)
+		}
+	}
+	return tokens, nil
+}
+
+// peek 返回下一个记号，没有时返回空字符串
+func (p *exprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+// expect 读取指定的记号
+func (p *exprParser) expect(token string) error {
+	if p.peek() != token {
+		return fmt.Errorf("缺少%q", token)
+	}
+	p.pos++
+	return nil
+}
+
+// binary 解析优先级不低于level的二元运算，同级运算左结合
+func (p *exprParser) binary(level int) (*patternExpr, error) {
+	if level == len(exprBinaryOps) {
+		return p.unary()
+	}
+	left, err := p.binary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := exprOp(0), false
+		for _, candidate := range exprBinaryOps[level] {
+			if p.peek() == candidate.token {
+				op, ok = candidate.op, true
+				break
+			}
+		}
+		if !ok {
+			return left, nil
+		}
+		p.pos++
+		right, err := p.binary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &patternExpr{op: op, args: []*patternExpr{left, right}}
+	}
+}
+
+// unary 解析一元运算
+func (p *exprParser) unary() (*patternExpr, error) {
+	switch p.peek() {
+	case "-", "!":
+		op := exprNeg
+		if p.peek() == "!" {
+			op = exprNot
+		}
+		p.pos++
+		arg, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &patternExpr{op: op, args: []*patternExpr{arg}}, nil
+	}
+	return p.primary()
+}
+
+// primary 解析数字、变量、函数调用和括号
+func (p *exprParser) primary() (*patternExpr, error) {
+	token := p.peek()
+	if token == "" {
+		return nil, fmt.Errorf("表达式不完整")
+	}
+	p.pos++
+
+	switch {
+	case token == "(":
+		e, err := p.binary(0)
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		v, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的数字%q", token)
+		}
+		return &patternExpr{op: exprConst, value: v}, nil
+
+	case token[0] == '$':
+		return p.variable(token[1:])
+	}
+
+	fn, ok := exprFuncs[token]
+	if !ok {
+		return nil, fmt.Errorf("未知的函数%q", token)
+	}
+	e := &patternExpr{op: fn.op}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for i := 0; i < fn.arity; i++ {
+		if i > 0 {
+			if err := p.expect(","); err != nil {
+				return nil, fmt.Errorf("%s需要%d个参数", token, fn.arity)
+			}
+		}
+		arg, err := p.binary(0)
+		if err != nil {
+			return nil, err
+		}
+		e.args = append(e.args, arg)
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, fmt.Errorf("%s需要%d个参数", token, fn.arity)
+	}
+	return e, nil
+}
+
+// variable 解析变量引用，内层循环的同名变量会遮蔽外层
+func (p *exprParser) variable(name string) (*patternExpr, error) {
+	switch name {
+	case "phase":
+		return &patternExpr{op: exprPhase}, nil
+	case "anim":
+		return &patternExpr{op: exprAnim}, nil
+	case "rand":
+		return &patternExpr{op: exprRand}, nil
+	case "pi":
+		return &patternExpr{op: exprConst, value: math.Pi}, nil
+	}
+	for i := len(p.scope) - 1; i >= 0; i-- {
+		if v := p.scope[i]; v.name == name {
+			op := exprIndex
+			if v.count {
+				op = exprCount
+			}
+			return &patternExpr{op: op, loop: v.loop}, nil
+		}
+	}
+	return nil, fmt.Errorf("未定义的变量$%s", name)
+}
+
+// boolValue 将比较结果转换为1或0
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// eval 在脚本任务t的上下文中求值
+func (e *patternExpr) eval(t *patternTask) float64 {
+	switch e.op {
+	case exprConst:
+		return e.value
+	case exprPhase:
+		return float64(t.phase)
+	case exprAnim:
+		return float64(t.anim)
+	case exprRand:
+		return t.bm.rng.Float64()
+	case exprIndex:
+		return float64(t.loopFrame(e.loop).index)
+	case exprCount:
+		return float64(t.loopFrame(e.loop).count)
+	case exprNeg:
+		return -e.args[0].eval(t)
+	case exprNot:
+		return boolValue(e.args[0].eval(t) == 0)
+	case exprAnd:
+		// 与Go一致采用短路求值，未求值的一侧不会消耗随机数
+		return boolValue(e.args[0].eval(t) != 0 && e.args[1].eval(t) != 0)
+	case exprOr:
+		return boolValue(e.args[0].eval(t) != 0 || e.args[1].eval(t) != 0)
+	case exprRad:
+		return e.args[0].eval(t) * math.Pi / 180.0
+	case exprAbs:
+		return math.Abs(e.args[0].eval(t))
+	case exprSin:
+		return simSin(e.args[0].eval(t))
+	case exprCos:
+		return simCos(e.args[0].eval(t))
+	}
+
+	a, b := e.args[0].eval(t), e.args[1].eval(t)
+	switch e.op {
+	case exprAdd:
+		return a + b
+	case exprSub:
+		return a - b
+	case exprMul:
+		return a * b
+	case exprDiv:
+		return a / b
+	case exprMod:
+		return math.Mod(a, b)
+	case exprLt:
+		return boolValue(a < b)
+	case exprLe:
+		return boolValue(a <= b)
+	case exprGt:
+		return boolValue(a > b)
+	case exprGe:
+		return boolValue(a >= b)
+	case exprEq:
+		return boolValue(a == b)
+	case exprNe:
+		return boolValue(a != b)
+	case exprMin:
+		return math.Min(a, b)
+	case exprMax:
+		return math.Max(a, b)
+	}
+	return 0
+}
+
+// evalCount 求值并转换为[0, limit]内的整数，用于循环次数和帧数。不大于0的值和NaN为0，
+// 超过limit的值和+Inf为limit；把非有限值或超出int范围的值直接转换为int的结果因平台而异
+func (e *patternExpr) evalCount(t *patternTask, limit int) int {
+	v := e.eval(t)
+	if !(v > 0) {
+		return 0
+	}
+	if v >= float64(limit) {
+		return limit
+	}
+	return int(v)
+}
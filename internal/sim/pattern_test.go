@@ -0,0 +1,221 @@
+package sim
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// 以下三个函数是改用弹幕脚本之前BOSS的fireCirclePattern、fireCrossPattern和fireHomingPattern，
+// 只把子弹改为返回而不是放入子弹管理器，用来检查resources/patterns/boss.json中的脚本与原来的弹幕完全一致
+
+// baselineCirclePattern 原来的环形弹幕
+func baselineCirclePattern(centerX, centerY float64, phase int) []EnemyBullet {
+	var bullets []EnemyBullet
+	bulletCount := 8 + (phase-1)*2
+	for i := 0; i < bulletCount; i++ {
+		angle := float64(i) * (360.0 / float64(bulletCount))
+		radian := angle * math.Pi / 180.0
+		bullets = append(bullets, NewEnemyBulletCustom(centerX, centerY, radian, 3.0, color.RGBA{255, 50, 50, 255}))
+	}
+	if phase >= 3 {
+		for i := 0; i < bulletCount; i++ {
+			angle := float64(i)*(360.0/float64(bulletCount)) + 180.0/float64(bulletCount)
+			radian := angle * math.Pi / 180.0
+			bullets = append(bullets, NewEnemyBulletCustom(centerX, centerY, radian, 3.0, color.RGBA{255, 150, 50, 255}))
+		}
+	}
+	return bullets
+}
+
+// baselineCrossPattern 原来的交叉弹幕
+func baselineCrossPattern(centerX, centerY float64, phase, animTimer int) []EnemyBullet {
+	var bullets []EnemyBullet
+	lineCount := min(2+(phase-1), 6)
+	bulletsPerLine := 5
+	for i := 0; i < lineCount; i++ {
+		angle := float64(i) * (180.0 / float64(lineCount))
+		radian := angle * math.Pi / 180.0
+		for j := 0; j < bulletsPerLine; j++ {
+			speed := 2.0 + float64(j)*0.5
+			bullets = append(bullets, NewEnemyBulletCustom(centerX, centerY, radian, speed, color.RGBA{0, 150, 255, 255}))
+		}
+	}
+	if phase >= 3 {
+		rotationOffset := float64(animTimer%360) * math.Pi / 180.0
+		for i := 0; i < lineCount; i++ {
+			angle := float64(i)*(180.0/float64(lineCount)) + rotationOffset
+			radian := angle * math.Pi / 180.0
+			bullets = append(bullets, NewEnemyBulletCustom(centerX, centerY, radian, 3.0, color.RGBA{200, 100, 255, 255}))
+		}
+	}
+	return bullets
+}
+
+// baselineHomingPattern 原来的追踪弹幕，随机数改为使用本局的rng
+func baselineHomingPattern(centerX, centerY float64, player *Player, phase int, rng *rand.Rand) []EnemyBullet {
+	var bullets []EnemyBullet
+	playerX := player.X + float64(player.Width)/2
+	playerY := player.Y + float64(player.Height)/2
+	angle := math.Atan2(playerY-centerY, playerX-centerX)
+	homingCount := 1 + (phase - 1)
+	for i := 0; i < homingCount; i++ {
+		angleOffset := (rng.Float64() - 0.5) * 0.5
+		bullets = append(bullets, NewEnemyBulletHoming(centerX, centerY, angle+angleOffset, color.RGBA{255, 255, 100, 255}))
+	}
+	if phase >= 2 {
+		spreadCount := 3 + (phase-2)*2
+		spreadAngle := math.Pi / 3.0
+		for i := 0; i < spreadCount; i++ {
+			spreadOffset := spreadAngle * (float64(i)/float64(spreadCount-1) - 0.5)
+			bullets = append(bullets, NewEnemyBulletCustom(centerX, centerY, angle+spreadOffset, 2.5, color.RGBA{255, 200, 0, 255}))
+		}
+	}
+	return bullets
+}
+
+// firedBullets 返回子弹管理器中所有子弹的副本
+func firedBullets(bm *EnemyBulletManager) []EnemyBullet {
+	bullets := make([]EnemyBullet, len(bm.Bullets))
+	for i, b := range bm.Bullets {
+		bullets[i] = *b
+	}
+	return bullets
+}
+
+func TestBossPatternsMatchBaseline(t *testing.T) {
+	field := playfields[FieldStandard]
+	for _, name := range []string{"circle", "cross", "homing"} {
+		for phase := 1; phase <= 4; phase++ {
+			for anim := 0; anim < 800; anim += 37 {
+				t.Run(fmt.Sprintf("%s/阶段%d/帧%d", name, phase, anim), func(t *testing.T) {
+					player := NewPlayer(field)
+					player.X, player.Y = float64(anim%600), 400
+
+					rng := rand.New(rand.NewSource(int64(anim)))
+					boss := NewBoss(bosses["ring"], rng, field)
+					boss.X, boss.Y = float64(anim%500), 90
+					boss.Phase, boss.AnimTimer = phase, anim
+					bm := NewEnemyBulletManager(rng, field)
+					bm.targets = []*Player{player}
+					boss.firePattern(bm, bulletPatterns[name])
+					got := firedBullets(bm)
+
+					baseRng := rand.New(rand.NewSource(int64(anim)))
+					x, y := boss.patternOrigin()
+					var want []EnemyBullet
+					switch name {
+					case "circle":
+						want = baselineCirclePattern(x, y, phase)
+					case "cross":
+						want = baselineCrossPattern(x, y, phase, anim)
+					case "homing":
+						want = baselineHomingPattern(x, y, player, phase, baseRng)
+					}
+
+					if len(got) != len(want) {
+						t.Fatalf("脚本发射了%d颗子弹，原来的弹幕为%d颗", len(got), len(want))
+					}
+					for i := range got {
+						if got[i] != want[i] {
+							t.Errorf("第%d颗子弹不同:\n脚本: %+v\n原来: %+v", i, got[i], want[i])
+						}
+					}
+					// 随机数的消耗也必须一致，否则之后的整局模拟都会不同
+					if rng.Int63() != baseRng.Int63() {
+						t.Error("脚本消耗的随机数与原来的弹幕不同")
+					}
+				})
+			}
+		}
+	}
+}
+
+func TestPatternRepeatNonPositive(t *testing.T) {
+	for _, times := range []string{"0", "-3", "$phase - 5", "0 / 0"} {
+		t.Run(times, func(t *testing.T) {
+			src := fmt.Sprintf(`[{"repeat": {"times": %q, "actions": [{"fire": {"direction": 0, "speed": 1}}]}}]`, times)
+			if n := runTestPattern(t, src); n != 0 {
+				t.Errorf("repeat %s次执行了循环体，发射了%d颗子弹", times, n)
+			}
+		})
+	}
+}
+
+// runTestPattern 解析只含脚本p的弹幕脚本，由ring BOSS执行一帧，返回发射的子弹数
+func runTestPattern(t *testing.T, actions string) int {
+	t.Helper()
+	patterns, err := ParsePatterns([]byte(`{"p": ` + actions + `}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	field := playfields[FieldStandard]
+	rng := rand.New(rand.NewSource(1))
+	bm := NewEnemyBulletManager(rng, field)
+	bm.RunPattern(patterns["p"], NewBoss(bosses["ring"], rng, field), 1, 0)
+	return len(bm.Bullets)
+}
+
+func TestPatternRepeatNonFinite(t *testing.T) {
+	// 各平台把±Inf转换为int的结果不同，必须在转换前钳制，否则录像会不同步
+	tests := []struct {
+		times string
+		want  int
+	}{
+		{"1 / 0", maxPatternRepeat},
+		{"-1 / 0", 0},
+		{"1000000 * 1000000 * 1000000", maxPatternRepeat},
+	}
+	for _, tt := range tests {
+		t.Run(tt.times, func(t *testing.T) {
+			src := fmt.Sprintf(`[{"repeat": {"times": %q, "actions": [{"fire": {"direction": 0, "speed": 1}}]}}]`, tt.times)
+			if n := runTestPattern(t, src); n != tt.want {
+				t.Errorf("repeat %s次发射了%d颗子弹，应为%d颗", tt.times, n, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatternActionBudget(t *testing.T) {
+	// 三层嵌套的repeat共10亿次发射，必须在指令数达到上限时结束而不是卡死
+	src := `[{"repeat": {"times": 1000, "actions": [{"repeat": {"times": 1000, "actions": [
+		{"repeat": {"times": 1000, "actions": [{"fire": {"direction": 0, "speed": 1}}]}}]}}]}}]`
+	n := runTestPattern(t, src)
+	if n == 0 || n > maxPatternActions {
+		t.Errorf("嵌套的repeat发射了%d颗子弹，应在1到%d颗之间", n, maxPatternActions)
+	}
+}
+
+func TestBossKeepsPatternsAfterReload(t *testing.T) {
+	field := playfields[FieldStandard]
+	fire := func(def *BossDef) int {
+		rng := rand.New(rand.NewSource(1))
+		boss := NewBoss(def, rng, field)
+		bm := NewEnemyBulletManager(rng, field)
+		boss.firePattern(bm, boss.currentPhase().patterns[0])
+		return len(bm.Bullets)
+	}
+	before := fire(bosses["ring"])
+
+	// 开发模式重新加载时解析出新的弹幕脚本，只有之后用新脚本解析的BOSS定义才使用它们
+	reloaded, err := ParsePatterns([]byte(`{"circle": [{"fire": {"direction": 0, "speed": 1}}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ParseBosses(defaultBossesJSON, builtinSprites, reloaded); err == nil {
+		t.Fatal("重新加载的脚本缺少cross等弹幕，解析BOSS定义应当失败")
+	}
+	defs, err := ParseBosses([]byte(`{"ring": {"name": "环", "width": 80, "height": 80, "health": 10,
+		"phases": [{"movement": {"type": "bounce", "speed": 1}, "patterns": ["circle"], "fireInterval": 30}]}}`), builtinSprites, reloaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fire(defs["ring"]); got != 1 {
+		t.Errorf("重新加载后解析的BOSS定义发射了%d颗子弹，应使用新脚本发射1颗", got)
+	}
+	if got := fire(bosses["ring"]); got != before {
+		t.Errorf("重新加载弹幕脚本后，已有的BOSS定义发射了%d颗子弹，原来为%d颗", got, before)
+	}
+}
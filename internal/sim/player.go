@@ -0,0 +1,127 @@
+package sim
+
+// Player 表示玩家控制的飞机
+type Player struct {
+	Body
+	Speed             float64
+	multiShotCount    int // 永久性多弹道数量
+	screenShotEnabled bool
+	powerUpTimer      int         // 用于控制全屏攻击的持续时间
+	attackPower       int         // 攻击力
+	Core              *PlayerCore // 用于子弹判定的核心
+	Bombs             int         // 剩余的炸弹数
+	field             Playfield   // 玩家可以活动的场地
+}
+
+// 炸弹和低速移动
+const (
+	initialBombs    = 3   // 每局开始时的炸弹数
+	focusSpeedScale = 0.5 // 低速移动时的速度比例
+)
+
+// playerCoreRadius 玩家核心判定圆的半径，子弹只有命中核心才算击中玩家
+const playerCoreRadius = 3
+
+// PlayerCore 是位于玩家飞机中心的小型判定点，只参与敌机子弹的碰撞，
+// 子弹擦过飞机外形不会导致死亡
+type PlayerCore struct {
+	player *Player
+}
+
+// Hitbox 返回以飞机中心为圆心的圆形判定区域
+func (c *PlayerCore) Hitbox() Hitbox {
+	p := c.player
+	return CircleHitbox(p.X+float64(p.Width)/2, p.Y+float64(p.Height)/2, playerCoreRadius)
+}
+
+// Layer 返回玩家核心所在的碰撞层
+func (c *PlayerCore) Layer() EntityLayer {
+	return LayerPlayerCore
+}
+
+// Active 返回所属玩家是否有效
+func (c *PlayerCore) Active() bool {
+	return c.player.active
+}
+
+// NewPlayer 在场地field底部中央创建一个新的玩家飞机
+func NewPlayer(field Playfield) *Player {
+	p := &Player{
+		Body: Body{
+			X:      float64(field.Width) / 2,
+			Y:      float64(field.Height) - 50,
+			Width:  32,
+			Height: 32,
+			active: true,
+		},
+		Speed:          4,
+		multiShotCount: 0,
+		attackPower:    1,
+		Bombs:          initialBombs,
+		field:          field,
+	}
+	p.Core = &PlayerCore{player: p}
+	return p
+}
+
+// Layer 返回玩家所在的碰撞层
+func (p *Player) Layer() EntityLayer {
+	return LayerPlayer
+}
+
+// EnableMultiShot 启用多弹道能力
+func (p *Player) EnableMultiShot() {
+	p.multiShotCount++ // 永久增加一个弹道
+}
+
+// EnableScreenShot 启用全屏攻击能力
+func (p *Player) EnableScreenShot() {
+	p.screenShotEnabled = true
+	p.powerUpTimer = 180 // 能力持续180帧（约3秒）
+}
+
+// EnableAttackBoost 增加攻击力
+func (p *Player) EnableAttackBoost() {
+	p.attackPower++ // 永久增加一点攻击力
+}
+
+// Update 根据本帧输入更新玩家飞机的状态
+func (p *Player) Update(in InputState) {
+	// 处理移动输入，按住低速键时减速以便躲避密集的弹幕
+	speed := p.Speed
+	if in.Focus {
+		speed *= focusSpeedScale
+	}
+	// 移动量显式舍入后再累加，避免arm64上合并为FMA，使录像在各平台上回放出相同的位置，见simmath.go
+	if in.MoveX < 0 && p.X > 0 {
+		p.X += float64(speed * in.MoveX)
+	}
+	if in.MoveX > 0 && p.X < float64(p.field.Width-p.Width) {
+		p.X += float64(speed * in.MoveX)
+	}
+	if in.MoveY < 0 && p.Y > 0 {
+		p.Y += float64(speed * in.MoveY)
+	}
+	if in.MoveY > 0 && p.Y < float64(p.field.Height-p.Height) {
+		p.Y += float64(speed * in.MoveY)
+	}
+
+	// 按水平输入显示倾斜动画
+	switch {
+	case in.MoveX < -bankThreshold:
+		p.Anim.Play(AnimBankLeft)
+	case in.MoveX > bankThreshold:
+		p.Anim.Play(AnimBankRight)
+	default:
+		p.Anim.Play(AnimIdle)
+	}
+	p.Anim.Update()
+
+	// 更新全屏攻击状态
+	if p.screenShotEnabled {
+		p.powerUpTimer--
+		if p.powerUpTimer <= 0 {
+			p.screenShotEnabled = false
+		}
+	}
+}
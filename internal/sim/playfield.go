@@ -0,0 +1,50 @@
+package sim
+
+// designWidth 关卡文件中出怪坐标所对应的场地宽度，其他宽度的场地按比例换算
+const designWidth = 640
+
+// PlayfieldKind 表示可选的场地类型
+type PlayfieldKind int
+
+const (
+	FieldStandard PlayfieldKind = iota // 4:3横版场地，HUD叠加在场地上
+	FieldVertical                      // 3:4纵版场地，HUD显示在场地旁边的侧边栏
+)
+
+// Playfield 是模拟使用的逻辑场地，所有实体的坐标都在场地坐标系中，与窗口大小和画面分辨率无关。
+// 场地大小会影响敌机和子弹的行为，因此是对局的一部分，会记录在录像中
+type Playfield struct {
+	Kind   PlayfieldKind
+	Width  int
+	Height int
+}
+
+// playfields 各场地类型对应的场地
+var playfields = [...]Playfield{
+	FieldStandard: {Kind: FieldStandard, Width: 640, Height: 480},
+	FieldVertical: {Kind: FieldVertical, Width: 360, Height: 480},
+}
+
+// PlayfieldNames 场地类型在命令行参数中的名称
+var PlayfieldNames = [...]string{
+	FieldStandard: "standard",
+	FieldVertical: "vertical",
+}
+
+// FindPlayfield 按名称查找场地类型
+func FindPlayfield(name string) (PlayfieldKind, bool) {
+	for kind, n := range PlayfieldNames {
+		if n == name {
+			return PlayfieldKind(kind), true
+		}
+	}
+	return 0, false
+}
+
+// scaleX 将按designWidth编写的x坐标换算到本场地
+func (f Playfield) scaleX(x float64) float64 {
+	if f.Width == designWidth {
+		return x
+	}
+	return x * float64(f.Width) / designWidth
+}
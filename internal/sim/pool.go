@@ -0,0 +1,45 @@
+package sim
+
+// Pool 是按块预分配的对象池。对象以块为单位分配，地址在整个生命周期内保持不变，
+// 归还的对象进入空闲列表等待复用，稳定状态下取用和归还都不会产生内存分配。
+type Pool[T any] struct {
+	blockSize int
+	free      []*T
+}
+
+// NewPool 创建对象池并预分配prealloc个对象，之后每次不足时按blockSize扩容
+func NewPool[T any](blockSize, prealloc int) *Pool[T] {
+	p := &Pool[T]{
+		blockSize: blockSize,
+		free:      make([]*T, 0, max(blockSize, prealloc)),
+	}
+	for len(p.free) < prealloc {
+		p.grow()
+	}
+	return p
+}
+
+// grow 分配一个新的对象块并放入空闲列表
+func (p *Pool[T]) grow() {
+	block := make([]T, p.blockSize)
+	for i := range block {
+		p.free = append(p.free, &block[i])
+	}
+}
+
+// Get 从池中取出一个零值对象
+func (p *Pool[T]) Get() *T {
+	if len(p.free) == 0 {
+		p.grow()
+	}
+	v := p.free[len(p.free)-1]
+	p.free = p.free[:len(p.free)-1]
+	return v
+}
+
+// Put 将对象清零后归还到池中
+func (p *Pool[T]) Put(v *T) {
+	var zero T
+	*v = zero
+	p.free = append(p.free, v)
+}
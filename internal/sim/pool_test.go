@@ -0,0 +1,81 @@
+package sim
+
+import (
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+// volleyScene 一个只有BOSS、玩家和两种子弹的场景，BOSS停在最后一个阶段不断发射弹幕
+type volleyScene struct {
+	boss          *Boss
+	player        *Player
+	targets       []*Player
+	bullets       *BulletManager
+	enemyBullets  *EnemyBulletManager
+	playerBullets int // 预热后的玩家子弹数
+	bossBullets   int // 预热后的敌机子弹数
+}
+
+// newVolleyScene 创建BOSS def的弹幕场景，并推进到子弹数量和对象池大小都稳定下来
+func newVolleyScene(def *BossDef) *volleyScene {
+	field := playfields[FieldStandard]
+	rng := rand.New(rand.NewSource(1))
+	s := &volleyScene{
+		boss:         NewBoss(def, rng, field),
+		player:       NewPlayer(field),
+		bullets:      NewBulletManager(field),
+		enemyBullets: NewEnemyBulletManager(rng, field),
+	}
+	s.targets = []*Player{s.player}
+	s.boss.enterScene = false
+	s.boss.Y = bossHomeY
+	s.boss.Phase = len(def.Phases)
+	s.player.multiShotCount = 8
+
+	for i := 0; i < 1200; i++ {
+		s.step()
+		s.playerBullets = max(s.playerBullets, len(s.bullets.Bullets))
+		s.bossBullets = max(s.bossBullets, len(s.enemyBullets.Bullets))
+	}
+	return s
+}
+
+// step 推进场景一帧：BOSS发射弹幕，两个子弹管理器更新并原地移除失效的子弹
+func (s *volleyScene) step() {
+	s.boss.Update(s.player, s.enemyBullets)
+	s.enemyBullets.Update(nil, s.targets)
+	s.bullets.Update(s.player, true)
+}
+
+func TestBulletManagersZeroAllocs(t *testing.T) {
+	for _, id := range slices.Sorted(maps.Keys(bosses)) {
+		t.Run(id, func(t *testing.T) {
+			s := newVolleyScene(bosses[id])
+			if s.bossBullets < 50 || s.playerBullets < 20 {
+				t.Fatalf("预热后只有%d颗敌机子弹和%d颗玩家子弹，场景不够密集", s.bossBullets, s.playerBullets)
+			}
+			// 稳定状态下对象池已经足够大，Update和Compact都只复用已有的对象和切片
+			if allocs := testing.AllocsPerRun(600, s.step); allocs != 0 {
+				t.Errorf("稳定状态下每帧分配%v次内存，应为0", allocs)
+			}
+		})
+	}
+}
+
+func BenchmarkBossVolley(b *testing.B) {
+	for _, id := range slices.Sorted(maps.Keys(bosses)) {
+		b.Run(id, func(b *testing.B) {
+			s := newVolleyScene(bosses[id])
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				s.step()
+			}
+			if allocs := testing.AllocsPerRun(100, s.step); allocs != 0 {
+				b.Errorf("稳定状态下每帧分配%v次内存，应为0", allocs)
+			}
+		})
+	}
+}
@@ -0,0 +1,113 @@
+package sim
+
+import (
+	"math"
+	"math/rand"
+)
+
+// PowerUpType 定义道具类型
+type PowerUpType int
+
+const (
+	MultiShot    PowerUpType = iota // 多弹道
+	ScreenShot                      // 全屏攻击
+	AttackBoost                     // 攻击力增强
+	ClearBullets                    // 清除全屏子弹
+)
+
+// PowerUp 表示道具
+type PowerUp struct {
+	Body
+	speed float64
+	Kind  PowerUpType
+}
+
+// NewPowerUp 创建一个新的道具
+func NewPowerUp(x, y float64, pType PowerUpType) *PowerUp {
+	return &PowerUp{
+		Body: Body{
+			X:      x,
+			Y:      y,
+			Width:  20,
+			Height: 20,
+			active: true,
+		},
+		speed: 1.5,
+		Kind:  pType,
+	}
+}
+
+// Layer 返回道具所在的碰撞层
+func (p *PowerUp) Layer() EntityLayer {
+	return LayerPowerUp
+}
+
+// Update 更新道具的状态，飞出场地field后标记为非活动状态
+func (p *PowerUp) Update(field Playfield) {
+	p.Anim.Update()
+
+	// 道具向下移动
+	p.Y += p.speed
+
+	// 如果飞出场地外，标记为非活动状态
+	if p.Y > float64(field.Height) {
+		p.active = false
+	}
+}
+
+// PowerUpManager 管理所有道具
+type PowerUpManager struct {
+	PowerUps []*PowerUp
+	rng      *rand.Rand // 本局共享的随机数生成器
+	field    Playfield  // 道具活动的场地
+}
+
+// NewPowerUpManager 创建一个新的道具管理器
+func NewPowerUpManager(rng *rand.Rand, field Playfield) *PowerUpManager {
+	return &PowerUpManager{
+		PowerUps: make([]*PowerUp, 0),
+		rng:      rng,
+		field:    field,
+	}
+}
+
+// SpawnPowerUp 按当前得分score计算掉落概率并生成道具
+func (pm *PowerUpManager) SpawnPowerUp(x, y float64, score int) {
+	// 基础掉落概率为35%
+	baseProb := 0.35
+	// 根据玩家得分增加掉落概率，每1000分增加5%的掉落概率，最高不超过60%
+	scoreBonus := math.Min(float64(score)/1000.0*0.05, 0.25)
+	if pm.rng.Float64() < baseProb+scoreBonus {
+		// 随机选择道具类型
+		randVal := pm.rng.Float64()
+		if randVal < 0.0005 { // 0.05%概率掉落攻击力增强道具
+			pm.PowerUps = append(pm.PowerUps, NewPowerUp(x, y, AttackBoost))
+		} else if randVal < 0.3005 { // 30%概率掉落全屏攻击道具
+			pm.PowerUps = append(pm.PowerUps, NewPowerUp(x, y, ScreenShot))
+		} else if randVal < 0.4005 { // 10%概率掉落清除子弹道具
+			pm.PowerUps = append(pm.PowerUps, NewPowerUp(x, y, ClearBullets))
+		} else { // 其余概率掉落多弹道道具
+			pm.PowerUps = append(pm.PowerUps, NewPowerUp(x, y, MultiShot))
+		}
+	}
+}
+
+// Update 更新所有道具的状态
+func (pm *PowerUpManager) Update() {
+	// 更新现有道具
+	for i := len(pm.PowerUps) - 1; i >= 0; i-- {
+		pm.PowerUps[i].Update(pm.field)
+		// 移除非活动道具
+		if !pm.PowerUps[i].active {
+			pm.PowerUps = append(pm.PowerUps[:i], pm.PowerUps[i+1:]...)
+		}
+	}
+}
+
+// AppendEntities 将所有道具追加到实体列表中
+func (pm *PowerUpManager) AppendEntities(entities []Entity) []Entity {
+	for _, powerUp := range pm.PowerUps {
+		entities = append(entities, powerUp)
+	}
+	return entities
+}
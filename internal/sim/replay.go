@@ -0,0 +1,401 @@
+package sim
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"os"
+)
+
+// 录像文件格式常量
+const (
+	replayMagic            = "GPRP" // 文件头标识
+	replayVersion          = 4      // 当前录像格式版本，第2版在文件头后增加了关卡包ID，第3版增加了场地类型，第4版增加了炸弹和低速按钮
+	replayChecksumInterval = 60     // 每隔多少模拟帧记录一次校验值
+
+	// maxReplayFrames 录像最多包含的帧数（4小时）。关卡和无尽模式都没有时间上限，
+	// 但读取时必须限制文件头声明的帧数，否则几个字节的损坏文件就能让游戏耗尽内存
+	maxReplayFrames = 4 * 60 * 60 * TicksPerSecond
+	// replayFramesPrealloc 读取时预先分配的帧数上限，更长的录像随解码逐渐扩容
+	replayFramesPrealloc = 60 * 60 * TicksPerSecond
+)
+
+// 录像中每帧按钮的位掩码。第1位保留不用：暂停不进入模拟，从不录入录像，解码时忽略该位
+const (
+	replayButtonFire uint8 = 1 << iota
+	_
+	replayButtonConfirm
+	replayButtonBack
+	replayButtonRestart
+	replayButtonBomb
+	replayButtonFocus
+)
+
+// Replay 表示一局游戏的完整录像
+type Replay struct {
+	seed      int64         // 对局随机种子
+	mode      GameMode      // 游戏模式
+	level     int           // 起始关卡
+	pack      string        // 关卡包ID，内置关卡为空
+	field     PlayfieldKind // 场地类型
+	interval  int           // 校验值记录间隔（模拟帧）
+	frames    []InputState  // 每帧的输入
+	checksums []uint32      // 第(i+1)*interval帧结束时的状态校验值
+}
+
+// NewReplay 为一局新的对局创建空录像
+func NewReplay(seed int64, mode GameMode, level int, pack string, field PlayfieldKind) *Replay {
+	return &Replay{
+		seed:     seed,
+		mode:     mode,
+		level:    level,
+		pack:     pack,
+		field:    field,
+		interval: replayChecksumInterval,
+	}
+}
+
+// NewSimulation 根据录像头信息创建与录制时完全一致的模拟，ls必须是录像使用的关卡包，见Pack
+func (r *Replay) NewSimulation(ls *LevelSet) *Simulation {
+	return NewSimulation(ls, r.field, r.mode, r.level, r.seed)
+}
+
+// Pack 返回录像使用的关卡包ID，内置关卡为空
+func (r *Replay) Pack() string {
+	return r.pack
+}
+
+// Seed 返回对局的随机种子
+func (r *Replay) Seed() int64 {
+	return r.seed
+}
+
+// Len 返回录像的帧数
+func (r *Replay) Len() int {
+	return len(r.frames)
+}
+
+// Checksum 计算模拟关键状态的校验值，用于检测回放不同步
+func (s *Simulation) Checksum() uint32 {
+	h := fnv.New32a()
+	var buf [8]byte
+	write := func(v uint64) {
+		binary.LittleEndian.PutUint64(buf[:], v)
+		h.Write(buf[:])
+	}
+	write(uint64(s.Tick))
+	write(uint64(s.Score))
+	write(math.Float64bits(s.Player.X))
+	write(math.Float64bits(s.Player.Y))
+	bossHealth := -1
+	if s.Boss != nil {
+		bossHealth = s.Boss.Health
+	}
+	write(uint64(bossHealth))
+	return h.Sum32()
+}
+
+// quantizeInput 将输入量化为录像可以精确保存的形式，录制时必须使用量化后的输入推进模拟
+func quantizeInput(in InputState) InputState {
+	return decodeReplayFrame(encodeReplayFrame(in))
+}
+
+// encodeReplayFrame 将一帧输入编码为3字节
+func encodeReplayFrame(in InputState) [3]byte {
+	var buttons uint8
+	if in.Fire {
+		buttons |= replayButtonFire
+	}
+	if in.Confirm {
+		buttons |= replayButtonConfirm
+	}
+	if in.Back {
+		buttons |= replayButtonBack
+	}
+	if in.Restart {
+		buttons |= replayButtonRestart
+	}
+	if in.Bomb {
+		buttons |= replayButtonBomb
+	}
+	if in.Focus {
+		buttons |= replayButtonFocus
+	}
+	return [3]byte{buttons, byte(quantizeAxis(in.MoveX)), byte(quantizeAxis(in.MoveY))}
+}
+
+// decodeReplayFrame 将3字节还原为一帧输入
+func decodeReplayFrame(b [3]byte) InputState {
+	return InputState{
+		Fire:    b[0]&replayButtonFire != 0,
+		Confirm: b[0]&replayButtonConfirm != 0,
+		Back:    b[0]&replayButtonBack != 0,
+		Restart: b[0]&replayButtonRestart != 0,
+		Bomb:    b[0]&replayButtonBomb != 0,
+		Focus:   b[0]&replayButtonFocus != 0,
+		MoveX:   float64(int8(b[1])) / 127,
+		MoveY:   float64(int8(b[2])) / 127,
+	}
+}
+
+// quantizeAxis 将[-1, 1]的轴值量化为int8
+func quantizeAxis(v float64) int8 {
+	v = math.Max(-1, math.Min(1, v))
+	return int8(math.Round(v * 127))
+}
+
+// WriteTo 以紧凑的二进制格式写出录像，连续相同的输入帧会被合并。
+// 超过maxReplayFrames帧的录像无法读回，不会写出
+func (r *Replay) WriteTo(w io.Writer) (int64, error) {
+	if len(r.frames) > maxReplayFrames {
+		return 0, fmt.Errorf("录像有%d帧，超过了上限%d帧", len(r.frames), maxReplayFrames)
+	}
+	bw := bufio.NewWriter(w)
+	cw := &countingWriter{w: bw}
+
+	header := struct {
+		Version  uint8
+		Mode     uint8
+		Level    uint16
+		Seed     int64
+		Interval uint16
+		Frames   uint32
+	}{replayVersion, uint8(r.mode), uint16(r.level), r.seed, uint16(r.interval), uint32(len(r.frames))}
+	cw.Write([]byte(replayMagic))
+	binary.Write(cw, binary.LittleEndian, header)
+	cw.Write([]byte{uint8(len(r.pack))})
+	cw.Write([]byte(r.pack))
+	cw.Write([]byte{uint8(r.field)})
+
+	// 游程编码：每段为 [uvarint 重复次数][3字节帧]
+	var varint [binary.MaxVarintLen64]byte
+	for i := 0; i < len(r.frames); {
+		frame := encodeReplayFrame(r.frames[i])
+		run := 1
+		for i+run < len(r.frames) && encodeReplayFrame(r.frames[i+run]) == frame {
+			run++
+		}
+		n := binary.PutUvarint(varint[:], uint64(run))
+		cw.Write(varint[:n])
+		cw.Write(frame[:])
+		i += run
+	}
+
+	binary.Write(cw, binary.LittleEndian, uint32(len(r.checksums)))
+	binary.Write(cw, binary.LittleEndian, r.checksums)
+
+	if cw.err != nil {
+		return cw.n, cw.err
+	}
+	return cw.n, bw.Flush()
+}
+
+// ReadReplay 从二进制数据中读取录像
+func ReadReplay(rd io.Reader) (*Replay, error) {
+	br := bufio.NewReader(rd)
+
+	magic := make([]byte, len(replayMagic))
+	if _, err := io.ReadFull(br, magic); err != nil {
+		return nil, fmt.Errorf("读取录像文件头失败: %w", err)
+	}
+	if string(magic) != replayMagic {
+		return nil, errors.New("不是有效的录像文件")
+	}
+
+	var header struct {
+		Version  uint8
+		Mode     uint8
+		Level    uint16
+		Seed     int64
+		Interval uint16
+		Frames   uint32
+	}
+	if err := binary.Read(br, binary.LittleEndian, &header); err != nil {
+		return nil, fmt.Errorf("读取录像文件头失败: %w", err)
+	}
+	if header.Version < 1 || header.Version > replayVersion {
+		return nil, fmt.Errorf("不支持的录像版本: %d", header.Version)
+	}
+	if header.Interval == 0 {
+		return nil, errors.New("录像校验间隔无效")
+	}
+	if header.Frames > maxReplayFrames {
+		return nil, fmt.Errorf("录像帧数%d超过了上限%d", header.Frames, maxReplayFrames)
+	}
+
+	r := &Replay{
+		seed:     header.Seed,
+		mode:     GameMode(header.Mode),
+		level:    int(header.Level),
+		interval: int(header.Interval),
+		frames:   make([]InputState, 0, min(header.Frames, replayFramesPrealloc)),
+	}
+	// 第1版录像没有关卡包ID，只可能使用内置关卡
+	if header.Version >= 2 {
+		n, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("读取录像文件头失败: %w", err)
+		}
+		pack := make([]byte, n)
+		if _, err := io.ReadFull(br, pack); err != nil {
+			return nil, fmt.Errorf("读取录像文件头失败: %w", err)
+		}
+		r.pack = string(pack)
+	}
+	// 第3版之前的录像没有场地类型，只可能使用标准场地
+	if header.Version >= 3 {
+		field, err := br.ReadByte()
+		if err != nil {
+			return nil, fmt.Errorf("读取录像文件头失败: %w", err)
+		}
+		if int(field) >= len(playfields) {
+			return nil, fmt.Errorf("未知的场地类型: %d", field)
+		}
+		r.field = PlayfieldKind(field)
+	}
+	for uint32(len(r.frames)) < header.Frames {
+		run, err := binary.ReadUvarint(br)
+		if err != nil {
+			return nil, fmt.Errorf("读取录像帧失败: %w", err)
+		}
+		var frame [3]byte
+		if _, err := io.ReadFull(br, frame[:]); err != nil {
+			return nil, fmt.Errorf("读取录像帧失败: %w", err)
+		}
+		if run == 0 || uint64(len(r.frames))+run > uint64(header.Frames) {
+			return nil, errors.New("录像帧数据损坏")
+		}
+		in := decodeReplayFrame(frame)
+		for i := uint64(0); i < run; i++ {
+			r.frames = append(r.frames, in)
+		}
+	}
+
+	var count uint32
+	if err := binary.Read(br, binary.LittleEndian, &count); err != nil {
+		return nil, fmt.Errorf("读取录像校验值失败: %w", err)
+	}
+	if count > header.Frames/uint32(header.Interval)+1 {
+		return nil, errors.New("录像校验值数量无效")
+	}
+	r.checksums = make([]uint32, count)
+	if err := binary.Read(br, binary.LittleEndian, r.checksums); err != nil {
+		return nil, fmt.Errorf("读取录像校验值失败: %w", err)
+	}
+	return r, nil
+}
+
+// Save 将录像保存到文件
+func (r *Replay) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := r.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadReplay 从文件加载录像
+func LoadReplay(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadReplay(f)
+}
+
+// countingWriter 统计写入字节数并记录第一个错误
+type countingWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	if c.err != nil {
+		return 0, c.err
+	}
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	c.err = err
+	return n, err
+}
+
+// ReplayRecorder 在对局进行时逐帧记录输入和校验值
+type ReplayRecorder struct {
+	replay *Replay
+}
+
+// NewReplayRecorder 为指定模拟创建录像记录器
+func NewReplayRecorder(sim *Simulation) *ReplayRecorder {
+	return &ReplayRecorder{replay: NewReplay(sim.seed, sim.Mode, sim.CurrentLevel, sim.Levels.Pack, sim.Field.Kind)}
+}
+
+// Record 记录一帧输入，返回量化后的输入，调用方必须用返回值推进模拟
+func (rr *ReplayRecorder) Record(in InputState) InputState {
+	in = quantizeInput(in)
+	rr.replay.frames = append(rr.replay.frames, in)
+	return in
+}
+
+// Checkpoint 在模拟推进后调用，按间隔记录状态校验值
+func (rr *ReplayRecorder) Checkpoint(sim *Simulation) {
+	if sim.Tick > 0 && sim.Tick%rr.replay.interval == 0 {
+		rr.replay.checksums = append(rr.replay.checksums, sim.Checksum())
+	}
+}
+
+// Replay 返回已记录的录像
+func (rr *ReplayRecorder) Replay() *Replay {
+	return rr.replay
+}
+
+// ReplayInput 按录像逐帧回放输入
+type ReplayInput struct {
+	replay *Replay
+	pos    int
+}
+
+// NewReplayInput 创建录像回放输入源
+func NewReplayInput(replay *Replay) *ReplayInput {
+	return &ReplayInput{replay: replay}
+}
+
+// Poll 返回录像中的下一帧输入，播放完毕后返回空输入
+func (ri *ReplayInput) Poll() InputState {
+	if ri.Done() {
+		return InputState{}
+	}
+	in := ri.replay.frames[ri.pos]
+	ri.pos++
+	return in
+}
+
+// Done 返回录像是否已经播放完毕
+func (ri *ReplayInput) Done() bool {
+	return ri.pos >= len(ri.replay.frames)
+}
+
+// Verify 在模拟推进后调用，若当前帧有记录的校验值且不一致则返回错误
+func (ri *ReplayInput) Verify(sim *Simulation) error {
+	interval := ri.replay.interval
+	if sim.Tick == 0 || sim.Tick%interval != 0 {
+		return nil
+	}
+	idx := sim.Tick/interval - 1
+	if idx >= len(ri.replay.checksums) {
+		return nil
+	}
+	if got, want := sim.Checksum(), ri.replay.checksums[idx]; got != want {
+		return fmt.Errorf("回放在第%d帧不同步: 校验值%08x, 录像中为%08x", sim.Tick, got, want)
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+package sim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// replayHeaderSize 文件头标识和固定长度的文件头占用的字节数，之后是关卡包ID的长度
+const replayHeaderSize = len(replayMagic) + 18
+
+// recordAutopilot 以自动驾驶录制一局ticks帧的对局，返回录像和结束时的状态。
+// 与stepAutopilot一样每帧补满炸弹，回放时也必须这样做
+func recordAutopilot(mode GameMode, level int, seed int64, ticks int) (*Replay, simState) {
+	s := NewSimulation(levels, FieldStandard, mode, level, seed)
+	rec := NewReplayRecorder(s)
+	for i := 0; i < ticks; i++ {
+		s.Player.Bombs = initialBombs
+		s.Step(rec.Record(autopilot(s)))
+		rec.Checkpoint(s)
+	}
+	return rec.Replay(), stateOf(s)
+}
+
+// playReplay 回放录像直到播放完毕或第一次不同步，返回结束时的状态和不同步的错误
+func playReplay(r *Replay) (simState, error) {
+	s := r.NewSimulation(levels)
+	ri := NewReplayInput(r)
+	for !ri.Done() {
+		s.Player.Bombs = initialBombs
+		s.Step(ri.Poll())
+		if err := ri.Verify(s); err != nil {
+			return stateOf(s), err
+		}
+	}
+	return stateOf(s), nil
+}
+
+// encodeReplay 将录像写成二进制数据
+func encodeReplay(t *testing.T, r *Replay) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	n, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != int64(buf.Len()) {
+		t.Fatalf("WriteTo返回写入%d字节，实际写入%d字节", n, buf.Len())
+	}
+	return buf.Bytes()
+}
+
+// checkSameReplay 检查读回的录像与写出的录像完全相同
+func checkSameReplay(t *testing.T, got, want *Replay) {
+	t.Helper()
+	if got.seed != want.seed || got.mode != want.mode || got.level != want.level ||
+		got.pack != want.pack || got.field != want.field || got.interval != want.interval {
+		t.Errorf("读回的录像头为%+v，应为%+v",
+			[]any{got.seed, got.mode, got.level, got.pack, got.field, got.interval},
+			[]any{want.seed, want.mode, want.level, want.pack, want.field, want.interval})
+	}
+	if !slices.Equal(got.frames, want.frames) {
+		t.Errorf("读回%d帧输入，与写出的%d帧不同", len(got.frames), len(want.frames))
+	}
+	if !slices.Equal(got.checksums, want.checksums) {
+		t.Errorf("读回的校验值%v与写出的%v不同", got.checksums, want.checksums)
+	}
+}
+
+func TestReplayRoundTrip(t *testing.T) {
+	const ticks = 4000
+	replay, want := recordAutopilot(ModePlaying, 1, 42, ticks)
+	if len(replay.checksums) != ticks/replayChecksumInterval {
+		t.Fatalf("录制了%d个校验值，应为%d个", len(replay.checksums), ticks/replayChecksumInterval)
+	}
+
+	data := encodeReplay(t, replay)
+	if len(data) >= ticks*3 {
+		t.Errorf("%d帧的录像占%d字节，游程编码没有合并相同的帧", ticks, len(data))
+	}
+	got, err := ReadReplay(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameReplay(t, got, replay)
+
+	state, err := playReplay(got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state != want {
+		t.Errorf("回放结束时的状态不同:\n%+v\n%+v", state, want)
+	}
+
+	// 篡改文件中最后一个校验值，回放到该帧时应当报告不同步
+	tampered := slices.Clone(data)
+	tampered[len(tampered)-1] ^= 0xff
+	bad, err := ReadReplay(bytes.NewReader(tampered))
+	if err != nil {
+		t.Fatal(err)
+	}
+	state, err = playReplay(bad)
+	if err == nil {
+		t.Fatal("校验值被篡改的录像回放时没有报告不同步")
+	}
+	if last := len(replay.checksums) * replayChecksumInterval; state.tick != last {
+		t.Errorf("回放在第%d帧报告不同步，应在第%d帧: %v", state.tick, last, err)
+	}
+}
+
+func TestReplayRunLengthEncoding(t *testing.T) {
+	r := NewReplay(7, ModeEndless, 1, "", FieldVertical)
+	for i := 0; i < 1000; i++ {
+		r.frames = append(r.frames, InputState{Fire: true, MoveX: -1})
+	}
+	r.frames = append(r.frames, InputState{Bomb: true, Focus: true, MoveY: 1})
+	r.checksums = []uint32{1, 2}
+
+	data := encodeReplay(t, r)
+	// 两段游程：1000的uvarint占2字节，1占1字节，每段后跟3字节的帧
+	want := replayHeaderSize + 1 + 1 + (2 + 3) + (1 + 3) + 4 + 4*len(r.checksums)
+	if len(data) != want {
+		t.Errorf("录像占%d字节，应为%d字节", len(data), want)
+	}
+	got, err := ReadReplay(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSameReplay(t, got, r)
+}
+
+// downgradeReplay 把当前版本的录像数据改写为旧版本version的格式。
+// 第3版和第4版的格式相同，第4版只是增加了炸弹和低速按钮
+func downgradeReplay(data []byte, version uint8) []byte {
+	old := slices.Clone(data)
+	old[len(replayMagic)] = version
+	packLen := int(old[replayHeaderSize])
+	if version < 3 {
+		// 删除关卡包ID之后的场地类型
+		old = slices.Delete(old, replayHeaderSize+1+packLen, replayHeaderSize+2+packLen)
+	}
+	if version < 2 {
+		// 删除关卡包ID
+		old = slices.Delete(old, replayHeaderSize, replayHeaderSize+1+packLen)
+	}
+	return old
+}
+
+func TestReplayOldVersions(t *testing.T) {
+	// 旧版本的录像只能使用内置关卡、标准场地，也没有炸弹和低速按钮
+	replay, _ := recordAutopilot(ModePlaying, 2, 5, 600)
+	for i := range replay.frames {
+		replay.frames[i].Bomb = false
+	}
+	data := encodeReplay(t, replay)
+
+	for version := uint8(1); version <= replayVersion; version++ {
+		got, err := ReadReplay(bytes.NewReader(downgradeReplay(data, version)))
+		if err != nil {
+			t.Errorf("读取第%d版录像失败: %v", version, err)
+			continue
+		}
+		checkSameReplay(t, got, replay)
+	}
+}
+
+func TestReadReplayRejectsBadData(t *testing.T) {
+	replay, _ := recordAutopilot(ModePlaying, 1, 3, 300)
+	data := encodeReplay(t, replay)
+
+	// withFrames 返回文件头声明frames帧、截断在文件头之后的录像
+	withFrames := func(frames uint32) []byte {
+		b := slices.Clone(data[:replayHeaderSize])
+		binary.LittleEndian.PutUint32(b[replayHeaderSize-4:], frames)
+		return b
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"文件头标识", append([]byte("XXXX"), data[len(replayMagic):]...), "不是有效的录像文件"},
+		{"未来的版本", downgradeReplay(data, replayVersion+1), "不支持的录像版本"},
+		{"截断的帧", data[:replayHeaderSize+4], "读取录像帧失败"},
+		{"截断的校验值", data[:len(data)-2], "读取录像校验值失败"},
+		{"帧数超过上限", withFrames(math.MaxUint32), "超过了上限"},
+		{"帧数达到上限但被截断", withFrames(maxReplayFrames), "读取录像文件头失败"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadReplay(bytes.NewReader(tt.data))
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("错误为%v，应包含%q", err, tt.want)
+			}
+		})
+	}
+}
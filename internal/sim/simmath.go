@@ -0,0 +1,199 @@
+package sim
+
+import "math"
+
+// 模拟使用的三角函数和求模长。
+//
+// Go编译器在arm64等平台上会把a*b+c合并为一条只舍入一次的FMA指令，结果与分开舍入的amd64不同，
+// 标准库math的Sin、Cos、Atan和Hypot在这些平台上也是这样编译的。录像的校验值逐位比较模拟状态，
+// 差一位就会在另一个平台上回放时报告不同步。这里的实现与标准库的纯Go实现使用相同的算法和常数，
+// 但每个乘积都显式转换为float64：显式转换会舍入乘积，编译器不能再把它与加减合并，
+// 因此在所有平台上都得到与amd64上的标准库逐位相同的结果。
+//
+// 模拟代码中其余的乘加同样用显式转换舍入乘积。加上一个数的一半（如x+float64(w)/2）时乘积是精确的，
+// 合并与否结果相同，这类表达式不必转换
+
+// 把角度归约到[-π/4, π/4]时使用的常数，π/4拆成三部分以保留足够的精度
+const (
+	trigPI4A = 7.85398125648498535156e-1
+	trigPI4B = 3.77489470793079817668e-8
+	trigPI4C = 2.69515142907905952645e-15
+	// 超过该值时标准库改用另一种归约算法，模拟中的角度远小于该值，直接使用标准库
+	trigReduceThreshold = 1 << 29
+)
+
+// 正弦和余弦在[-π/4, π/4]上的多项式系数
+var (
+	sinCoeffs = [...]float64{
+		1.58962301576546568060e-10,
+		-2.50507477628578072866e-8,
+		2.75573136213857245213e-6,
+		-1.98412698295895385996e-4,
+		8.33333333332211858878e-3,
+		-1.66666666666666307295e-1,
+	}
+	cosCoeffs = [...]float64{
+		-1.13585365213876817300e-11,
+		2.08757008419747316778e-9,
+		-2.75573141792967388112e-7,
+		2.48015872888517045348e-5,
+		-1.38888888888730564116e-3,
+		4.16666666666665929218e-2,
+	}
+)
+
+// horner 以霍纳法则计算系数为coeffs（从最高次开始）的多项式在x处的值
+func horner(coeffs []float64, x float64) float64 {
+	r := coeffs[0]
+	for _, c := range coeffs[1:] {
+		r = float64(r*x) + c
+	}
+	return r
+}
+
+// reduceAngle 把非负的角度x归约到[-π/4, π/4]，返回x所在的八分之一圆周j（0到7）和归约后的角度z
+func reduceAngle(x float64) (uint64, float64) {
+	j := uint64(x * (4 / math.Pi))
+	y := float64(j)
+	// 奇数的八分之一圆周归到下一个，使z落在[-π/4, π/4]
+	if j&1 == 1 {
+		j++
+		y++
+	}
+	j &= 7
+	return j, ((x - float64(y*trigPI4A)) - float64(y*trigPI4B)) - float64(y*trigPI4C)
+}
+
+// sinKernel 返回z在[-π/4, π/4]上的正弦，zz为z*z
+func sinKernel(z, zz float64) float64 {
+	return z + float64(float64(z*zz)*horner(sinCoeffs[:], zz))
+}
+
+// cosKernel 返回z在[-π/4, π/4]上的余弦，zz为z*z
+func cosKernel(zz float64) float64 {
+	return (1.0 - float64(0.5*zz)) + float64(float64(zz*zz)*horner(cosCoeffs[:], zz))
+}
+
+// simSin 返回x的正弦，在所有平台上结果相同
+func simSin(x float64) float64 {
+	if x == 0 || math.IsNaN(x) || math.IsInf(x, 0) || math.Abs(x) >= trigReduceThreshold {
+		return math.Sin(x)
+	}
+	sign := x < 0
+	j, z := reduceAngle(math.Abs(x))
+	if j > 3 {
+		sign = !sign
+		j -= 4
+	}
+	zz := float64(z * z)
+	var y float64
+	if j == 1 || j == 2 {
+		y = cosKernel(zz)
+	} else {
+		y = sinKernel(z, zz)
+	}
+	if sign {
+		y = -y
+	}
+	return y
+}
+
+// simCos 返回x的余弦，在所有平台上结果相同
+func simCos(x float64) float64 {
+	if math.IsNaN(x) || math.IsInf(x, 0) || math.Abs(x) >= trigReduceThreshold {
+		return math.Cos(x)
+	}
+	sign := false
+	j, z := reduceAngle(math.Abs(x))
+	if j > 3 {
+		sign = !sign
+		j -= 4
+	}
+	if j > 1 {
+		sign = !sign
+	}
+	zz := float64(z * z)
+	var y float64
+	if j == 1 || j == 2 {
+		y = sinKernel(z, zz)
+	} else {
+		y = cosKernel(zz)
+	}
+	if sign {
+		y = -y
+	}
+	return y
+}
+
+// xatan 返回x在[0, 0.66]上的反正切
+func xatan(x float64) float64 {
+	const (
+		P0 = -8.750608600031904122785e-01
+		P1 = -1.615753718733365076637e+01
+		P2 = -7.500855792314704667340e+01
+		P3 = -1.228866684490136173410e+02
+		P4 = -6.485021904942025371773e+01
+		Q0 = +2.485846490142306297962e+01
+		Q1 = +1.650270098316988542046e+02
+		Q2 = +4.328810604912902668951e+02
+		Q3 = +4.853903996359136964868e+02
+		Q4 = +1.945506571482613964425e+02
+	)
+	z := float64(x * x)
+	p := horner([]float64{P0, P1, P2, P3, P4}, z)
+	q := horner([]float64{1, Q0, Q1, Q2, Q3, Q4}, z)
+	z = float64(z*p) / q
+	return float64(x*z) + x
+}
+
+// satan 把非负的x归约到[0, 0.66]后返回其反正切
+func satan(x float64) float64 {
+	const (
+		morebits = 6.123233995736765886130e-17 // π/2 = math.Pi/2 + morebits
+		tan3pio8 = 2.41421356237309504880      // tan(3π/8)
+	)
+	if x <= 0.66 {
+		return xatan(x)
+	}
+	if x > tan3pio8 {
+		return math.Pi/2 - xatan(1/x) + morebits
+	}
+	return math.Pi/4 + xatan((x-1)/(x+1)) + 0.5*morebits
+}
+
+// simAtan2 返回y/x的反正切，按x和y的符号确定象限，在所有平台上结果相同
+func simAtan2(y, x float64) float64 {
+	// 坐标轴上和无穷大、NaN的结果是常数，与平台无关
+	if x == 0 || y == 0 || math.IsNaN(x) || math.IsNaN(y) || math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return math.Atan2(y, x)
+	}
+	q := y / x
+	if q > 0 {
+		q = satan(q)
+	} else {
+		q = -satan(-q)
+	}
+	if x < 0 {
+		if q <= 0 {
+			return q + math.Pi
+		}
+		return q - math.Pi
+	}
+	return q
+}
+
+// simHypot 返回向量(p, q)的长度，在所有平台上结果相同
+func simHypot(p, q float64) float64 {
+	p, q = math.Abs(p), math.Abs(q)
+	if math.IsInf(p, 0) || math.IsInf(q, 0) || math.IsNaN(p) || math.IsNaN(q) {
+		return math.Hypot(p, q)
+	}
+	if p < q {
+		p, q = q, p
+	}
+	if p == 0 {
+		return 0
+	}
+	q = q / p
+	return p * math.Sqrt(1+float64(q*q))
+}
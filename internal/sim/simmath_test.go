@@ -0,0 +1,63 @@
+package sim
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// 在模拟中常见的范围内随机取点，计算simSin、simCos、simAtan2和simHypot的结果的FNV哈希。
+// 这些函数在所有平台上必须得到逐位相同的结果，哈希值是在不合并乘加的amd64上计算的，
+// 在arm64上或以GOAMD64=v3编译时结果不同说明有乘积没有显式舍入
+const simMathGoldenHash = 0x2bfa73149fb58b92
+
+func TestSimMathGolden(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	h := fnv.New64a()
+	var buf [8]byte
+	put := func(v float64) {
+		binary.LittleEndian.PutUint64(buf[:], math.Float64bits(v))
+		h.Write(buf[:])
+	}
+	for i := 0; i < 100000; i++ {
+		// 角度和坐标的数量级从0.01到10000
+		x := (rng.Float64() - 0.5) * math.Pow(10, float64(rng.Intn(7)-2))
+		y := (rng.Float64() - 0.5) * math.Pow(10, float64(rng.Intn(7)-2))
+		put(simSin(x))
+		put(simCos(x))
+		put(simAtan2(y, x))
+		put(simHypot(y, x))
+	}
+	if got := h.Sum64(); got != simMathGoldenHash {
+		t.Errorf("结果的哈希值为%#x，应为%#x", got, uint64(simMathGoldenHash))
+	}
+}
+
+func TestSimMathSpecialValues(t *testing.T) {
+	// 零、无穷大、NaN和超出归约范围的值交给标准库，结果与标准库相同
+	values := []float64{0, math.Copysign(0, -1), 1 << 30, -1 << 30, math.Inf(1), math.Inf(-1), math.NaN()}
+	same := func(a, b float64) bool {
+		return math.Float64bits(a) == math.Float64bits(b) || (math.IsNaN(a) && math.IsNaN(b))
+	}
+	for _, x := range values {
+		if got, want := simSin(x), math.Sin(x); !same(got, want) {
+			t.Errorf("simSin(%v) = %v，标准库为%v", x, got, want)
+		}
+		if got, want := simCos(x), math.Cos(x); !same(got, want) {
+			t.Errorf("simCos(%v) = %v，标准库为%v", x, got, want)
+		}
+		for _, y := range []float64{0, math.Copysign(0, -1), 1, -1, math.Inf(1), math.Inf(-1), math.NaN()} {
+			if x == 1<<30 || x == -1<<30 {
+				continue
+			}
+			if got, want := simAtan2(y, x), math.Atan2(y, x); !same(got, want) {
+				t.Errorf("simAtan2(%v, %v) = %v，标准库为%v", y, x, got, want)
+			}
+			if got, want := simHypot(y, x), math.Hypot(y, x); !same(got, want) {
+				t.Errorf("simHypot(%v, %v) = %v，标准库为%v", y, x, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,299 @@
+// Package sim 实现游戏的模拟部分：玩家、敌机、子弹、弹幕、BOSS、关卡和录像。
+// 模拟只依赖输入和随机种子，不依赖Ebiten，可以在没有显示设备的环境中运行和测试
+package sim
+
+import (
+	"math/rand"
+)
+
+// TicksPerSecond 模拟的固定步进频率，与Ebiten默认的TPS保持一致
+const TicksPerSecond = 60
+
+// GameMode 游戏模式。模拟只使用关卡模式和无尽模式，前两个值已不再使用，保留是为了不改变录像中记录的数值
+type GameMode int
+
+const (
+	ModeMenu        GameMode = iota // 菜单模式
+	ModeLevelSelect                 // 关卡选择模式
+	ModePlaying                     // 游戏进行中模式
+	ModeEndless                     // 无尽模式
+)
+
+// Simulation 表示一局游戏的纯逻辑状态，不依赖任何渲染或窗口
+type Simulation struct {
+	Player             *Player
+	targets            []*Player // 敌方可以瞄准的所有玩家
+	EnemyManager       *EnemyManager
+	BulletManager      *BulletManager
+	EnemyBulletManager *EnemyBulletManager
+	PowerUpManager     *PowerUpManager
+	EffectManager      *EffectManager
+	Score              int
+	IsGameOver         bool
+	AllCleared         bool       // 关卡模式下是否已通关所有关卡
+	Mode               GameMode   // 游戏模式（关卡模式或无尽模式）
+	Levels             *LevelSet  // 本局使用的关卡定义
+	Field              Playfield  // 本局使用的场地
+	CurrentLevel       int        // 当前关卡（仅用于关卡模式）
+	startLevel         int        // 本局开始时的关卡，Reset时回到该关卡
+	TargetScore        int        // 当前关卡目标分数
+	Tick               int        // 已执行的模拟帧数
+	BombFlash          int        // 炸弹闪光剩余的帧数，仅用于渲染
+	Sounds             []Sound    // 本帧产生的音效，仅用于播放，跨帧复用
+	seed               int64      // 本局随机种子
+	rng                *rand.Rand // 本局所有随机决策共用的随机数生成器
+	// BOSS相关字段
+	Boss               *Boss // 当前关卡BOSS
+	BossActive         bool  // BOSS是否已出现
+	bossDefeated       bool  // BOSS是否已被击败
+	bossScoreThreshold int   // 触发BOSS的分数阈值
+	// 碰撞相关字段
+	collisions *CollisionWorld // 碰撞规则与分发
+	entities   []Entity        // 本帧参与碰撞的实体，跨帧复用
+}
+
+// NewSimulation 使用关卡定义ls在场地field上创建一局新的模拟，level仅在关卡模式下生效，
+// 相同的seed和场地会产生完全相同的敌机、子弹、道具和BOSS行为
+func NewSimulation(ls *LevelSet, field PlayfieldKind, mode GameMode, level int, seed int64) *Simulation {
+	s := &Simulation{
+		Mode:         mode,
+		Levels:       ls,
+		Field:        playfields[field],
+		CurrentLevel: level,
+		startLevel:   level,
+		seed:         seed,
+	}
+	s.Reset()
+	return s
+}
+
+// Reset 将模拟重置到本局开始时的模式和关卡，并以原种子重新开始随机序列
+func (s *Simulation) Reset() {
+	s.rng = rand.New(rand.NewSource(s.seed))
+	s.collisions = s.newCollisionWorld()
+	s.Player = NewPlayer(s.Field)
+	s.targets = []*Player{s.Player}
+	s.EnemyManager = NewEnemyManager(s.rng, s.Field)
+	s.BulletManager = NewBulletManager(s.Field)
+	s.EnemyBulletManager = NewEnemyBulletManager(s.rng, s.Field)
+	s.PowerUpManager = NewPowerUpManager(s.rng, s.Field)
+	s.EffectManager = NewEffectManager()
+	s.Score = 0
+	s.IsGameOver = false
+	s.AllCleared = false
+	s.Tick = 0
+	s.BombFlash = 0
+	s.Sounds = s.Sounds[:0]
+	s.Boss = nil
+	s.BossActive = false
+	s.bossDefeated = false
+	s.CurrentLevel = s.startLevel
+	if s.Mode == ModePlaying {
+		s.setLevel(s.CurrentLevel)
+	}
+}
+
+// setLevel 切换到指定关卡，目标分数和敌机生成参数来自关卡定义
+func (s *Simulation) setLevel(level int) {
+	def := s.Levels.Level(level)
+	s.CurrentLevel = level
+	s.TargetScore = def.TargetScore
+	s.bossScoreThreshold = def.BossScore
+	s.EnemyManager.SetSpawn(def.Spawn)
+	s.EnemyManager.SetWaves(def.Waves)
+	s.BossActive = false
+	s.bossDefeated = false
+}
+
+// Step 以固定步长推进一帧模拟
+func (s *Simulation) Step(in InputState) {
+	s.Sounds = s.Sounds[:0]
+	if s.IsGameOver || s.AllCleared {
+		return
+	}
+	s.Tick++
+
+	// 更新玩家状态
+	s.Player.Update(in)
+	if s.BombFlash > 0 {
+		s.BombFlash--
+	}
+	if in.Bomb {
+		s.useBomb()
+	}
+
+	// 只有在BOSS没有出现时才生成普通敌机
+	if !s.BossActive {
+		// 更新敌机状态
+		s.EnemyManager.Update()
+	} else {
+		// 如果BOSS已经出现，更新BOSS状态
+		if s.Boss != nil && s.Boss.active {
+			phase := s.Boss.Phase
+			if s.Boss.Update(s.Player, s.EnemyBulletManager) {
+				s.playSound(s.Boss.Def.volley)
+			}
+			if s.Boss.Phase != phase {
+				s.playSound(SoundBossPhase)
+			}
+		}
+	}
+
+	// 更新子弹状态
+	if s.BulletManager.Update(s.Player, in.Fire) {
+		s.playSound(SoundShot)
+	}
+
+	// 更新敌方子弹状态
+	if s.BossActive {
+		// Boss激活时也需要更新子弹状态
+		s.EnemyBulletManager.Update(nil, s.targets)
+	} else {
+		s.EnemyBulletManager.Update(s.EnemyManager.Enemies, s.targets)
+	}
+
+	// 更新道具状态
+	s.PowerUpManager.Update()
+
+	// 更新爆炸等视觉效果
+	s.EffectManager.Update()
+
+	// 收集本帧所有实体并统一分发碰撞
+	s.entities = s.appendEntities(s.entities[:0])
+	s.collisions.Dispatch(s.entities)
+}
+
+// playSound 记录本帧产生的音效
+func (s *Simulation) playSound(sound Sound) {
+	s.Sounds = append(s.Sounds, sound)
+}
+
+// BombFlashTicks 使用炸弹后画面闪光的帧数
+const BombFlashTicks = 20
+
+// useBomb 消耗一枚炸弹清除场上所有敌机子弹，没有炸弹时什么也不做
+func (s *Simulation) useBomb() {
+	if s.Player.Bombs == 0 {
+		return
+	}
+	s.Player.Bombs--
+	s.EnemyBulletManager.Clear()
+	s.BombFlash = BombFlashTicks
+}
+
+// appendEntities 收集本帧参与碰撞的所有实体
+func (s *Simulation) appendEntities(entities []Entity) []Entity {
+	entities = append(entities, s.Player, s.Player.Core)
+	entities = s.BulletManager.AppendEntities(entities)
+	// BOSS出现后普通敌机不再参与碰撞
+	if !s.BossActive {
+		entities = s.EnemyManager.AppendEntities(entities)
+	} else if s.Boss != nil {
+		entities = append(entities, s.Boss)
+	}
+	entities = s.EnemyBulletManager.AppendEntities(entities)
+	entities = s.PowerUpManager.AppendEntities(entities)
+	return entities
+}
+
+// newCollisionWorld 注册对局中所有碰撞的处理规则
+func (s *Simulation) newCollisionWorld() *CollisionWorld {
+	w := NewCollisionWorld(s.Field.Width, s.Field.Height)
+	OnCollision(w, LayerPlayerBullet, LayerEnemy, s.onBulletHitEnemy)
+	OnCollision(w, LayerPlayerBullet, LayerBoss, s.onBulletHitBoss)
+	OnCollision(w, LayerPlayer, LayerPowerUp, s.onPlayerPickPowerUp)
+	OnCollision(w, LayerPlayer, LayerEnemy, s.onPlayerHit)
+	OnCollision(w, LayerPlayer, LayerBoss, s.onPlayerHit)
+	OnCollision(w, LayerPlayerCore, LayerEnemyBullet, s.onPlayerCoreHit)
+	return w
+}
+
+// onBulletHitEnemy 处理玩家子弹击中敌机
+func (s *Simulation) onBulletHitEnemy(bullet *Bullet, enemy *Enemy) {
+	bullet.active = false
+	enemy.Health -= 1     // 减少敌机血量
+	if enemy.Health > 0 { // 只有当血量为0时才销毁敌机
+		enemy.Anim.Hit()
+		s.playSound(SoundEnemyHit)
+		return
+	}
+	enemy.active = false
+	s.EffectManager.Explode(&enemy.Body, "enemy")
+	s.playSound(SoundEnemyDestroyed)
+	s.Score += 100
+	// 在敌机被击毁的位置生成道具
+	s.PowerUpManager.SpawnPowerUp(enemy.X, enemy.Y, s.Score)
+
+	// 关卡模式下，检查是否达到触发BOSS的分数
+	if s.Mode == ModePlaying && s.Score >= s.bossScoreThreshold && !s.BossActive && !s.bossDefeated {
+		// 触发BOSS战
+		s.BossActive = true
+		// 根据当前关卡创建对应的BOSS
+		s.Boss = NewBoss(s.Levels.Level(s.CurrentLevel).BossDef, s.rng, s.Field)
+		s.playSound(SoundBossWarning)
+	}
+}
+
+// onBulletHitBoss 处理玩家子弹击中BOSS
+func (s *Simulation) onBulletHitBoss(bullet *Bullet, boss *Boss) {
+	bullet.active = false
+	boss.Health -= s.Player.attackPower // 减少BOSS血量，考虑玩家攻击力
+	if boss.Health > 0 {
+		boss.Anim.Hit()
+		s.playSound(SoundEnemyHit)
+		return
+	}
+
+	// BOSS被击败
+	boss.active = false
+	s.EffectManager.Explode(&boss.Body, boss.Def.SpriteKey)
+	s.playSound(SoundBossDestroyed)
+	s.bossDefeated = true
+	s.Score += 2000 // BOSS奖励分数
+
+	// 在BOSS位置生成多个道具
+	for i := 0; i < 5; i++ {
+		offsetX := float64(s.rng.Intn(boss.Width))
+		offsetY := float64(s.rng.Intn(boss.Height))
+		s.PowerUpManager.SpawnPowerUp(boss.X+offsetX, boss.Y+offsetY, s.Score)
+	}
+
+	// 在关卡模式下，检查是否需要进入下一关
+	if s.Mode == ModePlaying {
+		if s.CurrentLevel < s.Levels.Count() {
+			// 进入下一关
+			s.setLevel(s.CurrentLevel + 1)
+		} else {
+			// 通关所有关卡，由上层决定如何展示
+			s.AllCleared = true
+		}
+	}
+}
+
+// onPlayerPickPowerUp 处理玩家拾取道具
+func (s *Simulation) onPlayerPickPowerUp(player *Player, powerUp *PowerUp) {
+	powerUp.active = false
+	s.playSound(powerUpSounds[powerUp.Kind])
+	// 根据道具类型给予玩家相应的能力
+	switch powerUp.Kind {
+	case MultiShot:
+		player.EnableMultiShot()
+	case ScreenShot:
+		player.EnableScreenShot()
+	}
+}
+
+// onPlayerCoreHit 处理敌机子弹命中玩家核心
+func (s *Simulation) onPlayerCoreHit(core *PlayerCore, bullet *EnemyBullet) {
+	s.onPlayerHit(core.player, bullet)
+}
+
+// onPlayerHit 处理玩家被敌机、BOSS或敌机子弹击中
+func (s *Simulation) onPlayerHit(player *Player, other Entity) {
+	// 同一帧可能被多个对象击中，只播放一次爆炸
+	if !s.IsGameOver {
+		s.EffectManager.Explode(&player.Body, "player")
+		s.playSound(SoundPlayerDeath)
+	}
+	s.IsGameOver = true
+}
@@ -0,0 +1,152 @@
+package sim
+
+import (
+	"slices"
+	"testing"
+)
+
+// autopilot 返回一个简单的自动驾驶操作：一直开火，停在场地底部对准最低的敌机或BOSS，
+// 敌机快要撞上时躲开，敌机子弹接近时使用炸弹。操作只取决于模拟状态，相同的模拟总是得到相同的操作
+func autopilot(s *Simulation) InputState {
+	p := s.Player
+	px, py := p.X+float64(p.Width)/2, p.Y+float64(p.Height)/2
+	in := InputState{MoveY: 1, Fire: true}
+
+	targetX, lowest := px, -1e9
+	if s.BossActive && s.Boss != nil {
+		targetX = s.Boss.X + float64(s.Boss.Width)/2
+	} else {
+		for _, e := range s.EnemyManager.Enemies {
+			if e.Y > lowest {
+				targetX, lowest = e.X+float64(e.Width)/2, e.Y
+			}
+		}
+	}
+	switch {
+	case targetX < px-4:
+		in.MoveX = -1
+	case targetX > px+4:
+		in.MoveX = 1
+	}
+
+	// 躲开已经飞到面前的敌机
+	for _, e := range s.EnemyManager.Enemies {
+		ex := e.X + float64(e.Width)/2
+		if e.Y+float64(e.Height) > py-120 && e.Y < py+float64(p.Height) && ex > px-60 && ex < px+60 {
+			in.MoveX = 1
+			if ex > px {
+				in.MoveX = -1
+			}
+			break
+		}
+	}
+
+	for _, b := range s.EnemyBulletManager.Bullets {
+		dx, dy := b.X-px, b.Y-py
+		if dx*dx+dy*dy < 40*40 {
+			in.Bomb = true
+			break
+		}
+	}
+	return in
+}
+
+// simState 模拟在某一帧的可比较状态
+type simState struct {
+	checksum     uint32
+	tick         int
+	score        int
+	level        int
+	gameOver     bool
+	enemies      int
+	bullets      int
+	enemyBullets int
+	powerUps     int
+	bossActive   bool
+}
+
+// stateOf 返回模拟当前的可比较状态
+func stateOf(s *Simulation) simState {
+	return simState{
+		checksum:     s.Checksum(),
+		tick:         s.Tick,
+		score:        s.Score,
+		level:        s.CurrentLevel,
+		gameOver:     s.IsGameOver,
+		enemies:      len(s.EnemyManager.Enemies),
+		bullets:      len(s.BulletManager.Bullets),
+		enemyBullets: len(s.EnemyBulletManager.Bullets),
+		powerUps:     len(s.PowerUpManager.PowerUps),
+		bossActive:   s.BossActive,
+	}
+}
+
+// stepAutopilot 以自动驾驶推进模拟一帧。炸弹不限量，使自动驾驶能在BOSS弹幕中坚持数千帧
+func stepAutopilot(s *Simulation) {
+	s.Player.Bombs = initialBombs
+	s.Step(autopilot(s))
+}
+
+// runSimulation 以自动驾驶推进一局模拟ticks帧，返回每一帧结束时的状态
+func runSimulation(mode GameMode, level int, seed int64, ticks int) []simState {
+	s := NewSimulation(levels, FieldStandard, mode, level, seed)
+	states := make([]simState, ticks)
+	for i := range states {
+		stepAutopilot(s)
+		states[i] = stateOf(s)
+	}
+	return states
+}
+
+func TestSimulationDeterministic(t *testing.T) {
+	const ticks = 5000
+	tests := []struct {
+		name  string
+		mode  GameMode
+		level int
+	}{
+		{"关卡1", ModePlaying, 1},
+		{"关卡3", ModePlaying, 3},
+		{"无尽模式", ModeEndless, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := runSimulation(tt.mode, tt.level, 42, ticks)
+			b := runSimulation(tt.mode, tt.level, 42, ticks)
+			for i := range a {
+				if a[i] != b[i] {
+					t.Fatalf("第%d帧状态不同:\n%+v\n%+v", i+1, a[i], b[i])
+				}
+			}
+			last := a[len(a)-1]
+			if last.tick < ticks/2 || last.score == 0 {
+				t.Errorf("自动驾驶只推进了%d帧，得分%d，没有覆盖足够长的对局", last.tick, last.score)
+			}
+
+			// 不同的种子应当产生不同的对局，否则说明随机决策没有使用本局的随机数生成器
+			c := runSimulation(tt.mode, tt.level, 43, ticks)
+			if slices.Equal(a, c) {
+				t.Errorf("种子42和43的%d帧状态完全相同", ticks)
+			}
+		})
+	}
+}
+
+func TestSimulationReset(t *testing.T) {
+	s := NewSimulation(levels, FieldStandard, ModePlaying, 1, 7)
+	for i := 0; i < 3000; i++ {
+		stepAutopilot(s)
+	}
+	want := stateOf(s)
+
+	s.Reset()
+	if s.Tick != 0 || s.Score != 0 || s.IsGameOver {
+		t.Fatalf("Reset后tick=%d score=%d isGameOver=%v，应为初始状态", s.Tick, s.Score, s.IsGameOver)
+	}
+	for i := 0; i < 3000; i++ {
+		stepAutopilot(s)
+	}
+	if got := stateOf(s); got != want {
+		t.Errorf("Reset后重新推进的状态不同:\n%+v\n%+v", got, want)
+	}
+}
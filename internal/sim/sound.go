@@ -0,0 +1,52 @@
+package sim
+
+// Sound 表示一种音效。模拟只记录本帧产生了哪些音效，由游戏中的AudioManager负责播放
+type Sound int
+
+const (
+	SoundShot              Sound = iota // 玩家发射子弹
+	SoundEnemyHit                       // 敌机或BOSS被击中
+	SoundEnemyDestroyed                 // 敌机爆炸
+	SoundBossDestroyed                  // BOSS爆炸
+	SoundPlayerDeath                    // 玩家被击毁
+	SoundPowerUpMultiShot               // 拾取多弹道道具
+	SoundPowerUpScreenShot              // 拾取全屏攻击道具
+	SoundPowerUpAttack                  // 拾取攻击力增强道具
+	SoundPowerUpClear                   // 拾取清除子弹道具
+	SoundBossWarning                    // BOSS出现
+	SoundBossPhase                      // BOSS进入下一阶段
+	SoundVolley                         // BOSS发射弹幕，BOSS定义省略volley时使用
+	SoundVolleyRing                     // 环形弹幕BOSS发射弹幕
+	SoundVolleyCross                    // 交叉弹幕BOSS发射弹幕
+	SoundVolleyHoming                   // 追踪弹幕BOSS发射弹幕
+	SoundVolleyChaos                    // 混合弹幕BOSS发射弹幕
+	SoundCount
+)
+
+// powerUpSounds 拾取每种道具时的音效
+var powerUpSounds = [...]Sound{
+	MultiShot:    SoundPowerUpMultiShot,
+	ScreenShot:   SoundPowerUpScreenShot,
+	AttackBoost:  SoundPowerUpAttack,
+	ClearBullets: SoundPowerUpClear,
+}
+
+// volleySounds BOSS定义中volley可以使用的音效名称
+var volleySounds = map[string]Sound{
+	"ring":   SoundVolleyRing,
+	"cross":  SoundVolleyCross,
+	"homing": SoundVolleyHoming,
+	"chaos":  SoundVolleyChaos,
+}
+
+// 音乐曲目名称，也是resources/audio/music中音乐文件去掉扩展名后的文件名
+const (
+	MusicMenu    = "menu"   // 主菜单和关卡选择
+	MusicEndless = "stage4" // 无尽模式
+)
+
+// levelMusic 关卡定义省略music时，第i关使用levelMusic[(i-1)%len(levelMusic)]
+var levelMusic = []string{"stage1", "stage2", "stage3", "stage4"}
+
+// MusicTracks 所有内置的音乐曲目，关卡定义的music必须是其中之一
+var MusicTracks = []string{MusicMenu, "stage1", "stage2", "stage3", "stage4"}
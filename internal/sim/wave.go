@@ -0,0 +1,152 @@
+package sim
+
+import (
+	"fmt"
+	"slices"
+)
+
+// EnemyKind 表示敌机种类
+type EnemyKind int
+
+const (
+	KindBasic EnemyKind = iota // 普通敌机
+	KindFast                   // 高速敌机，血量较低
+	KindTank                   // 重型敌机，速度慢、血量高
+)
+
+// enemyKindStats 敌机种类的基础属性
+type enemyKindStats struct {
+	speed       float64 // 基础速度，再乘以关卡的速度倍率
+	healthBonus int     // 在关卡血量基础上的增减
+}
+
+// enemyKinds 各敌机种类的基础属性
+var enemyKinds = map[EnemyKind]enemyKindStats{
+	KindBasic: {speed: 2, healthBonus: 0},
+	KindFast:  {speed: 3.5, healthBonus: -1},
+	KindTank:  {speed: 1.2, healthBonus: 3},
+}
+
+// EnemyPath 表示敌机的移动路径
+type EnemyPath int
+
+const (
+	PathStraight EnemyPath = iota // 垂直向下
+	PathSine                      // 向下飞行并左右摆动
+	PathDiagonal                  // 斜向穿过屏幕，从哪一侧入场就飞向另一侧
+)
+
+// FirePattern 表示敌机的射击方式
+type FirePattern int
+
+const (
+	FireRandom FirePattern = iota // 随机时机、随机角度射击
+	FireAimed                     // 定时瞄准最近的玩家射击
+	FireNone                      // 不射击
+)
+
+// 关卡文件中敌机种类、路径和射击方式的名称
+var (
+	enemyKindNames = map[string]EnemyKind{
+		"basic": KindBasic,
+		"fast":  KindFast,
+		"tank":  KindTank,
+	}
+	enemyPathNames = map[string]EnemyPath{
+		"straight": PathStraight,
+		"sine":     PathSine,
+		"diagonal": PathDiagonal,
+	}
+	firePatternNames = map[string]FirePattern{
+		"random": FireRandom,
+		"aimed":  FireAimed,
+		"none":   FireNone,
+	}
+)
+
+// WaveEvent 描述关卡时间轴上的一个出怪事件，省略的种类、路径和射击方式取第一个取值
+type WaveEvent struct {
+	At       int      `json:"at"`       // 触发帧，从进入关卡开始计算
+	Kind     string   `json:"kind"`     // 敌机种类：basic、fast、tank
+	Count    int      `json:"count"`    // 敌机数量，省略时为1
+	Delay    int      `json:"delay"`    // 同一事件中相邻敌机的出现间隔（帧）
+	X        *float64 `json:"x"`        // 第一架敌机入场的x坐标，省略时随机
+	SpacingX float64  `json:"spacingX"` // 相邻敌机入场x坐标的偏移
+	Path     string   `json:"path"`     // 移动路径：straight、sine、diagonal
+	Fire     string   `json:"fire"`     // 射击方式：random、aimed、none
+}
+
+// 出怪事件的上限。buildTimeline为每架敌机分配一项，时间轴上的敌机全部按计划生成、不受spawn.maxEnemies限制，
+// 上限防止关卡文件中过大的数值耗尽内存或一次生成过多敌机
+const (
+	maxWaveCount       = 100                      // 单个事件的敌机数量
+	maxWaveTick        = 10 * 60 * TicksPerSecond // 事件中任何一架敌机的生成帧
+	maxTimelineEnemies = 1000                     // 一个关卡时间轴上的敌机总数
+)
+
+// scheduledSpawn 表示时间轴上一架待生成的敌机
+type scheduledSpawn struct {
+	tick    int         // 生成帧
+	kind    EnemyKind   // 敌机种类
+	x       float64     // 入场x坐标，randomX为true时忽略
+	randomX bool        // 是否随机入场位置
+	path    EnemyPath   // 移动路径
+	fire    FirePattern // 射击方式
+}
+
+// validate 校验出怪事件，返回发现的第一个问题
+func (w *WaveEvent) validate() error {
+	if w.At < 0 || w.At > maxWaveTick {
+		return fmt.Errorf("at必须在0到%d之间，当前为%d", maxWaveTick, w.At)
+	}
+	if w.Count < 0 || w.Count > maxWaveCount {
+		return fmt.Errorf("count必须在0到%d之间，当前为%d", maxWaveCount, w.Count)
+	}
+	if w.Delay < 0 || w.Delay > maxWaveTick {
+		return fmt.Errorf("delay必须在0到%d之间，当前为%d", maxWaveTick, w.Delay)
+	}
+	// 上面的范围保证这里不会溢出
+	if last := w.At + (w.enemyCount()-1)*w.Delay; last > maxWaveTick {
+		return fmt.Errorf("最后一架敌机在第%d帧生成，不能晚于第%d帧", last, maxWaveTick)
+	}
+	if _, ok := enemyKindNames[w.Kind]; w.Kind != "" && !ok {
+		return fmt.Errorf("未知的kind %q", w.Kind)
+	}
+	if _, ok := enemyPathNames[w.Path]; w.Path != "" && !ok {
+		return fmt.Errorf("未知的path %q", w.Path)
+	}
+	if _, ok := firePatternNames[w.Fire]; w.Fire != "" && !ok {
+		return fmt.Errorf("未知的fire %q", w.Fire)
+	}
+	return nil
+}
+
+// enemyCount 返回事件生成的敌机数量，省略count时为1
+func (w *WaveEvent) enemyCount() int {
+	return max(w.Count, 1)
+}
+
+// buildTimeline 将出怪事件展开为按生成帧排序的时间轴，同一帧的敌机保持事件中的先后顺序
+func buildTimeline(waves []WaveEvent) []scheduledSpawn {
+	timeline := make([]scheduledSpawn, 0, len(waves))
+	for _, w := range waves {
+		for i := range w.enemyCount() {
+			spawn := scheduledSpawn{
+				tick:    w.At + i*w.Delay,
+				kind:    enemyKindNames[w.Kind],
+				randomX: w.X == nil,
+				path:    enemyPathNames[w.Path],
+				fire:    firePatternNames[w.Fire],
+			}
+			if w.X != nil {
+				// 间距可以是任意小数，显式舍入乘积使各平台结果相同，见simmath.go
+				spawn.x = *w.X + float64(float64(i)*w.SpacingX)
+			}
+			timeline = append(timeline, spawn)
+		}
+	}
+	slices.SortStableFunc(timeline, func(a, b scheduledSpawn) int {
+		return a.tick - b.tick
+	})
+	return timeline
+}
@@ -0,0 +1,58 @@
+package sim
+
+import (
+	"strings"
+	"testing"
+)
+
+// levelWithWaves 返回以内置第1关为模板、出怪时间轴为waves的关卡集合
+func levelWithWaves(waves ...WaveEvent) *LevelSet {
+	def := *levels.Level(1)
+	def.Requires = ""
+	def.Waves = waves
+	return &LevelSet{Levels: []*LevelDef{&def}}
+}
+
+func TestWaveValidateBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		wave WaveEvent
+		want string // 为空表示应通过校验
+	}{
+		{"上限内", WaveEvent{At: maxWaveTick - 99*10, Count: maxWaveCount, Delay: 10}, ""},
+		{"负数at", WaveEvent{At: -1}, "at必须在0到"},
+		{"过晚的at", WaveEvent{At: maxWaveTick + 1}, "at必须在0到"},
+		{"过多的count", WaveEvent{Count: 100000000}, "count必须在0到"},
+		{"负数delay", WaveEvent{Count: 2, Delay: -1}, "delay必须在0到"},
+		{"过大的delay", WaveEvent{Count: 2, Delay: 1 << 40}, "delay必须在0到"},
+		{"最后一架过晚", WaveEvent{At: 60, Count: maxWaveCount, Delay: maxWaveTick / 50}, "最后一架敌机"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := levelWithWaves(tt.wave).validate(bosses)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("校验失败: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("错误为%v，应包含%q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestWaveTimelineTotal(t *testing.T) {
+	waves := make([]WaveEvent, maxTimelineEnemies/maxWaveCount+1)
+	for i := range waves {
+		waves[i] = WaveEvent{At: i, Count: maxWaveCount}
+	}
+	err := levelWithWaves(waves...).validate(bosses)
+	if err == nil || !strings.Contains(err.Error(), "waves共有") {
+		t.Errorf("时间轴共%d架敌机时错误为%v", len(waves)*maxWaveCount, err)
+	}
+	if err := levelWithWaves(waves[1:]...).validate(bosses); err != nil {
+		t.Errorf("时间轴共%d架敌机时校验失败: %v", maxTimelineEnemies, err)
+	}
+}
@@ -0,0 +1,137 @@
+// Package synth 是一个小型芯片音乐合成器：方波、三角波和噪声振荡器加ADSR包络，
+// 把音色渲染为Ebiten音频流使用的16位立体声PCM数据
+package synth
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// Waveform 振荡器的波形
+type Waveform int
+
+const (
+	Square   Waveform = iota // 方波，音色由占空比决定
+	Triangle                 // 三角波，柔和的低音
+	Noise                    // 噪声，用于爆炸和打击声
+)
+
+// Envelope ADSR包络：音量在Attack秒内从0升到最大，Decay秒内降到Sustain，
+// 保持Hold秒后在Release秒内降到0
+type Envelope struct {
+	Attack  float64
+	Decay   float64
+	Sustain float64 // 保持阶段的音量，范围[0, 1]
+	Hold    float64
+	Release float64
+}
+
+// Length 返回包络的总时长（秒）
+func (e Envelope) Length() float64 {
+	return e.Attack + e.Decay + e.Hold + e.Release
+}
+
+// Level 返回开始发声t秒后的音量
+func (e Envelope) Level(t float64) float64 {
+	switch {
+	case t < e.Attack:
+		return t / e.Attack
+	case t < e.Attack+e.Decay:
+		return 1 - (1-e.Sustain)*(t-e.Attack)/e.Decay
+	case t < e.Attack+e.Decay+e.Hold:
+		return e.Sustain
+	case t < e.Length():
+		return e.Sustain * (1 - (t-e.Attack-e.Decay-e.Hold)/e.Release)
+	}
+	return 0
+}
+
+// Tone 合成器的一个声部：一个振荡器加一个包络，频率在发声期间从Freq线性滑到Slide
+type Tone struct {
+	Wave   Waveform
+	Duty   float64 // 方波的占空比，范围(0, 1)
+	Freq   float64 // 起始频率（Hz），噪声为噪声发生器的时钟频率
+	Slide  float64 // 结束频率（Hz），0表示频率不变
+	Delay  float64 // 开始发声前的静音时长（秒），用于排列琶音
+	Env    Envelope
+	Volume float64 // 最大音量，范围[0, 1]
+}
+
+// Blip 返回一个音量不变、短促收尾的方波声部，用于组成琶音和警报
+func Blip(freq, delay, length, volume float64) Tone {
+	return Tone{
+		Wave:   Square,
+		Duty:   0.5,
+		Freq:   freq,
+		Delay:  delay,
+		Env:    Envelope{Attack: 0.002, Sustain: 1, Hold: length, Release: 0.03},
+		Volume: volume,
+	}
+}
+
+// Preset 一个音效的音色，由同时或先后发声的多个声部混合而成
+type Preset []Tone
+
+// Duration 返回音效的总时长（秒）
+func (p Preset) Duration() float64 {
+	d := 0.0
+	for _, t := range p {
+		d = max(d, t.Delay+t.Env.Length())
+	}
+	return d
+}
+
+// Render 以采样率sampleRate渲染音效，返回16位小端立体声PCM数据，即Ebiten音频流的格式。
+// 渲染不使用随机数，相同的音色总是得到完全相同的数据，可以用数据的哈希值检查音色是否被意外改变
+func (p Preset) Render(sampleRate int) []byte {
+	mix := make([]float64, int(p.Duration()*float64(sampleRate)))
+	for i := range p {
+		p[i].render(mix, sampleRate)
+	}
+	pcm := make([]byte, len(mix)*4)
+	for i, v := range mix {
+		s := uint16(int16(math.Round(math.Max(-1, math.Min(1, v)) * math.MaxInt16)))
+		binary.LittleEndian.PutUint16(pcm[i*4:], s)
+		binary.LittleEndian.PutUint16(pcm[i*4+2:], s)
+	}
+	return pcm
+}
+
+// render 把声部叠加到mix上
+func (t *Tone) render(mix []float64, sampleRate int) {
+	rate := float64(sampleRate)
+	start := int(t.Delay * rate)
+	n := int(t.Env.Length() * rate)
+	phase := 0.0
+	// 噪声使用与红白机相同的15位线性反馈移位寄存器，每个时钟周期移位一次
+	lfsr := uint16(1)
+	for i := 0; i < n && start+i < len(mix); i++ {
+		freq := t.Freq
+		if t.Slide > 0 {
+			freq += (t.Slide - t.Freq) * float64(i) / float64(n)
+		}
+		phase += freq / rate
+		for phase >= 1 {
+			phase--
+			if t.Wave == Noise {
+				bit := (lfsr ^ lfsr>>1) & 1
+				lfsr = lfsr>>1 | bit<<14
+			}
+		}
+
+		var v float64
+		switch t.Wave {
+		case Square:
+			v = -1
+			if phase < t.Duty {
+				v = 1
+			}
+		case Triangle:
+			v = 1 - float64(4*math.Abs(phase-0.5))
+		case Noise:
+			v = float64(lfsr&1)*2 - 1
+		}
+		// 显式转换阻止编译器在arm64等平台上把乘法和加法合并为FMA指令，使各平台渲染的数据完全相同
+		mix[start+i] += float64(v * t.Env.Level(float64(i)/rate) * t.Volume)
+	}
+}
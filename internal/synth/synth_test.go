@@ -0,0 +1,56 @@
+package synth
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+)
+
+func TestEnvelopeLevel(t *testing.T) {
+	e := Envelope{Attack: 0.1, Decay: 0.1, Sustain: 0.5, Hold: 0.2, Release: 0.1}
+	tests := []struct {
+		t, want float64
+	}{
+		{0, 0},
+		{0.05, 0.5},
+		{0.15, 0.75},
+		{0.3, 0.5},
+		{0.45, 0.25},
+		{0.5, 0},
+		{1, 0},
+	}
+	for _, tt := range tests {
+		if got := e.Level(tt.t); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("Level(%v) = %v，应为%v", tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestPresetRender(t *testing.T) {
+	const rate = 1000
+	p := Preset{
+		Blip(100, 0, 0.1, 1),
+		{Wave: Noise, Freq: 500, Delay: 0.2, Env: Envelope{Sustain: 1, Hold: 0.1}, Volume: 0.5},
+	}
+	if got := p.Duration(); math.Abs(got-0.3) > 1e-9 {
+		t.Fatalf("Duration() = %v，应为0.3", got)
+	}
+	pcm := p.Render(rate)
+	if len(pcm) != 300*4 {
+		t.Fatalf("渲染%d字节，应为%d", len(pcm), 300*4)
+	}
+	for i := 0; i < len(pcm); i += 4 {
+		l := int16(binary.LittleEndian.Uint16(pcm[i:]))
+		r := int16(binary.LittleEndian.Uint16(pcm[i+2:]))
+		if l != r {
+			t.Fatalf("第%d个采样左右声道不同: %d, %d", i/4, l, r)
+		}
+	}
+	// 方波以满音量发声，两个声部之间静音
+	if s := int16(binary.LittleEndian.Uint16(pcm[10*4:])); s != math.MaxInt16 && s != -math.MaxInt16 {
+		t.Errorf("方波第10个采样为%d，应为满幅", s)
+	}
+	if s := binary.LittleEndian.Uint16(pcm[170*4:]); s != 0 {
+		t.Errorf("两个声部之间的采样为%d，应为0", s)
+	}
+}
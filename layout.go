@@ -0,0 +1,142 @@
+package main
+
+import (
+	"image"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/sim"
+)
+
+// hudPanelWidth 侧边栏HUD的宽度，场地旁边的空间不小于它时HUD显示在侧边栏中
+const hudPanelWidth = 180
+
+// Resolution 是游戏画面的内部分辨率。菜单和HUD按内部分辨率布局，整个画面再缩放到窗口中
+type Resolution struct {
+	name   string
+	width  int
+	height int
+}
+
+// resolutions 可选的内部分辨率，宽高都不小于640x480以保证菜单能完整显示
+var resolutions = []Resolution{
+	{"640x480", 640, 480},   // 4:3
+	{"800x600", 800, 600},   // 4:3
+	{"854x480", 854, 480},   // 16:9
+	{"960x540", 960, 540},   // 16:9
+	{"1280x720", 1280, 720}, // 16:9
+}
+
+// findResolution 按名称查找内部分辨率，返回其在resolutions中的下标
+func findResolution(name string) (int, bool) {
+	for i, r := range resolutions {
+		if r.name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// DisplaySettings 画面相关的设置
+type DisplaySettings struct {
+	resolution   int               // 内部分辨率在resolutions中的下标
+	playfield    sim.PlayfieldKind // 新对局使用的场地类型
+	pixelPerfect bool              // 只按整数倍缩放，并使用最近邻采样保持像素清晰
+	windowScale  int               // 启动时窗口相对内部分辨率的倍数
+}
+
+// display 当前的画面设置，修改后调用applyDisplay生效
+var display = DisplaySettings{windowScale: 1}
+
+// applyDisplay 使画面设置生效：更新内部分辨率，并按窗口倍数调整窗口大小
+func applyDisplay() {
+	res := resolutions[display.resolution]
+	screenWidth, screenHeight = res.width, res.height
+	ebiten.SetWindowSize(res.width*display.windowScale, res.height*display.windowScale)
+}
+
+// viewTransform 描述把一块画面等比缩放后放到目标区域中央的变换
+type viewTransform struct {
+	scale   float64
+	offsetX float64
+	offsetY float64
+}
+
+// fitView 计算把w×h的画面放入areaW×areaH区域中央的变换。pixelPerfect时只按整数倍放大，
+// 区域比画面还小时仍按比例缩小，保证画面完整可见
+func fitView(w, h, areaW, areaH int, pixelPerfect bool) viewTransform {
+	scale := math.Min(float64(areaW)/float64(w), float64(areaH)/float64(h))
+	if pixelPerfect && scale >= 1 {
+		scale = math.Floor(scale)
+	}
+	return viewTransform{
+		scale:   scale,
+		offsetX: math.Floor((float64(areaW) - float64(w)*scale) / 2),
+		offsetY: math.Floor((float64(areaH) - float64(h)*scale) / 2),
+	}
+}
+
+// apply 将变换追加到geoM上
+func (v viewTransform) apply(geoM *ebiten.GeoM) {
+	geoM.Scale(v.scale, v.scale)
+	geoM.Translate(v.offsetX, v.offsetY)
+}
+
+// toLocal 将目标区域中的坐标换算回画面中的坐标，变换尚未计算时原样返回
+func (v viewTransform) toLocal(x, y int) (int, int) {
+	if v.scale == 0 {
+		return x, y
+	}
+	lx := (float64(x) - v.offsetX) / v.scale
+	ly := (float64(y) - v.offsetY) / v.scale
+	return int(math.Floor(lx)), int(math.Floor(ly))
+}
+
+// filter 返回绘制缩放后画面应使用的采样方式
+func (v viewTransform) filter() ebiten.Filter {
+	if display.pixelPerfect || v.scale == math.Trunc(v.scale) {
+		return ebiten.FilterNearest
+	}
+	return ebiten.FilterLinear
+}
+
+// drawView 按变换v把src绘制到dst上
+func drawView(dst, src *ebiten.Image, v viewTransform) {
+	options := &ebiten.DrawImageOptions{Filter: v.filter()}
+	v.apply(&options.GeoM)
+	dst.DrawImage(src, options)
+}
+
+// playLayout 描述对局画面在内部画面中的布局
+type playLayout struct {
+	field viewTransform   // 场地到内部画面的变换
+	rect  image.Rectangle // 场地在内部画面中占据的区域
+	panel image.Rectangle // 侧边栏区域，HUD叠加在场地上时为空
+}
+
+// newPlayLayout 计算场地和HUD在内部画面中的布局：场地按高度缩放到画面中，
+// 旁边的空间足够时在场地右侧留出侧边栏显示HUD，否则HUD叠加在场地左上角
+func newPlayLayout(field sim.Playfield) playLayout {
+	v := fitView(field.Width, field.Height, screenWidth, screenHeight, display.pixelPerfect)
+	fieldW := int(float64(field.Width) * v.scale)
+	fieldH := int(float64(field.Height) * v.scale)
+
+	var layout playLayout
+	if spare := screenWidth - fieldW; spare >= hudPanelWidth {
+		// 场地和侧边栏作为一个整体水平居中
+		v.offsetX = float64((spare - hudPanelWidth) / 2)
+		panelX := int(v.offsetX) + fieldW
+		layout.panel = image.Rect(panelX, int(v.offsetY), panelX+hudPanelWidth, int(v.offsetY)+fieldH)
+	}
+	layout.field = v
+	layout.rect = image.Rect(int(v.offsetX), int(v.offsetY), int(v.offsetX)+fieldW, int(v.offsetY)+fieldH)
+	return layout
+}
+
+// hudOrigin 返回HUD文字的起始位置：侧边栏中或场地左上角
+func (l playLayout) hudOrigin() (int, int) {
+	if !l.panel.Empty() {
+		return l.panel.Min.X + 20, l.panel.Min.Y + 30
+	}
+	return l.rect.Min.X + 20, l.rect.Min.Y + 30
+}
@@ -4,86 +4,170 @@ import (
 	"fmt"
 	"image/color"
 	"math"
+	"strings"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/text"
+	"go-play-plane/internal/sim"
+	"golang.org/x/image/font"
 )
 
+// 关卡选择菜单的标签页
+const (
+	tabBuiltin = iota // 内置关卡
+	tabCustom         // 模组目录中的自定义关卡
+)
+
+// levelTabNames 各标签页的标题
+var levelTabNames = []string{"内置关卡", "自定义"}
+
+// levelsPerRow 每行显示的关卡数量，关卡更多时按行翻页
+const levelsPerRow = 5
+
 // LevelSelectMenu 关卡选择菜单
 type LevelSelectMenu struct {
-	levels           int         // 可选关卡数量
-	currentSelection int         // 当前选中的关卡
-	animTimer        int         // 动画计时器
-	titleScale       float64     // 标题缩放
-	titleRotation    float64     // 标题旋转
-	levelsAlpha      float64     // 关卡透明度
-	ready            bool        // 是否已准备好
-	levelInfos       []levelInfo // 关卡信息
+	tab              int            // 当前标签页
+	tabInfos         [2][]levelInfo // 各标签页的关卡信息
+	levels           int            // 当前标签页的可选关卡数量
+	currentSelection int            // 当前选中的关卡
+	animTimer        int            // 动画计时器
+	nav              menuNav        // 方向键和摇杆的导航状态
+	titleScale       float64        // 标题缩放
+	titleRotation    float64        // 标题旋转
+	levelsAlpha      float64        // 关卡透明度
+	ready            bool           // 是否已准备好
+	levelInfos       []levelInfo    // 当前标签页的关卡信息
 }
 
 // levelInfo 关卡信息结构体
 type levelInfo struct {
-	name        string   // 关卡名称
-	description string   // 关卡描述
-	bossName    string   // BOSS名称
-	bossType    BossType // BOSS类型
-	difficulty  int      // 难度（1-5星）
-	locked      bool     // 是否锁定
+	name        string        // 关卡名称
+	description string        // 关卡描述
+	bossName    string        // BOSS名称
+	difficulty  int           // 难度（1-5星）
+	requires    int           // 需要先通关的关卡在同一标签页中的下标，-1表示默认解锁
+	locked      bool          // 是否锁定
+	key         string        // 关卡在进度文件中的键
+	record      LevelRecord   // 关卡的通关记录
+	pack        *sim.LevelSet // 关卡所属的关卡包
+	level       int           // 关卡在关卡包中的序号（从1开始）
+	err         *ModError     // 加载失败的模组，不为nil时该项只用于显示错误
 }
 
-// NewLevelSelectMenu 创建一个新的关卡选择菜单
+// NewLevelSelectMenu 根据当前的关卡定义和已加载的模组创建关卡选择菜单
 func NewLevelSelectMenu() *LevelSelectMenu {
-	// 创建关卡信息
-	levelInfos := []levelInfo{
-		{
-			name:        "第1关：初入战场",
-			description: "遭遇第一个BOSS，熟悉控制",
-			bossName:    "环形魔王",
-			bossType:    BossType1,
-			difficulty:  1,
-			locked:      false,
-		},
-		{
-			name:        "第2关：交叉火力",
-			description: "小心交叉弹幕的包围",
-			bossName:    "十字统领",
-			bossType:    BossType2,
-			difficulty:  2,
-			locked:      true,
-		},
-		{
-			name:        "第3关：追踪猎手",
-			description: "BOSS会发射追踪弹幕",
-			bossName:    "追猎者",
-			bossType:    BossType3,
-			difficulty:  3,
-			locked:      true,
-		},
-		{
-			name:        "第4关：混沌风暴",
-			description: "终极BOSS，混合所有弹幕类型",
-			bossName:    "混沌大帝",
-			bossType:    BossType4,
-			difficulty:  5,
-			locked:      true,
-		},
+	// 创建关卡信息，自定义标签页先列出各关卡包的关卡，再列出加载失败的模组
+	var tabInfos [2][]levelInfo
+	tabInfos[tabBuiltin] = appendLevelInfos(nil, levels)
+	for _, ls := range levelPacks {
+		tabInfos[tabCustom] = appendLevelInfos(tabInfos[tabCustom], ls)
+	}
+	for _, modErr := range modErrors {
+		tabInfos[tabCustom] = append(tabInfos[tabCustom], levelInfo{
+			name:     modErr.mod,
+			requires: -1,
+			locked:   true,
+			err:      modErr,
+		})
 	}
 
 	return &LevelSelectMenu{
-		levels:           len(levelInfos),
+		tab:              tabBuiltin,
+		tabInfos:         tabInfos,
+		levels:           len(tabInfos[tabBuiltin]),
 		currentSelection: 0,
 		animTimer:        0,
 		titleScale:       0.1,
 		titleRotation:    0.0,
 		levelsAlpha:      0.0,
 		ready:            false,
-		levelInfos:       levelInfos,
+		levelInfos:       tabInfos[tabBuiltin],
+	}
+}
+
+// appendLevelInfos 将关卡包ls中的关卡追加到infos末尾
+func appendLevelInfos(infos []levelInfo, ls *sim.LevelSet) []levelInfo {
+	start := len(infos)
+	for i, def := range ls.Levels {
+		requires := -1
+		if def.Requires != "" {
+			requires = start + ls.Index(def.Requires)
+		}
+		infos = append(infos, levelInfo{
+			name:        def.Name,
+			description: def.Description,
+			bossName:    def.BossDef.Name,
+			difficulty:  def.Difficulty,
+			requires:    requires,
+			locked:      requires >= 0,
+			key:         levelKey(ls, def),
+			pack:        ls,
+			level:       i + 1,
+		})
+	}
+	return infos
+}
+
+// refreshProgress 根据当前的关卡进度更新通关记录，前置关卡已通关的关卡解锁。
+// 只在进入菜单和对局记录了新成绩时调用，不必每帧查询进度
+func (lsm *LevelSelectMenu) refreshProgress() {
+	for _, infos := range lsm.tabInfos {
+		for i := range infos {
+			info := &infos[i]
+			if info.err != nil {
+				continue
+			}
+			info.record = progress.Record(info.key)
+			info.locked = info.requires >= 0 && !progress.Record(infos[info.requires].key).Cleared
+		}
 	}
 }
 
-// Update 更新关卡选择菜单
-func (lsm *LevelSelectMenu) Update(game *Game) bool {
+// switchTab 切换到指定标签页并选中第一项
+func (lsm *LevelSelectMenu) switchTab(tab int) {
+	lsm.tab = tab
+	lsm.levelInfos = lsm.tabInfos[tab]
+	lsm.levels = len(lsm.levelInfos)
+	lsm.currentSelection = 0
+}
+
+// canStart 返回当前选中的关卡是否可以开始
+func (lsm *LevelSelectMenu) canStart() bool {
+	return lsm.levels > 0 && !lsm.levelInfos[lsm.currentSelection].locked
+}
+
+// tabRect 返回第tab个标签页标题的位置和大小
+func tabRect(tab int) (x, y, width, height float64) {
+	return float64(screenWidth/2 - 130 + tab*140), 108, 120, 32
+}
+
+// levelRowX 返回当前行第col个关卡按钮的X坐标，各行以画面中线为基准排列
+func levelRowX(col int) int {
+	return screenWidth/2 - levelsPerRow*100/2 + col*100
+}
+
+// Enter 进入关卡选择菜单，按当前进度刷新通关记录和解锁状态
+func (lsm *LevelSelectMenu) Enter(g *Game) {
+	lsm.refreshProgress()
+}
+
+// Resume 从对局返回时刷新成绩和解锁状态，对局期间菜单一直在栈中，不会再次调用Enter
+func (lsm *LevelSelectMenu) Resume(g *Game) {
+	lsm.refreshProgress()
+}
+
+// Exit 离开关卡选择菜单
+func (lsm *LevelSelectMenu) Exit(g *Game) {}
+
+// startLevel 以关卡模式开始当前选中的关卡
+func (lsm *LevelSelectMenu) startLevel(g *Game) {
+	info := lsm.levelInfos[lsm.currentSelection]
+	g.scenes.Push(g, NewPlayScene(sim.NewSimulation(info.pack, display.playfield, sim.ModePlaying, info.level, newSeed())))
+}
+
+// Update 根据本帧输入更新关卡选择菜单
+func (lsm *LevelSelectMenu) Update(g *Game, in sim.InputState) error {
 	lsm.animTimer++
 
 	// 标题动画效果
@@ -112,35 +196,36 @@ func (lsm *LevelSelectMenu) Update(game *Game) bool {
 
 	// 只有在准备好后才能选择关卡
 	if lsm.ready {
-		// 键盘操作
-		if ebiten.IsKeyPressed(ebiten.KeyLeft) && !ebiten.IsKeyPressed(ebiten.KeyRight) {
-			if lsm.animTimer%10 == 0 { // 降低移动速度
-				lsm.currentSelection--
-				if lsm.currentSelection < 0 {
-					lsm.currentSelection = lsm.levels - 1
-				}
-			}
-		} else if ebiten.IsKeyPressed(ebiten.KeyRight) && !ebiten.IsKeyPressed(ebiten.KeyLeft) {
-			if lsm.animTimer%10 == 0 { // 降低移动速度
-				lsm.currentSelection++
-				if lsm.currentSelection >= lsm.levels {
-					lsm.currentSelection = 0
-				}
-			}
+		// 上下键切换标签页，按住不放时不重复切换
+		dx, dy := lsm.nav.update(in)
+		if dy != 0 && lsm.nav.pressed() {
+			lsm.switchTab(1 - lsm.tab)
+		}
+
+		// 左右键选择关卡，当前标签页没有关卡时不移动选择
+		if lsm.levels > 0 && dx != 0 {
+			lsm.currentSelection = cycle(lsm.currentSelection, lsm.levels, dx)
 		}
 
 		// 鼠标操作
-		if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
-			mx, my := ebiten.CursorPosition()
+		if in.Click && in.HasCursor {
+			mx, my := in.CursorX, in.CursorY
+
+			// 检查点击的是哪个标签页
+			for tab := range levelTabNames {
+				if x, y, w, h := tabRect(tab); checkCursorInArea(in, x, y, w, h) && tab != lsm.tab {
+					lsm.switchTab(tab)
+					return nil
+				}
+			}
 
-			// 检查点击的是哪个关卡
-			for i := 0; i < lsm.levels; i++ {
-				// 计算每行最多显示5个关卡
-				row := i / 5
-				col := i % 5
+			// 检查点击的是当前行的哪个关卡
+			page := lsm.currentSelection / levelsPerRow
+			for i := page * levelsPerRow; i < min(lsm.levels, (page+1)*levelsPerRow); i++ {
+				col := i % levelsPerRow
 
-				x := 70 + col*100
-				y := 150 + row*90
+				x := levelRowX(col)
+				y := 150
 
 				if float64(mx) >= float64(x) && float64(mx) <= float64(x+70) &&
 					float64(my) >= float64(y) && float64(my) <= float64(y+70) {
@@ -154,15 +239,10 @@ func (lsm *LevelSelectMenu) Update(game *Game) bool {
 			startY := screenHeight/2 + 150
 			if float64(mx) >= float64(startX) && float64(mx) <= float64(startX+200) &&
 				float64(my) >= float64(startY-30) && float64(my) <= float64(startY+10) &&
-				!lsm.levelInfos[lsm.currentSelection].locked {
+				lsm.canStart() {
 				// 开始所选关卡
-				game.currentLevel = lsm.currentSelection + 1
-				game.targetScore = game.currentLevel * 1000
-				game.enemyManager.SetLevel(game.currentLevel)
-				game.bossActive = false // 初始没有BOSS
-				game.bossDefeated = false
-				game.gameMode = ModePlaying // 切换到游戏模式
-				return true
+				lsm.startLevel(g)
+				return nil
 			}
 
 			// 检查是否点击了返回按钮
@@ -171,43 +251,30 @@ func (lsm *LevelSelectMenu) Update(game *Game) bool {
 			if float64(mx) >= float64(backX-60) && float64(mx) <= float64(backX+60) &&
 				float64(my) >= float64(backY-25) && float64(my) <= float64(backY+15) {
 				// 返回主菜单
-				game.gameMode = ModeMenu
-				return true
+				g.scenes.Pop(g)
+				return nil
 			}
 		}
 
 		// 空格或回车键确认选择
-		if (ebiten.IsKeyPressed(ebiten.KeySpace) || ebiten.IsKeyPressed(ebiten.KeyEnter)) &&
-			!lsm.levelInfos[lsm.currentSelection].locked {
+		if in.Confirm && lsm.canStart() {
 			// 开始所选关卡
-			game.currentLevel = lsm.currentSelection + 1
-			game.targetScore = game.currentLevel * 1000
-			game.enemyManager.SetLevel(game.currentLevel)
-			game.bossActive = false // 初始没有BOSS
-			game.bossDefeated = false
-			game.gameMode = ModePlaying // 切换到游戏模式
-			return true
+			lsm.startLevel(g)
+			return nil
 		}
 
 		// ESC键返回主菜单
-		if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-			game.gameMode = ModeMenu
-			return true
-		}
-	}
-
-	// 解锁已完成关卡的下一关
-	for i := 0; i < lsm.levels-1; i++ {
-		if !lsm.levelInfos[i].locked {
-			lsm.levelInfos[i+1].locked = false
+		if in.Back {
+			g.scenes.Pop(g)
+			return nil
 		}
 	}
 
-	return false
+	return nil
 }
 
 // Draw 绘制关卡选择菜单
-func (lsm *LevelSelectMenu) Draw(screen *ebiten.Image) {
+func (lsm *LevelSelectMenu) Draw(g *Game, screen *ebiten.Image) {
 	// 绘制渐变背景
 	gradientTop := color.RGBA{20, 20, 60, 255}
 	gradientBottom := color.RGBA{40, 40, 100, 255}
@@ -266,23 +333,47 @@ func (lsm *LevelSelectMenu) Draw(screen *ebiten.Image) {
 	ebitenutil.DrawRect(screen, float64(backX-60), float64(backY-25), 120, 40, color.RGBA{0, 0, 100, menuAlpha})
 	text.Draw(screen, "返回", chineseFont, backX-30, backY, color.RGBA{255, 255, 255, menuAlpha})
 
-	// 绘制关卡选项
-	for i := 0; i < lsm.levels; i++ {
+	// 绘制标签页标题
+	for tab, name := range levelTabNames {
+		x, y, w, h := tabRect(tab)
+		tabColor := color.RGBA{0, 50, 150, menuAlpha}
+		if tab == lsm.tab {
+			tabColor = color.RGBA{0, 100, 200, menuAlpha}
+		}
+		ebitenutil.DrawRect(screen, x, y, w, h, tabColor)
+		nameX := int(x+w/2) - len([]rune(name))*12
+		text.Draw(screen, name, chineseFont, nameX, int(y)+25, color.RGBA{255, 255, 255, menuAlpha})
+	}
+
+	// 自定义标签页为空时提示模组目录的位置
+	if lsm.levels == 0 {
+		emptyText := fmt.Sprintf("没有找到自定义关卡，请将关卡包放到 %s 目录下", *modsFlag)
+		emptyX := screenWidth/2 - font.MeasureString(smallFont, emptyText).Round()/2
+		text.Draw(screen, emptyText, smallFont, emptyX, 190, color.RGBA{200, 200, 200, menuAlpha})
+	}
+
+	// 绘制当前行的关卡选项，关卡超过一行时按行翻页
+	page := lsm.currentSelection / levelsPerRow
+	for i := page * levelsPerRow; i < min(lsm.levels, (page+1)*levelsPerRow); i++ {
 		levelInfo := lsm.levelInfos[i]
 
-		// 计算每行最多显示5个关卡
-		row := i / 5
-		col := i % 5
+		col := i % levelsPerRow
 
-		levelX := 70 + col*100
-		levelY := 150 + row*90
+		levelX := levelRowX(col)
+		levelY := 150
 
 		// 判断是否是当前选中的关卡
 		isSelected := (i == lsm.currentSelection)
 
 		// 关卡选项背景
 		var levelBgColor color.RGBA
-		if levelInfo.locked {
+		if levelInfo.err != nil {
+			// 加载失败的模组显示红色
+			levelBgColor = color.RGBA{150, 30, 30, menuAlpha}
+			if isSelected {
+				levelBgColor = color.RGBA{200, 50, 50, menuAlpha}
+			}
+		} else if levelInfo.locked {
 			// 锁定的关卡显示灰色
 			levelBgColor = color.RGBA{100, 100, 100, menuAlpha}
 		} else if isSelected {
@@ -305,10 +396,36 @@ func (lsm *LevelSelectMenu) Draw(screen *ebiten.Image) {
 		// 绘制关卡图标背景
 		ebitenutil.DrawRect(screen, float64(levelX), float64(levelY), 70, 70, levelBgColor)
 
-		// 绘制关卡数字
-		levelNumText := fmt.Sprintf("%d", i+1)
+		// 加载失败的模组绘制感叹号，不显示关卡数字和锁
+		if levelInfo.err != nil {
+			text.Draw(screen, "!", chineseFont, levelX+30, levelY+45, color.RGBA{255, 255, 255, menuAlpha})
+			continue
+		}
+
+		// 绘制关卡数字，有成绩的关卡把数字上移，在下方显示最高分和最快通关用时
+		record := levelInfo.record
+		hasRecord := record.Cleared || record.BestScore > 0
+		numY := levelY + 40
+		if hasRecord {
+			numY = levelY + 32
+		}
+		levelNumText := fmt.Sprintf("%d", levelInfo.level)
 		numX := levelX + 30 - len(levelNumText)*5
-		text.Draw(screen, levelNumText, chineseFont, numX, levelY+40, color.RGBA{255, 255, 0, menuAlpha})
+		text.Draw(screen, levelNumText, chineseFont, numX, numY, color.RGBA{255, 255, 0, menuAlpha})
+
+		if hasRecord {
+			scoreText := fmt.Sprintf("%d", record.BestScore)
+			scoreX := levelX + 35 - font.MeasureString(smallFont, scoreText).Round()/2
+			text.Draw(screen, scoreText, smallFont, scoreX, levelY+50, color.RGBA{255, 255, 255, menuAlpha})
+
+			// 已通关的关卡用绿色显示最快通关用时
+			timeText, timeColor := "未通关", color.RGBA{180, 180, 180, menuAlpha}
+			if record.Cleared {
+				timeText, timeColor = formatTicks(record.BestTicks), color.RGBA{150, 255, 150, menuAlpha}
+			}
+			timeX := levelX + 35 - font.MeasureString(smallFont, timeText).Round()/2
+			text.Draw(screen, timeText, smallFont, timeX, levelY+66, timeColor)
+		}
 
 		// 对于锁定的关卡，绘制锁定图标
 		if levelInfo.locked {
@@ -324,45 +441,20 @@ func (lsm *LevelSelectMenu) Draw(screen *ebiten.Image) {
 		}
 	}
 
+	// 关卡超过一行时显示页码
+	if pages := (lsm.levels + levelsPerRow - 1) / levelsPerRow; pages > 1 {
+		pageText := fmt.Sprintf("%d/%d", page+1, pages)
+		text.Draw(screen, pageText, smallFont, screenWidth-50, 235, color.RGBA{200, 200, 200, menuAlpha})
+	}
+
 	// 绘制当前选中关卡的信息
 	if lsm.currentSelection >= 0 && lsm.currentSelection < lsm.levels {
 		levelInfo := lsm.levelInfos[lsm.currentSelection]
 
-		// 信息面板背景
-		infoPanelX := screenWidth/2 - 200
-		infoPanelY := screenHeight/2 + 70
-		ebitenutil.DrawRect(screen, float64(infoPanelX), float64(infoPanelY), 400, 60, color.RGBA{0, 0, 100, menuAlpha})
-
-		// 关卡名称
-		text.Draw(screen, levelInfo.name, chineseFont, infoPanelX+10, infoPanelY+25, color.RGBA{255, 255, 0, menuAlpha})
-
-		// 关卡描述
-		text.Draw(screen, levelInfo.description, chineseFont, infoPanelX+10, infoPanelY+50, color.RGBA{255, 255, 255, menuAlpha})
-
-		// BOSS信息面板
-		bossInfoX := screenWidth/2 - 150
-		bossInfoY := screenHeight/2 + 10
-		bossTitleText := fmt.Sprintf("BOSS: %s", levelInfo.bossName)
-		text.Draw(screen, bossTitleText, chineseFont, bossInfoX, bossInfoY, color.RGBA{255, 50, 50, menuAlpha})
-
-		// 难度星级
-		difficultyText := "难度: "
-		text.Draw(screen, difficultyText, chineseFont, infoPanelX+270, infoPanelY+25, color.RGBA{255, 255, 255, menuAlpha})
-
-		// 绘制星星
-		for i := 0; i < 5; i++ {
-			starX := infoPanelX + 330 + i*15
-			starY := infoPanelY + 22
-
-			if i < levelInfo.difficulty {
-				// 点亮的星星
-				starColor := color.RGBA{255, 255, 0, menuAlpha}
-				ebitenutil.DrawRect(screen, float64(starX), float64(starY), 10, 10, starColor)
-			} else {
-				// 未点亮的星星
-				starColor := color.RGBA{100, 100, 100, menuAlpha}
-				ebitenutil.DrawRect(screen, float64(starX), float64(starY), 10, 10, starColor)
-			}
+		if levelInfo.err != nil {
+			lsm.drawModError(screen, levelInfo.err, menuAlpha)
+		} else {
+			lsm.drawLevelInfo(screen, levelInfo, menuAlpha)
 		}
 	}
 
@@ -371,7 +463,7 @@ func (lsm *LevelSelectMenu) Draw(screen *ebiten.Image) {
 	startY := screenHeight/2 + 150
 
 	// 判断当前选择的关卡是否解锁
-	canStart := !lsm.levelInfos[lsm.currentSelection].locked
+	canStart := lsm.canStart()
 
 	// 根据是否可以开始设置按钮颜色
 	startBtnColor := color.RGBA{100, 100, 100, menuAlpha} // 默认灰色（锁定状态）
@@ -383,15 +475,115 @@ func (lsm *LevelSelectMenu) Draw(screen *ebiten.Image) {
 
 	ebitenutil.DrawRect(screen, float64(startX), float64(startY-30), 200, 40, startBtnColor)
 	startText := "开始游戏"
-	if !canStart {
+	if lsm.levels == 0 {
+		startText = "没有关卡"
+	} else if lsm.levelInfos[lsm.currentSelection].err != nil {
+		startText = "无法加载"
+	} else if !canStart {
 		startText = "关卡锁定"
 	}
 	startTextX := startX + 100 - len([]rune(startText))*12 // 居中文本
 	text.Draw(screen, startText, chineseFont, startTextX, startY, color.RGBA{255, 255, 255, menuAlpha})
 
 	// 操作提示
-	hintText := "← → 选择关卡   空格/回车 确认   ESC 返回"
+	hintText := "←→ 选择  ↑↓ 切换标签  回车 确认  ESC 返回"
 	hintX := screenWidth/2 - len([]rune(hintText))*10
 	hintY := screenHeight - 30
 	text.Draw(screen, hintText, chineseFont, hintX, hintY, color.RGBA{200, 200, 200, menuAlpha})
 }
+
+// drawLevelInfo 绘制选中关卡的名称、描述、BOSS和难度
+func (lsm *LevelSelectMenu) drawLevelInfo(screen *ebiten.Image, levelInfo levelInfo, menuAlpha uint8) {
+	// 信息面板背景
+	infoPanelX := screenWidth/2 - 200
+	infoPanelY := screenHeight/2 + 70
+	ebitenutil.DrawRect(screen, float64(infoPanelX), float64(infoPanelY), 400, 60, color.RGBA{0, 0, 100, menuAlpha})
+
+	// 关卡名称
+	text.Draw(screen, levelInfo.name, chineseFont, infoPanelX+10, infoPanelY+25, color.RGBA{255, 255, 0, menuAlpha})
+
+	// 关卡描述
+	text.Draw(screen, levelInfo.description, chineseFont, infoPanelX+10, infoPanelY+50, color.RGBA{255, 255, 255, menuAlpha})
+
+	// BOSS信息面板
+	bossInfoX := screenWidth/2 - 150
+	bossInfoY := screenHeight/2 + 10
+	bossTitleText := fmt.Sprintf("BOSS: %s", levelInfo.bossName)
+	text.Draw(screen, bossTitleText, chineseFont, bossInfoX, bossInfoY, color.RGBA{255, 50, 50, menuAlpha})
+
+	// 自定义关卡在BOSS信息右侧显示所属的关卡包
+	if lsm.tab == tabCustom {
+		packText := fmt.Sprintf("关卡包: %s", levelInfo.pack.Name)
+		text.Draw(screen, packText, smallFont, screenWidth/2+80, bossInfoY, color.RGBA{200, 200, 255, menuAlpha})
+	}
+
+	// 通关记录：最高分和最快通关用时
+	recordText := "尚未通关"
+	if levelInfo.record.Cleared {
+		recordText = fmt.Sprintf("最高分: %d  最快通关: %s", levelInfo.record.BestScore, formatTicks(levelInfo.record.BestTicks))
+	} else if levelInfo.record.BestScore > 0 {
+		recordText = fmt.Sprintf("最高分: %d  尚未通关", levelInfo.record.BestScore)
+	}
+	text.Draw(screen, recordText, smallFont, bossInfoX, bossInfoY+30, color.RGBA{200, 255, 200, menuAlpha})
+
+	// 难度星级
+	difficultyText := "难度: "
+	text.Draw(screen, difficultyText, chineseFont, infoPanelX+270, infoPanelY+25, color.RGBA{255, 255, 255, menuAlpha})
+
+	// 绘制星星
+	for i := 0; i < 5; i++ {
+		starX := infoPanelX + 330 + i*15
+		starY := infoPanelY + 22
+
+		if i < levelInfo.difficulty {
+			// 点亮的星星
+			starColor := color.RGBA{255, 255, 0, menuAlpha}
+			ebitenutil.DrawRect(screen, float64(starX), float64(starY), 10, 10, starColor)
+		} else {
+			// 未点亮的星星
+			starColor := color.RGBA{100, 100, 100, menuAlpha}
+			ebitenutil.DrawRect(screen, float64(starX), float64(starY), 10, 10, starColor)
+		}
+	}
+}
+
+// drawModError 绘制加载失败的模组及出错原因，放不下的行会被省略
+func (lsm *LevelSelectMenu) drawModError(screen *ebiten.Image, modErr *ModError, menuAlpha uint8) {
+	const (
+		panelWidth  = 560
+		lineHeight  = 18
+		maxLines    = 5
+		panelHeight = lineHeight*(maxLines+1) + 12
+	)
+	panelX := screenWidth/2 - panelWidth/2
+	panelY := screenHeight/2 - 10
+	ebitenutil.DrawRect(screen, float64(panelX), float64(panelY), panelWidth, panelHeight, color.RGBA{80, 0, 0, menuAlpha})
+
+	title := fmt.Sprintf("模组 %s 加载失败，已跳过：", modErr.mod)
+	text.Draw(screen, title, smallFont, panelX+10, panelY+lineHeight, color.RGBA{255, 255, 0, menuAlpha})
+
+	lines := wrapText(smallFont, modErr.err.Error(), panelWidth-20)
+	if len(lines) > maxLines {
+		lines = append(lines[:maxLines-1], fmt.Sprintf("……还有%d行", len(lines)-maxLines+1))
+	}
+	for i, line := range lines {
+		text.Draw(screen, line, smallFont, panelX+10, panelY+lineHeight*(i+2), color.RGBA{255, 255, 255, menuAlpha})
+	}
+}
+
+// wrapText 按换行符和显示宽度将文字拆分为多行
+func wrapText(face font.Face, s string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(s, "\n") {
+		line := ""
+		for _, r := range paragraph {
+			if line != "" && font.MeasureString(face, line+string(r)).Round() > width {
+				lines = append(lines, line)
+				line = ""
+			}
+			line += string(r)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"testing"
+
+	"go-play-plane/internal/sim"
+)
+
+func TestLevelSelectRefreshesAfterResult(t *testing.T) {
+	old := progress
+	progress = newProgress()
+	defer func() { progress = old }()
+
+	lsm := NewLevelSelectMenu()
+	g := &Game{scenes: NewSceneStack(), levelSelectMenu: lsm}
+	g.scenes.Push(g, lsm)
+	if info := lsm.tabInfos[tabBuiltin][1]; !info.locked {
+		t.Fatal("没有进度时第2关应当锁定")
+	}
+
+	// 开发模式重新加载资源时会丢弃缓存的菜单，栈中对局下方的菜单仍应在返回时刷新
+	ps := &PlayScene{sim: sim.NewSimulation(levels, sim.FieldStandard, sim.ModePlaying, 1, 1)}
+	g.scenes.Push(g, ps)
+	g.levelSelectMenu = nil
+	ps.sim.Score, ps.sim.Tick = 1234, 5*sim.TicksPerSecond
+	ps.recordLevel(g, 1, true)
+
+	// 对局弹出后，菜单不再调用Enter，也应当显示新成绩并解锁第2关
+	g.scenes.Pop(g)
+	first := lsm.tabInfos[tabBuiltin][0]
+	if !first.record.Cleared || first.record.BestScore != 1234 || first.record.BestTicks != 5*sim.TicksPerSecond {
+		t.Errorf("记录成绩后第1关的记录为%+v", first.record)
+	}
+	if lsm.tabInfos[tabBuiltin][1].locked {
+		t.Error("通关第1关后第2关仍然锁定")
+	}
+}
+
+func TestLevelSelectStickNavigation(t *testing.T) {
+	old := progress
+	progress = newProgress()
+	defer func() { progress = old }()
+
+	lsm := NewLevelSelectMenu()
+	g := &Game{scenes: NewSceneStack(), levelSelectMenu: lsm}
+	g.scenes.Push(g, lsm)
+	lsm.ready = true
+	hold := func(in sim.InputState, frames int) {
+		for i := 0; i < frames; i++ {
+			if err := lsm.Update(g, in); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	// 斜向右推摇杆浏览关卡时，竖直方向的偏移不应切换标签页
+	hold(sim.InputState{MoveX: 0.9, MoveY: 0.4}, 30)
+	if lsm.tab != tabBuiltin {
+		t.Error("斜推摇杆切换了标签页")
+	}
+	if lsm.currentSelection == 0 {
+		t.Error("向右推摇杆没有移动选择")
+	}
+
+	// 按住上方向只切换一次标签页
+	hold(sim.InputState{}, 1)
+	hold(sim.InputState{MoveY: -1}, 60)
+	if lsm.tab != tabCustom {
+		t.Error("按住上方向后标签页应切换一次")
+	}
+}
@@ -1,51 +1,52 @@
 package main
 
 import (
-	"bytes"
+	"flag"
 	"fmt"
-	"image"
-	"image/color" // 注册PNG格式支持
+	"image/color"
 	"log"
-	"math"
-	"math/rand"
 	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
 	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
 	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
 	"github.com/hajimehoshi/ebiten/v2/text"
+	"go-play-plane/internal/sim"
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/opentype"
 )
 
-const (
-	screenWidth  = 640
-	screenHeight = 480
-	gameTitle    = "打飞机游戏"
-)
+const gameTitle = "打飞机游戏"
 
-// GameMode 游戏模式
-type GameMode int
+// screenWidth、screenHeight 当前的内部分辨率，即场景绘制所用画面的大小，由applyDisplay设置。
+// 菜单和HUD按它布局；对局中实体的坐标使用各自的场地大小，与它无关
+var screenWidth, screenHeight = 640, 480
 
-const (
-	ModeMenu        GameMode = iota // 菜单模式
-	ModeLevelSelect                 // 关卡选择模式
-	ModePlaying                     // 游戏进行中模式
-	ModeEndless                     // 无尽模式
+// 命令行参数，画面相关的参数只在显式给出时覆盖设置文件中的值
+var (
+	seedFlag   = flag.Int64("seed", 0, "固定对局随机种子（0表示随机）")
+	recordFlag = flag.String("record", "", "将每局录像保存到指定目录")
+	replayFlag = flag.String("replay", "", "回放指定的录像文件")
+	levelsFlag = flag.String("levels", "", "使用指定的关卡文件代替内置关卡")
+	modsFlag   = flag.String("mods", "mods", "从指定目录加载自定义关卡包")
+	devFlag    = flag.Bool("dev", false, "开发模式：从磁盘上的resources目录加载资源，文件改动后自动重新加载")
+	resFlag    = flag.String("resolution", "640x480", "内部分辨率：640x480、800x600、854x480、960x540或1280x720")
+	fieldFlag  = flag.String("playfield", "standard", "场地类型：standard（4:3横版）或vertical（3:4纵版，HUD显示在侧边栏）")
+	pixelFlag  = flag.Bool("pixelperfect", false, "只按整数倍缩放画面，保持像素清晰")
+	scaleFlag  = flag.Int("scale", 1, "启动时窗口相对内部分辨率的倍数")
+	touchFlag  = flag.Bool("touchsim", false, "用鼠标模拟触摸屏，按住左键相当于一根手指，便于在桌面上测试触摸操作")
+	muteFlag   = flag.Bool("mute", false, "关闭所有声音，不使用音频设备")
 )
 
 var (
 	gameFont    font.Face
 	chineseFont font.Face
-	enemyImage  *ebiten.Image
-	playerImage *ebiten.Image
-	game        *Game // 全局游戏实例，用于其他模块引用
+	smallFont   font.Face // 小号中文字体，用于显示较长的说明文字
+	// loadedSprites 贴图键到精灵的映射，包含内置图集中的精灵和模组自带的贴图
+	loadedSprites map[string]*Sprite
 )
 
 func init() {
-	// 初始化随机数生成器
-	rand.Seed(time.Now().UnixNano())
-
 	// 加载英文字体
 	tt, err := opentype.Parse(fonts.PressStart2P_ttf)
 	if err != nil {
@@ -67,678 +68,295 @@ func init() {
 		log.Fatal(err)
 	}
 
-	chineseTT, err := opentype.Parse(chineseFontData)
+	if err := loadChineseFonts(chineseFontData); err != nil {
+		log.Fatal(err)
+	}
+
+	// 加载精灵图集
+	loadedSprites, err = LoadAtlas(spritesFS, "resources/sprites/atlas.json")
 	if err != nil {
 		log.Fatal(err)
 	}
+	// 爆炸效果按爆炸时的图集计算持续时间，开发模式下重新加载的动画对之后的爆炸生效
+	sim.ExplodeDuration = func(sprite string) int {
+		return explodeAnimation(sprite).duration()
+	}
+}
 
-	chineseFont, err = opentype.NewFace(chineseTT, &opentype.FaceOptions{
+// loadChineseFonts 从TrueType/OpenType字体数据创建常规和小号中文字体
+func loadChineseFonts(data []byte) error {
+	tt, err := opentype.Parse(data)
+	if err != nil {
+		return err
+	}
+	regular, err := opentype.NewFace(tt, &opentype.FaceOptions{
 		Size:    24,
 		DPI:     72,
 		Hinting: font.HintingFull,
 	})
 	if err != nil {
-		log.Fatal(err)
+		return err
 	}
-
-	// 加载图片资源
-	enemyImage = ebiten.NewImage(32, 32)
-	enemyImage.Fill(color.RGBA{255, 0, 0, 255}) // 临时使用红色方块代替敌机
-
-	playerImage = ebiten.NewImage(32, 32)
-	playerImage.Fill(color.RGBA{0, 255, 0, 255}) // 临时使用绿色方块代替玩家
-
-	// 加载敌机图片
-	enemyImgData, err := imagesFS.ReadFile("resources/images/enemy.png")
-	if err != nil {
-		log.Printf("无法加载敌机图片，使用默认方块: %v", err)
-	} else {
-		// 从嵌入的资源创建图片
-		enemyImg, _, err := image.Decode(bytes.NewReader(enemyImgData))
-		if err != nil {
-			log.Printf("无法解码敌机图片，使用默认方块: %v", err)
-		} else {
-			// 创建指定大小的图片缓冲区
-			enemyImage = ebiten.NewImage(32, 32)
-			// 设置缩放选项
-			options := &ebiten.DrawImageOptions{}
-			// 计算缩放比例
-			scaleX := float64(32) / float64(enemyImg.Bounds().Dx())
-			scaleY := float64(32) / float64(enemyImg.Bounds().Dy())
-			options.GeoM.Scale(scaleX, scaleY)
-			// 将原始图片绘制到指定大小的缓冲区
-			enemyImage.DrawImage(ebiten.NewImageFromImage(enemyImg), options)
-		}
-	}
-
-	// 加载玩家图片
-	playerImgData, err := imagesFS.ReadFile("resources/images/player.png")
+	small, err := opentype.NewFace(tt, &opentype.FaceOptions{
+		Size:    14,
+		DPI:     72,
+		Hinting: font.HintingFull,
+	})
 	if err != nil {
-		log.Printf("无法加载玩家图片，使用默认方块: %v", err)
-	} else {
-		// 从嵌入的资源创建图片
-		playerImg, _, err := image.Decode(bytes.NewReader(playerImgData))
-		if err != nil {
-			log.Printf("无法解码玩家图片，使用默认方块: %v", err)
-		} else {
-			// 创建指定大小的图片缓冲区
-			playerImage = ebiten.NewImage(32, 32)
-			// 设置缩放选项
-			options := &ebiten.DrawImageOptions{}
-			// 计算缩放比例
-			scaleX := float64(32) / float64(playerImg.Bounds().Dx())
-			scaleY := float64(32) / float64(playerImg.Bounds().Dy())
-			options.GeoM.Scale(scaleX, scaleY)
-			// 将原始图片绘制到指定大小的缓冲区
-			playerImage.DrawImage(ebiten.NewImageFromImage(playerImg), options)
-		}
+		return err
 	}
+	chineseFont, smallFont = regular, small
+	return nil
 }
 
-// Game 结构体用于保存游戏状态
+// Game 结构体是Ebiten与场景栈之间的适配层，负责输入采集并把更新和渲染交给场景
 type Game struct {
-	player             *Player
-	enemyManager       *EnemyManager
-	bulletManager      *BulletManager
-	enemyBulletManager *EnemyBulletManager
-	powerUpManager     *PowerUpManager
-	score              int
-	isGameOver         bool
-	gameMode           GameMode // 当前游戏模式
-	currentLevel       int      // 当前关卡（仅用于关卡模式）
-	difficulty         float64  // 游戏难度系数
-	targetScore        int      // 当前关卡目标分数
-	// BOSS相关字段
-	boss               *Boss // 当前关卡BOSS
-	bossActive         bool  // BOSS是否已出现
-	bossDefeated       bool  // BOSS是否已被击败
-	bossScoreThreshold int   // 触发BOSS的分数阈值
+	scenes *SceneStack // 场景栈
+	// 输入相关字段
+	input       sim.InputSource // 输入源
+	lastInput   sim.InputState  // 最近一帧的操作快照，供渲染高亮使用
+	waitRelease bool            // 场景切换后等待按键松开，避免一次按键触发多个场景
+	keyboard    *KeyboardInput  // 键盘输入源，也用于键位设置界面捕获按键
+	gamepads    *GamepadInput   // 手柄输入源，也用于检测手柄插拔和捕获按钮
+	// 音效和背景音乐
+	audio *AudioManager
+	// 手柄插拔提示
+	padNotice      string
+	padNoticeTimer int
 	// 菜单相关字段
 	levelSelectMenu *LevelSelectMenu // 关卡选择菜单
-	// 启动动画相关字段
-	animTimer      int          // 动画计时器
-	titleScale     float64      // 标题缩放
-	titleRotation  float64      // 标题旋转角度
-	titleAlpha     float64      // 标题透明度
-	titleY         float64      // 标题Y坐标
-	menuItemsAlpha float64      // 菜单项透明度
-	starPositions  [][2]float64 // 背景星星位置
+	// 开发模式下的资源监视器，非开发模式为nil
+	assets *AssetWatcher
+	// 设置文件的路径，为空时设置无法保存
+	settingsPath string
+	// 进度文件的路径，为空时进度无法保存
+	progressPath string
+	// 画面缩放相关字段
+	canvas *ebiten.Image // 按内部分辨率绘制的画面，每帧缩放到窗口中
+	view   viewTransform // 内部画面到窗口的变换
+}
+
+// newSeed 返回新对局使用的随机种子
+func newSeed() int64 {
+	if *seedFlag != 0 {
+		return *seedFlag
+	}
+	return time.Now().UnixNano()
 }
 
 // Update 处理游戏逻辑更新
 func (g *Game) Update() error {
-	// 在菜单模式下处理模式选择和动画效果
-	if g.gameMode == ModeMenu {
-		// 更新动画计时器
-		g.animTimer++
-
-		// 标题动画效果
-		if g.titleScale < 1.0 {
-			g.titleScale += 0.05
-			if g.titleScale > 1.0 {
-				g.titleScale = 1.0
-			}
-		}
-
-		// 标题旋转效果
-		if g.animTimer < 30 {
-			g.titleRotation = math.Sin(float64(g.animTimer)/10) * 0.1
-		} else {
-			g.titleRotation = math.Sin(float64(g.animTimer)/100) * 0.03
-		}
-
-		// 标题透明度渐变
-		if g.titleAlpha < 1.0 {
-			g.titleAlpha += 0.03
-			if g.titleAlpha > 1.0 {
-				g.titleAlpha = 1.0
-			}
-		}
-
-		// 菜单项目透明度渐变，在标题出现后
-		if g.titleAlpha >= 0.8 && g.menuItemsAlpha < 1.0 {
-			g.menuItemsAlpha += 0.03
-			if g.menuItemsAlpha > 1.0 {
-				g.menuItemsAlpha = 1.0
-			}
-		}
-
-		// 按1选择关卡模式或鼠标点击
-		if (ebiten.IsKeyPressed(ebiten.Key1) && g.menuItemsAlpha >= 0.9) || (ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && g.checkMouseInArea(screenWidth/2-170, screenHeight/2+20, 340, 40)) {
-			// 进入关卡选择模式
-			g.gameMode = ModeLevelSelect
-			// 初始化关卡选择菜单
-			if g.levelSelectMenu == nil {
-				g.levelSelectMenu = NewLevelSelectMenu()
-			}
-			return nil
-		}
-		// 按2选择无尽模式或鼠标点击
-		if (ebiten.IsKeyPressed(ebiten.Key2) && g.menuItemsAlpha >= 0.9) || (ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) && g.checkMouseInArea(screenWidth/2-170, screenHeight/2+70, 340, 40)) {
-			g.gameMode = ModeEndless
-			g.difficulty = 1.0
-			return nil
-		}
-		return nil
+	// 关卡数据重新加载后，关卡选择菜单需要按新关卡重建
+	if g.assets != nil && g.assets.Update() {
+		g.levelSelectMenu = nil
 	}
 
-	// 在关卡选择模式下处理选择逻辑
-	if g.gameMode == ModeLevelSelect {
-		// 更新关卡选择菜单
-		if g.levelSelectMenu.Update(g) {
-			// 如果返回true，表示已完成选择或返回主菜单
-			return nil
-		}
-		return nil
+	g.audio.Update()
+	in := g.input.Poll()
+	g.handleGamepadEvents()
+	// 指针坐标从窗口换算到内部画面
+	if in.HasCursor {
+		in.CursorX, in.CursorY = g.view.toLocal(in.CursorX, in.CursorY)
 	}
+	g.lastInput = in
 
-	// 如果游戏已结束，处理重新开始或返回菜单的输入
-	if g.isGameOver {
-		// 按R键重新开始当前模式
-		if ebiten.IsKeyPressed(ebiten.KeyR) {
-			// 重置游戏状态
-			g.player = NewPlayer()
-			g.enemyManager = NewEnemyManager()
-			g.bulletManager = NewBulletManager()
-			g.enemyBulletManager = NewEnemyBulletManager()
-			g.powerUpManager = NewPowerUpManager()
-			g.score = 0
-			g.isGameOver = false
-			g.bossActive = false
-			g.bossDefeated = false
-			g.boss = nil
-			if g.gameMode == ModePlaying {
-				// 重置当前关卡
-				g.targetScore = g.currentLevel * 1000
-				g.bossScoreThreshold = g.targetScore / 2
-				g.enemyManager.SetLevel(g.currentLevel)
-			}
-		}
-		// 按ESC键返回菜单
-		if ebiten.IsKeyPressed(ebiten.KeyEscape) {
-			if g.gameMode == ModePlaying {
-				// 从关卡模式返回关卡选择
-				g.gameMode = ModeLevelSelect
-			} else {
-				// 从无尽模式返回主菜单
-				g.gameMode = ModeMenu
-			}
-			g.isGameOver = false
-			g.score = 0
+	// 场景切换后，菜单类按键需要先松开才能再次生效
+	if g.waitRelease {
+		if in.Confirm || in.Back || in.Restart || in.Click || in.MenuChoice != 0 {
+			in.Confirm, in.Back, in.Restart, in.Click, in.MenuChoice = false, false, false, false, 0
+		} else {
+			g.waitRelease = false
 		}
-		return nil
 	}
 
-	// 游戏进行中，更新所有游戏元素
-	// 更新玩家状态
-	g.player.Update()
-
-	// 只有在BOSS没有出现时才生成普通敌机
-	if !g.bossActive {
-		// 更新敌机状态
-		g.enemyManager.Update()
-	} else {
-		// 如果BOSS已经出现，更新BOSS状态
-		if g.boss != nil && g.boss.active {
-			g.boss.Update(g.player, g.enemyBulletManager)
-		}
+	g.scenes.changed = false
+	err := g.scenes.Update(g, in)
+	if g.scenes.changed {
+		g.waitRelease = true
 	}
+	g.audio.PlayMusic(g.scenes.Music())
+	return err
+}
 
-	// 更新子弹状态
-	g.bulletManager.Update(g.player)
+// padNoticeTicks 手柄插拔提示显示的帧数
+const padNoticeTicks = 2 * sim.TicksPerSecond
 
-	// 更新敌方子弹状态
-	if g.bossActive {
-		// Boss激活时也需要更新子弹状态
-		g.enemyBulletManager.Update(nil)
-	} else {
-		g.enemyBulletManager.Update(g.enemyManager.enemies)
+// handleGamepadEvents 提示手柄的插拔，对局中手柄被拔出时自动暂停
+func (g *Game) handleGamepadEvents() {
+	if g.padNoticeTimer > 0 {
+		g.padNoticeTimer--
 	}
-
-	// 更新道具状态
-	g.powerUpManager.Update()
-
-	// 检测子弹与敌机的碰撞
-	for _, bullet := range g.bulletManager.bullets {
-		// 检测与普通敌机的碰撞
-		if !g.bossActive {
-			for _, enemy := range g.enemyManager.enemies {
-				if bullet.CheckCollision(enemy) {
-					bullet.active = false
-					enemy.health -= 1      // 减少敌机血量
-					if enemy.health <= 0 { // 只有当血量为0时才销毁敌机
-						enemy.active = false
-						g.score += 100
-						// 在敌机被击毁的位置生成道具
-						g.powerUpManager.SpawnPowerUp(enemy.x, enemy.y)
-
-						// 关卡模式下，检查是否达到触发BOSS的分数
-						if g.gameMode == ModePlaying && g.score >= g.bossScoreThreshold && !g.bossActive && !g.bossDefeated {
-							// 触发BOSS战
-							g.bossActive = true
-							// 根据当前关卡创建对应的BOSS
-							bossType := BossType(g.currentLevel - 1)
-							if int(bossType) >= 4 {
-								bossType = BossType4 // 最多支持4种BOSS类型
-							}
-							g.boss = NewBoss(bossType)
-						}
-					}
-				}
-			}
-		}
-
-		// 检测与BOSS的碰撞
-		if g.bossActive && g.boss != nil && g.boss.active {
-			// 检查子弹是否与BOSS发生碰撞
-			if bullet.x < g.boss.x+float64(g.boss.width) &&
-				bullet.x+float64(bullet.width) > g.boss.x &&
-				bullet.y < g.boss.y+float64(g.boss.height) &&
-				bullet.y+float64(bullet.height) > g.boss.y {
-
-				bullet.active = false
-				g.boss.health -= g.player.attackPower // 减少BOSS血量，考虑玩家攻击力
-
-				// 检查BOSS是否被击败
-				if g.boss.health <= 0 {
-					g.boss.active = false
-					g.bossDefeated = true
-					g.score += 2000 // BOSS奖励分数
-
-					// 在BOSS位置生成多个道具
-					for i := 0; i < 5; i++ {
-						offsetX := float64(rand.Intn(g.boss.width))
-						offsetY := float64(rand.Intn(g.boss.height))
-						g.powerUpManager.SpawnPowerUp(g.boss.x+offsetX, g.boss.y+offsetY)
-					}
-
-					// 在关卡模式下，检查是否需要进入下一关
-					if g.gameMode == ModePlaying {
-						// 检查是否是最后一关
-						if g.currentLevel < 4 { // 假设总共有4关
-							// 进入下一关
-							g.currentLevel++
-							g.targetScore = g.currentLevel * 1000
-							g.bossScoreThreshold = g.targetScore / 2
-							g.enemyManager.SetLevel(g.currentLevel)
-							g.bossActive = false
-							g.bossDefeated = false
-						} else {
-							// 通关所有关卡，返回关卡选择
-							// 可以显示一个胜利画面，这里简化处理
-							g.gameMode = ModeLevelSelect
-							g.currentLevel = 1
-							g.score = 0
-						}
-					}
-				}
+	for _, e := range g.gamepads.Events() {
+		if e.connected {
+			g.padNotice = "手柄已连接: " + e.name
+		} else {
+			g.padNotice = "手柄已断开: " + e.name
+			if _, playing := g.scenes.Top().(*PlayScene); playing {
+				g.scenes.Push(g, NewPauseScene())
 			}
 		}
+		g.padNoticeTimer = padNoticeTicks
 	}
+}
 
-	// 检测玩家与道具的碰撞
-	for _, powerUp := range g.powerUpManager.powerUps {
-		if powerUp.active && g.checkPlayerPowerUpCollision(powerUp) {
-			powerUp.active = false
-			// 根据道具类型给予玩家相应的能力
-			switch powerUp.pType {
-			case MultiShot:
-				g.player.EnableMultiShot()
-			case ScreenShot:
-				g.player.EnableScreenShot()
-			}
-		}
+// checkCursorInArea 检测指针是否在指定区域内
+func checkCursorInArea(in sim.InputState, x, y, width, height float64) bool {
+	if !in.HasCursor {
+		return false
 	}
+	mx, my := float64(in.CursorX), float64(in.CursorY)
+	return mx >= x && mx <= x+width && my >= y && my <= y+height
+}
 
-	// 检测玩家与敌机的碰撞
-	if !g.bossActive {
-		for _, enemy := range g.enemyManager.enemies {
-			if enemy.active && g.checkPlayerCollision(enemy) {
-				g.isGameOver = true
-				break
-			}
-		}
-	} else if g.boss != nil && g.boss.active {
-		// 检测玩家与BOSS的碰撞
-		if g.player.x < g.boss.x+float64(g.boss.width) &&
-			g.player.x+float64(g.player.width) > g.boss.x &&
-			g.player.y < g.boss.y+float64(g.boss.height) &&
-			g.player.y+float64(g.player.height) > g.boss.y {
-			g.isGameOver = true
-		}
+// Draw 先按内部分辨率绘制场景，再把画面等比缩放到窗口中央，多余部分留黑
+func (g *Game) Draw(screen *ebiten.Image) {
+	if g.canvas == nil || g.canvas.Bounds().Dx() != screenWidth || g.canvas.Bounds().Dy() != screenHeight {
+		g.canvas = ebiten.NewImage(screenWidth, screenHeight)
 	}
-
-	// 敌机子弹状态已在前面更新，这里不需要重复
-
-	// 检测敌机子弹与玩家的碰撞
-	for _, bullet := range g.enemyBulletManager.bullets {
-		if bullet.CheckCollision(g.player) {
-			g.isGameOver = true
-			break
-		}
+	g.canvas.Clear()
+	g.scenes.Draw(g, g.canvas)
+	if g.padNoticeTimer > 0 {
+		ebitenutil.DrawRect(g.canvas, 10, float64(screenHeight-34), 300, 24, color.RGBA{0, 0, 0, 160})
+		text.Draw(g.canvas, g.padNotice, smallFont, 20, screenHeight-17, color.RGBA{255, 255, 255, 255})
 	}
 
-	return nil
+	size := screen.Bounds().Size()
+	g.view = fitView(screenWidth, screenHeight, size.X, size.Y, display.pixelPerfect)
+	drawView(screen, g.canvas, g.view)
 }
 
-// checkPlayerCollision 检测玩家与敌机的碰撞
-func (g *Game) checkPlayerCollision(enemy *Enemy) bool {
-	return g.player.x < enemy.x+float64(enemy.width) &&
-		g.player.x+float64(g.player.width) > enemy.x &&
-		g.player.y < enemy.y+float64(enemy.height) &&
-		g.player.y+float64(g.player.height) > enemy.y
+// Layout 使用窗口的实际像素大小作为屏幕大小，由Draw自行缩放内部画面，以便支持整数倍缩放
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	scale := ebiten.Monitor().DeviceScaleFactor()
+	return int(float64(outsideWidth) * scale), int(float64(outsideHeight) * scale)
 }
 
-// checkPlayerPowerUpCollision 检测玩家与道具的碰撞
-func (g *Game) checkPlayerPowerUpCollision(powerUp *PowerUp) bool {
-	return g.player.x < powerUp.x+float64(powerUp.width) &&
-		g.player.x+float64(g.player.width) > powerUp.x &&
-		g.player.y < powerUp.y+float64(powerUp.height) &&
-		g.player.y+float64(g.player.height) > powerUp.y
-}
+func main() {
+	flag.Parse()
 
-// checkMouseInArea 检测鼠标是否在指定区域内
-func (g *Game) checkMouseInArea(x, y, width, height float64) bool {
-	mx, my := ebiten.CursorPosition()
-	return float64(mx) >= x && float64(mx) <= x+width && float64(my) >= y && float64(my) <= y+height
-}
+	// 开发模式下改用磁盘上的资源，并在运行中监视改动；需要在加载模组之前完成，
+	// 模组关卡才能使用磁盘上的BOSS定义
+	var assets *AssetWatcher
+	if *devFlag {
+		assets = NewAssetWatcher(resourcesDir, *levelsFlag)
+	}
 
-// Draw 处理游戏画面渲染
-func (g *Game) Draw(screen *ebiten.Image) {
-	if g.gameMode == ModeMenu {
-		// 绘制渐变背景
-		gradientTop := color.RGBA{10, 10, 50, 255}
-		gradientBottom := color.RGBA{30, 30, 80, 255}
-
-		// 绘制背景渐变色
-		for y := 0; y < screenHeight; y++ {
-			// 计算该行的渐变颜色
-			ratio := float64(y) / float64(screenHeight)
-			r := uint8(float64(gradientTop.R) + ratio*float64(gradientBottom.R-gradientTop.R))
-			g := uint8(float64(gradientTop.G) + ratio*float64(gradientBottom.G-gradientTop.G))
-			b := uint8(float64(gradientTop.B) + ratio*float64(gradientBottom.B-gradientTop.B))
-			ebitenutil.DrawRect(screen, 0, float64(y), float64(screenWidth), 1, color.RGBA{r, g, b, 255})
+	// 加载自定义关卡文件，校验失败时列出所有问题并退出
+	if *levelsFlag != "" {
+		ls, err := sim.LoadLevels(*levelsFlag, bosses)
+		if err != nil {
+			log.Fatalf("加载关卡文件失败: %v", err)
 		}
+		levels = ls
+	}
 
-		// 绘制动态星星背景
-		for i, star := range g.starPositions {
-			// 星星大小和亮度随时间变化
-			starSize := 1.0 + math.Sin(float64(g.animTimer)/20.0+float64(i))*0.5
-			starBrightness := 150.0 + math.Sin(float64(g.animTimer)/15.0+float64(i))*50.0
-
-			// 让星星闪烁
-			starColor := color.RGBA{255, 255, 255, uint8(starBrightness)}
-			ebitenutil.DrawRect(screen, star[0], star[1], starSize, starSize, starColor)
-		}
+	// 加载模组目录中的关卡包，出错的模组会被跳过并在关卡选择菜单中列出
+	levelPacks, modErrors = LoadMods(*modsFlag)
 
-		// 绘制标题
-		titleMsg := "飞机大战"
-		// 计算文本宽度以便居中显示
-		titleWidth := len([]rune(titleMsg)) * 36 // 估计每个汉字宽度约36像素
-		//titleX := screenWidth/2 - titleWidth/2
-		titleOptions := &ebiten.DrawImageOptions{}
-		titleWidth = len([]rune(titleMsg)) * 36 // 估计每个汉字宽度
-
-		titleImgWidth := titleWidth + 60 // 两侧各留30像素空间
-
-		// 设置标题的变换
-		titleOptions.GeoM.Translate(-float64(titleImgWidth/2), -30)   // 调整旋转中心点到图像中心
-		titleOptions.GeoM.Rotate(g.titleRotation)                     // 应用旋转
-		titleOptions.GeoM.Scale(g.titleScale, g.titleScale)           // 应用缩放
-		titleOptions.GeoM.Translate(float64(screenWidth/2), g.titleY) // 移动到屏幕中心
-		titleOptions.ColorM.Scale(1, 1, 1, g.titleAlpha)              // 应用透明度
-		titleOptions.ColorM.Scale(1, 1, 1, g.titleAlpha)              // 应用透明度
-
-		// 创建一个临时图像来渲染标题
-		titleImg := ebiten.NewImage(titleImgWidth, 60)
-
-		// 绘制闪亮的标题背景
-		backgroundWidth := float64(titleImgWidth - 20) // 两侧各留10像素
-		ebitenutil.DrawRect(titleImg, 10, 10, backgroundWidth, 50, color.RGBA{0, 0, 150, 200})
-
-		// 添加标题光晕效果
-		glowSize := 5.0 + math.Sin(float64(g.animTimer)/10.0)*2.0
-		ebitenutil.DrawRect(titleImg, 10-glowSize, 10-glowSize, backgroundWidth+glowSize*2, 50+glowSize*2, color.RGBA{100, 100, 255, 50})
-
-		// 直接使用已有的中文字体渲染标题
-		// 计算文本在图像中的X位置，使其居中
-		textX := titleImgWidth/2 - titleWidth/2
-		// 绘制阴影效果
-		text.Draw(titleImg, titleMsg, chineseFont, textX+2, 47, color.RGBA{0, 0, 80, 255})
-		// 主文字
-		text.Draw(titleImg, titleMsg, chineseFont, textX, 45, color.RGBA{255, 255, 255, 255})
-		// 添加发光效果
-		text.Draw(titleImg, titleMsg, chineseFont, textX-1, 44, color.RGBA{100, 200, 255, 150})
-
-		// 将标题绘制到屏幕
-		screen.DrawImage(titleImg, titleOptions)
-
-		// 使用透明度来控制菜单项的显示
-		menuAlpha := uint8(g.menuItemsAlpha * 255)
-
-		// 绘制模式选择说明
-		modeTitle := "- 选择游戏模式 -"
-		modeTitleX := screenWidth/2 - 100
-		modeTitleY := int(g.titleY) + 120
-
-		// 模式选择背景带有呼吸效果
-		pulseEffect := 0.7 + math.Sin(float64(g.animTimer)/20.0)*0.3
-		bgWidth := 240.0 * pulseEffect
-		ebitenutil.DrawRect(screen, float64(modeTitleX-20)-(bgWidth-200)/2, float64(modeTitleY-25), bgWidth, 35,
-			color.RGBA{0, 50, 150, menuAlpha})
-		text.Draw(screen, modeTitle, chineseFont, modeTitleX, modeTitleY,
-			color.RGBA{220, 220, 255, menuAlpha})
-
-		// 绘制模式选项
-		mode1 := "[1] 关卡模式"
-		mode1Desc := "逐级挑战，难度递增"
-		mode2 := "[2] 无尽模式"
-		mode2Desc := "无限挑战，直到失败"
-
-		mode1X := screenWidth/2 - 150
-		mode1Y := modeTitleY + 60
-		mode2X := screenWidth/2 - 150
-		mode2Y := modeTitleY + 110
-
-		// 高亮效果（鼠标或键盘悬停时）
-		mx, my := ebiten.CursorPosition()
-		mode1Highlight := (mx >= mode1X-10 && mx <= mode1X+310 && my >= mode1Y-25 && my <= mode1Y+15)
-		mode2Highlight := (mx >= mode2X-10 && mx <= mode2X+310 && my >= mode2Y-25 && my <= mode2Y+15)
-
-		// 模式选项背景
-		mode1BgColor := color.RGBA{0, 0, 100, menuAlpha}
-		mode2BgColor := color.RGBA{0, 0, 100, menuAlpha}
-
-		// 为选项添加悬停高亮效果
-		if mode1Highlight {
-			mode1BgColor = color.RGBA{50, 50, 150, menuAlpha}
-		}
-		if mode2Highlight {
-			mode2BgColor = color.RGBA{50, 50, 150, menuAlpha}
+	// 读取设置文件，命令行中显式给出的画面参数优先于设置文件。
+	// 这些参数只修改本次运行的display，不写入settings，选项界面保存时也不会把它们存进设置文件
+	path, err := settingsPath()
+	if err != nil {
+		log.Printf("找不到用户配置目录，设置将无法保存: %v", err)
+	}
+	s := defaultSettings()
+	if path != "" {
+		var ok bool
+		s, ok = LoadSettings(path)
+		if !ok {
+			path = ""
 		}
-
-		ebitenutil.DrawRect(screen, float64(mode1X-20), float64(mode1Y-25), 340, 40, mode1BgColor)
-		ebitenutil.DrawRect(screen, float64(mode2X-20), float64(mode2Y-25), 340, 40, mode2BgColor)
-
-		// 绘制模式选项文字
-		text.Draw(screen, mode1, chineseFont, mode1X, mode1Y, color.RGBA{255, 255, 0, menuAlpha})
-		text.Draw(screen, mode1Desc, chineseFont, mode1X+140, mode1Y, color.RGBA{255, 255, 255, menuAlpha})
-		text.Draw(screen, mode2, chineseFont, mode2X, mode2Y, color.RGBA{255, 255, 0, menuAlpha})
-		text.Draw(screen, mode2Desc, chineseFont, mode2X+140, mode2Y, color.RGBA{255, 255, 255, menuAlpha})
-
-		// 绘制飞机小图标在当前选择的模式旁边
-		if mode1Highlight || mode2Highlight {
-			planeOptions := &ebiten.DrawImageOptions{}
-			// 添加小飞机图标的飘动效果
-			planeY := 0.0
-			if mode1Highlight {
-				planeY = float64(mode1Y - 15)
-			} else {
-				planeY = float64(mode2Y - 15)
+	}
+	applySettings(s)
+	var flagErr error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "resolution":
+			res, ok := findResolution(*resFlag)
+			if !ok {
+				flagErr = fmt.Errorf("未知的内部分辨率: %s", *resFlag)
 			}
-			// 让飞机小图标左右摆动
-			planeX := float64(mode1X-40) + math.Sin(float64(g.animTimer)/10.0)*5.0
-			planeOptions.GeoM.Scale(0.6, 0.6) // 缩小图标
-			planeOptions.GeoM.Translate(planeX, planeY)
-			screen.DrawImage(playerImage, planeOptions)
+			display.resolution = res
+		case "playfield":
+			field, ok := sim.FindPlayfield(*fieldFlag)
+			if !ok {
+				flagErr = fmt.Errorf("未知的场地类型: %s", *fieldFlag)
+			}
+			display.playfield = field
+		case "pixelperfect":
+			display.pixelPerfect = *pixelFlag
+		case "scale":
+			display.windowScale = max(1, min(*scaleFlag, maxWindowScale))
 		}
-
-		// 绘制操作提示
-		hint := "按对应数字键或点击选择模式"
-		hintX := screenWidth/2 - 140
-		hintY := mode2Y + 70
-
-		// 提示背景带有呼吸效果
-		hintPulse := 0.8 + math.Sin(float64(g.animTimer+30)/20.0)*0.2
-		hintBgWidth := 280.0 * hintPulse
-		ebitenutil.DrawRect(screen, float64(hintX-20)-(hintBgWidth-280)/2, float64(hintY-25), hintBgWidth, 35,
-			color.RGBA{0, 0, 100, uint8(float64(menuAlpha) * 0.7)})
-		text.Draw(screen, hint, chineseFont, hintX, hintY,
-			color.RGBA{180, 180, 255, menuAlpha})
-
-		// 添加版本信息和作者信息
-		versionText := "版本: v1.0.0"
-		authorText := "© 2025 飞机大战开发团队"
-		text.Draw(screen, versionText, chineseFont, 10, screenHeight-30,
-			color.RGBA{200, 200, 200, menuAlpha})
-		text.Draw(screen, authorText, chineseFont, screenWidth-240, screenHeight-30,
-			color.RGBA{200, 200, 200, menuAlpha})
-		return
+	})
+	if flagErr != nil {
+		log.Fatal(flagErr)
 	}
-
-	// 关卡选择模式
-	if g.gameMode == ModeLevelSelect {
-		// 绘制关卡选择菜单
-		g.levelSelectMenu.Draw(screen)
-		return
+	applyDisplay()
+
+	// 读取关卡进度，进度文件与设置文件在同一目录中
+	progPath, err := progressPath()
+	if err == nil {
+		var ok bool
+		progress, ok = LoadProgress(progPath)
+		if !ok {
+			progPath = ""
+		}
 	}
+	ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	ebiten.SetWindowTitle(gameTitle)
 
-	// 绘制玩家
-	g.player.Draw(screen)
-
-	// 只有在BOSS没有出现时才绘制普通敌机
-	if !g.bossActive {
-		// 绘制敌机
-		g.enemyManager.Draw(screen)
-	} else if g.boss != nil && g.boss.active {
-		// 绘制BOSS
-		g.boss.Draw(screen)
+	// 加载音效和音乐，关闭声音时不使用音频设备
+	audioManager := NewAudioManagerFrom(NewNullAudio())
+	// 没有音频设备或音频资源损坏时不播放声音，游戏照常运行
+	if !*muteFlag {
+		if am, err := NewAudioManager(); err != nil {
+			log.Printf("加载音频失败，游戏将没有声音: %v", err)
+		} else {
+			audioManager = am
+		}
 	}
 
-	// 绘制子弹
-	g.bulletManager.Draw(screen)
-
-	// 绘制敌机子弹
-	g.enemyBulletManager.Draw(screen)
-
-	// 绘制道具
-	g.powerUpManager.Draw(screen)
-
-	// 绘制分数
-	scoreText := fmt.Sprintf("得分: %d", g.score)
-	scoreX := 20
-	scoreY := 30
-	// 分数背景
-	ebitenutil.DrawRect(screen, float64(scoreX-10), float64(scoreY-25), 150, 35, color.RGBA{0, 0, 100, 150})
-	text.Draw(screen, scoreText, chineseFont, scoreX, scoreY, color.RGBA{255, 255, 0, 255})
-
-	// 在关卡模式下显示当前关卡和目标分数
-	if g.gameMode == ModeLevelSelect {
-		levelText := fmt.Sprintf("当前关卡: %d", g.currentLevel)
-		levelX := 20
-		levelY := 70
-		// 关卡背景
-		ebitenutil.DrawRect(screen, float64(levelX-10), float64(levelY-25), 150, 35, color.RGBA{0, 0, 100, 150})
-		text.Draw(screen, levelText, chineseFont, levelX, levelY, color.RGBA{255, 255, 0, 255})
-
-		targetText := fmt.Sprintf("目标分数: %d", g.targetScore)
-		targetX := 20
-		targetY := 110
-		// 目标分数背景
-		ebitenutil.DrawRect(screen, float64(targetX-10), float64(targetY-25), 150, 35, color.RGBA{0, 0, 100, 150})
-		text.Draw(screen, targetText, chineseFont, targetX, targetY, color.RGBA{255, 255, 0, 255})
+	// 创建游戏实例
+	keyboard := NewKeyboardInput()
+	gamepads := NewGamepadInput()
+	touches := NewTouchInput()
+	if *touchFlag {
+		touches = NewTouchInputFrom(mouseTouches{})
 	}
-
-	// 如果游戏结束，显示游戏结束信息
-	if g.isGameOver {
-		// 绘制半透明背景
-		ebitenutil.DrawRect(screen, 0, 0, float64(screenWidth), float64(screenHeight), color.RGBA{0, 0, 0, 180})
-
-		// 绘制游戏结束标题
-		gameOverMsg := "游戏结束！"
-		gameOverX := screenWidth/2 - 80
-		gameOverY := screenHeight/2 - 50
-		// 标题背景
-		ebitenutil.DrawRect(screen, float64(gameOverX-20), float64(gameOverY-35), 200, 45, color.RGBA{0, 0, 100, 200})
-		text.Draw(screen, gameOverMsg, chineseFont, gameOverX, gameOverY, color.RGBA{255, 50, 50, 255})
-
-		// 绘制最终得分
-		scoreMsg := fmt.Sprintf("最终得分：%d", g.score)
-		scoreX := screenWidth/2 - 80
-		scoreY := screenHeight / 2
-		// 得分背景
-		ebitenutil.DrawRect(screen, float64(scoreX-20), float64(scoreY-25), 200, 35, color.RGBA{0, 0, 100, 150})
-		text.Draw(screen, scoreMsg, chineseFont, scoreX, scoreY, color.RGBA{255, 255, 0, 255})
-
-		// 绘制操作提示
-		restartMsg := "按R重新开始当前模式"
-		restartX := screenWidth/2 - 150
-		restartY := screenHeight/2 + 50
-		menuMsg := "按ESC键返回模式选择"
-		menuX := screenWidth/2 - 100
-		menuY := screenHeight/2 + 90
-
-		// 提示背景
-		ebitenutil.DrawRect(screen, float64(restartX-10), float64(restartY-25), 320, 35, color.RGBA{0, 0, 100, 150})
-		ebitenutil.DrawRect(screen, float64(menuX-10), float64(menuY-25), 220, 35, color.RGBA{0, 0, 100, 150})
-
-		text.Draw(screen, restartMsg, chineseFont, restartX, restartY, color.RGBA{200, 200, 255, 255})
-		text.Draw(screen, menuMsg, chineseFont, menuX, menuY, color.RGBA{200, 200, 255, 255})
+	game := &Game{
+		scenes:   NewSceneStack(),
+		input:    NewMultiInput(keyboard, NewMouseInput(), gamepads, touches),
+		keyboard: keyboard,
+		gamepads: gamepads,
+		audio:    audioManager,
+		assets:   assets,
+		// 设置场景保存设置时使用
+		settingsPath: path,
+		// 通关或结束对局时保存进度使用
+		progressPath: progPath,
 	}
-}
+	game.scenes.Push(game, NewMenuScene())
 
-// Layout 返回游戏窗口的大小
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth, screenHeight
-}
-
-func main() {
-	ebiten.SetWindowSize(screenWidth, screenHeight)
-	ebiten.SetWindowTitle(gameTitle)
-
-	// 创建随机星星背景
-	starPositions := make([][2]float64, 100)
-	for i := 0; i < 100; i++ {
-		starPositions[i] = [2]float64{float64(rand.Intn(screenWidth)), float64(rand.Intn(screenHeight))}
+	// 回放模式下直接进入录像中的对局
+	if *replayFlag != "" {
+		replay, err := sim.LoadReplay(*replayFlag)
+		if err != nil {
+			log.Fatalf("加载录像失败: %v", err)
+		}
+		ls := findLevelPack(replay.Pack())
+		if ls == nil {
+			log.Fatalf("录像使用的关卡包%q没有加载", replay.Pack())
+		}
+		game.scenes.Push(game, NewReplayScene(replay, ls))
 	}
 
-	// 创建游戏实例并设置全局引用
-	game = &Game{
-		player:             NewPlayer(),
-		enemyManager:       NewEnemyManager(),
-		bulletManager:      NewBulletManager(),
-		enemyBulletManager: NewEnemyBulletManager(),
-		powerUpManager:     NewPowerUpManager(),
-		score:              0,
-		isGameOver:         false,
-		gameMode:           ModeMenu,
-		currentLevel:       1,
-		difficulty:         1.0,
-		// BOSS相关初始化
-		bossActive:         false,
-		bossDefeated:       false,
-		bossScoreThreshold: 500, // 默认在得分500时触发BOSS
-		// 初始化动画参数
-		animTimer:      0,
-		titleScale:     0.1,
-		titleRotation:  0.0,
-		titleAlpha:     0.0,
-		titleY:         float64(screenHeight) / 4,
-		menuItemsAlpha: 0.0,
-		starPositions:  starPositions,
+	err = ebiten.RunGame(game)
+	// 窗口关闭时依次退出所有场景，保存未完成对局的录像
+	for game.scenes.Top() != nil {
+		game.scenes.Pop(game)
 	}
-
-	if err := ebiten.RunGame(game); err != nil {
+	if err != nil {
 		log.Fatal(err)
 	}
 }
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"image/png"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/sim"
+)
+
+// 模组目录中每个关卡包的文件布局
+const (
+	modLevelsFile = "levels.json" // 关卡定义，必需
+	modBossesFile = "bosses.json" // BOSS定义，可选
+	modImagesDir  = "images"      // 贴图目录，可选，其中的PNG文件名（不含扩展名）即贴图名称
+)
+
+// ModError 表示一个因为出错而被跳过的模组
+type ModError struct {
+	mod string // 模组目录名
+	err error  // 出错原因，包含出错的文件和行号
+}
+
+// Error 返回模组名称和出错原因
+func (e *ModError) Error() string {
+	return fmt.Sprintf("模组%s: %v", e.mod, e.err)
+}
+
+// Unwrap 返回出错原因
+func (e *ModError) Unwrap() error {
+	return e.err
+}
+
+// modErrors 启动时加载失败而被跳过的模组，在关卡选择菜单中显示
+var modErrors []*ModError
+
+// LoadMods 加载模组目录下的所有关卡包，每个子目录是一个关卡包。
+// 出错的模组会被跳过并在返回的错误列表中说明原因，目录不存在时视为没有模组
+func LoadMods(dir string) ([]*sim.LevelSet, []*ModError) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, []*ModError{{mod: dir, err: err}}
+	}
+
+	var packs []*sim.LevelSet
+	var errs []*ModError
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		ls, err := loadMod(filepath.Join(dir, entry.Name()), entry.Name())
+		if err != nil {
+			modErr := &ModError{mod: entry.Name(), err: err}
+			log.Printf("跳过无法加载的%v", modErr)
+			errs = append(errs, modErr)
+			continue
+		}
+		packs = append(packs, ls)
+	}
+	return packs, errs
+}
+
+// loadMod 加载一个关卡包，只有全部文件都有效时才注册其中的贴图
+func loadMod(dir, id string) (*sim.LevelSet, error) {
+	if len(id) > 255 {
+		return nil, errors.New("模组目录名过长")
+	}
+
+	// 模组的BOSS可以使用内置贴图和自带的贴图，自带贴图以模组ID为前缀避免与其他模组冲突
+	sprites := maps.Clone(builtinSprites)
+	images := make(map[string]*Sprite)
+	files, err := filepath.Glob(filepath.Join(dir, modImagesDir, "*.png"))
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		img, err := loadSpriteFile(file)
+		if err != nil {
+			return nil, err
+		}
+		name := strings.TrimSuffix(filepath.Base(file), ".png")
+		key := id + "/" + name
+		sprites[name] = key
+		images[key] = NewStaticSprite(img)
+	}
+
+	// 模组的关卡可以使用内置BOSS和自带的BOSS，同名时自带的优先
+	bossDefs := maps.Clone(bosses)
+	bossesPath := filepath.Join(dir, modBossesFile)
+	data, err := os.ReadFile(bossesPath)
+	if err == nil {
+		defs, err := sim.ParseBosses(data, sprites, bulletPatterns)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", bossesPath, err)
+		}
+		maps.Copy(bossDefs, defs)
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	ls, err := sim.LoadLevels(filepath.Join(dir, modLevelsFile), bossDefs)
+	if err != nil {
+		return nil, err
+	}
+	ls.Pack = id
+	if ls.Name == "" {
+		ls.Name = id
+	}
+	maps.Copy(loadedSprites, images)
+	return ls, nil
+}
+
+// loadSpriteFile 从磁盘读取PNG贴图
+func loadSpriteFile(path string) (*ebiten.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: 解码贴图失败: %w", path, err)
+	}
+	return ebiten.NewImageFromImage(img), nil
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"path/filepath"
+	"slices"
+
+	"go-play-plane/internal/sim"
+)
+
+// progressVersion 进度文件的当前版本，格式不兼容地改变时递增
+const progressVersion = 1
+
+// progressFileName 进度文件的文件名，与设置文件保存在同一目录中
+const progressFileName = "progress.json"
+
+// LevelRecord 一个关卡的通关记录
+type LevelRecord struct {
+	Cleared   bool `json:"cleared"`   // 是否击败过本关BOSS
+	BestScore int  `json:"bestScore"` // 本关得到的最高分，从进入本关时开始计算
+	BestTicks int  `json:"bestTicks"` // 最快通关用时（帧），0表示尚未通关
+}
+
+// Progress 玩家的关卡进度，以JSON格式保存在用户配置目录中，启动时读取
+type Progress struct {
+	Version int                     `json:"version"` // 进度文件版本
+	Levels  map[string]*LevelRecord `json:"levels"`  // 关卡键到通关记录的映射，关卡键见levelKey
+}
+
+// progress 当前的关卡进度
+var progress = newProgress()
+
+// newProgress 返回没有任何记录的进度
+func newProgress() *Progress {
+	return &Progress{Version: progressVersion, Levels: map[string]*LevelRecord{}}
+}
+
+// levelKey 返回关卡在进度文件中的键：内置关卡为关卡ID，模组关卡为“模组目录名/关卡ID”
+func levelKey(ls *sim.LevelSet, def *sim.LevelDef) string {
+	if ls.Pack == "" {
+		return def.ID
+	}
+	return ls.Pack + "/" + def.ID
+}
+
+// Record 返回关卡key的通关记录，没有记录时返回零值
+func (p *Progress) Record(key string) LevelRecord {
+	if r := p.Levels[key]; r != nil {
+		return *r
+	}
+	return LevelRecord{}
+}
+
+// AddResult 记录关卡key的一次成绩：score为本关得分，cleared为是否击败了BOSS，
+// ticks为通关用时，只在cleared时有意义。返回记录是否有变化
+func (p *Progress) AddResult(key string, score, ticks int, cleared bool) bool {
+	r := p.Levels[key]
+	if r == nil {
+		r = &LevelRecord{}
+		p.Levels[key] = r
+	}
+	old := *r
+	r.BestScore = max(r.BestScore, score)
+	if cleared {
+		r.Cleared = true
+		if r.BestTicks == 0 || ticks < r.BestTicks {
+			r.BestTicks = ticks
+		}
+	}
+	return *r != old
+}
+
+// LoadProgress 读取进度文件，文件不存在或无法使用时从头开始记录进度，见loadJSONFile；
+// 个别记录无效时只忽略该记录。第二个返回值表示之后能否把进度保存回path
+func LoadProgress(path string) (*Progress, bool) {
+	p := newProgress()
+	if loaded, ok := loadJSONFile(path, "进度文件", p, &p.Version, progressVersion); !loaded {
+		return newProgress(), ok
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(p.Levels)) {
+		if r := p.Levels[key]; r == nil || r.BestScore < 0 || r.BestTicks < 0 {
+			log.Printf("进度文件%s: 关卡%s的记录无效，已忽略", path, key)
+			delete(p.Levels, key)
+		}
+	}
+	if p.Levels == nil {
+		p.Levels = map[string]*LevelRecord{}
+	}
+	p.Version = progressVersion
+	return p, true
+}
+
+// Save 将进度写入path，目录不存在时自动创建
+func (p *Progress) Save(path string) error {
+	return writeJSONFile(path, p)
+}
+
+// progressPath 返回进度文件的路径，与设置文件在同一目录中
+func progressPath() (string, error) {
+	path, err := settingsPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(path), progressFileName), nil
+}
+
+// formatTicks 把帧数格式化为“分:秒.百分秒”
+func formatTicks(ticks int) string {
+	centis := ticks * 100 / sim.TicksPerSecond
+	return fmt.Sprintf("%d:%02d.%02d", centis/6000, centis/100%60, centis%100)
+}
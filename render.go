@@ -0,0 +1,246 @@
+package main
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"go-play-plane/internal/sim"
+)
+
+// drawSprite 按实体的动画状态绘制精灵name的当前帧，缩放到实体大小并乘以colorScale着色；
+// 精灵缺少当前动画时绘制待机动画，找不到精灵时什么也不画；开启减少闪烁时不播放受击闪烁
+func drawSprite(screen *ebiten.Image, name string, b *sim.Body, colorScale ebiten.ColorScale) {
+	sprite, ok := loadedSprites[name]
+	if !ok {
+		return
+	}
+	anim, t := b.Anim.Current()
+	if anim == sim.AnimHit && settings.ReduceFlashing {
+		anim, t = b.Anim.Base()
+	}
+	drawFrame(screen, sprite.animation(anim).Frame(t), b, colorScale)
+}
+
+// drawFrame 将一帧图像缩放到实体b的大小绘制在实体所在的位置
+func drawFrame(screen, frame *ebiten.Image, b *sim.Body, colorScale ebiten.ColorScale) {
+	size := frame.Bounds().Size()
+	options := &ebiten.DrawImageOptions{}
+	options.GeoM.Scale(float64(b.Width)/float64(size.X), float64(b.Height)/float64(size.Y))
+	options.GeoM.Translate(b.X, b.Y)
+	options.ColorScale = colorScale
+	screen.DrawImage(frame, options)
+}
+
+// spriteIcon 返回精灵待机动画的第一帧，用于菜单等不随模拟播放动画的地方
+func spriteIcon(name string) *ebiten.Image {
+	return loadedSprites[name].animation(sim.AnimIdle).Frame(0)
+}
+
+// drawPlayer 绘制玩家飞机
+func drawPlayer(screen *ebiten.Image, p *sim.Player) {
+	drawSprite(screen, "player", &p.Body, ebiten.ColorScale{})
+
+	// 在飞机中心标出核心判定点
+	core := p.Core.Hitbox()
+	vector.DrawFilledCircle(screen, float32(core.CX), float32(core.CY), float32(core.Radius)+1, color.RGBA{255, 0, 0, 255}, true)
+	vector.DrawFilledCircle(screen, float32(core.CX), float32(core.CY), float32(core.Radius), color.White, true)
+}
+
+// drawBullet 绘制子弹
+func drawBullet(screen *ebiten.Image, b *sim.Bullet) {
+	drawSprite(screen, "bullet", &b.Body, ebiten.ColorScale{})
+}
+
+// drawBullets 绘制所有子弹
+func drawBullets(screen *ebiten.Image, bm *sim.BulletManager) {
+	for _, bullet := range bm.Bullets {
+		drawBullet(screen, bullet)
+	}
+}
+
+// drawEnemy 绘制敌机
+func drawEnemy(screen *ebiten.Image, e *sim.Enemy) {
+	// 按敌机种类着色，便于区分
+	var tint ebiten.ColorScale
+	switch e.Kind {
+	case sim.KindFast:
+		tint.Scale(1, 0.6, 0.6, 1)
+	case sim.KindTank:
+		tint.Scale(0.6, 0.7, 1, 1)
+	}
+	drawSprite(screen, "enemy", &e.Body, tint)
+
+	// 血条宽度与敌机相同
+	bloodBarWidth := float64(e.Width)
+	const bloodBarHeight = 5.0
+
+	// 先绘制整个血条的灰色背景（表示总血量）
+	ebitenutil.DrawRect(screen, e.X, e.Y-8, bloodBarWidth, bloodBarHeight, color.RGBA{100, 100, 100, 200})
+
+	// 计算当前血量比例
+	healthRatio := float64(e.Health) / float64(e.MaxHealth)
+	healthWidth := bloodBarWidth * healthRatio
+
+	// 根据健康比例变化颜色
+	// 满血时是绿色(0,255,0)，血量越低越变红
+	var healthColor color.RGBA
+	if healthRatio == 1.0 {
+		// 满血时显示绿色
+		healthColor = color.RGBA{0, 255, 0, 255}
+	} else {
+		// 非满血时从黄色渐变到红色
+		greenValue := uint8(200 * healthRatio)
+		healthColor = color.RGBA{255, greenValue, 0, 255}
+	}
+
+	// 绘制健康部分
+	ebitenutil.DrawRect(screen, e.X, e.Y-8, healthWidth, bloodBarHeight, healthColor)
+}
+
+// drawEnemies 绘制所有敌机
+func drawEnemies(screen *ebiten.Image, em *sim.EnemyManager) {
+	for _, enemy := range em.Enemies {
+		drawEnemy(screen, enemy)
+	}
+}
+
+// drawEnemyBullet 绘制敌机子弹
+func drawEnemyBullet(screen *ebiten.Image, b *sim.EnemyBullet) {
+	// 使用自定义颜色绘制子弹
+	bulletColor := b.Color
+	// 如果颜色为空值，使用默认红色
+	if bulletColor.A == 0 {
+		bulletColor = color.RGBA{255, 0, 0, 255}
+	}
+
+	// 追踪子弹的外发光颜色
+	glowColor := color.RGBA{bulletColor.R, bulletColor.G, bulletColor.B, 100}
+
+	// 追踪子弹先在下层绘制外发光
+	if b.IsHoming {
+		if b.Shape == sim.ShapeCircle {
+			hitbox := b.Hitbox()
+			vector.DrawFilledCircle(screen, float32(hitbox.CX), float32(hitbox.CY), float32(hitbox.Radius)+2, glowColor, true)
+		} else {
+			ebitenutil.DrawRect(screen, b.X-2, b.Y-2, float64(b.Width)+4, float64(b.Height)+4, glowColor)
+		}
+	}
+
+	// 高对比度模式下先绘制白色描边，使子弹在任何背景上都清晰可见
+	if settings.HighContrastBullets {
+		if b.Shape == sim.ShapeCircle {
+			hitbox := b.Hitbox()
+			vector.DrawFilledCircle(screen, float32(hitbox.CX), float32(hitbox.CY), float32(hitbox.Radius)+1.5, color.White, true)
+		} else {
+			ebitenutil.DrawRect(screen, b.X-1.5, b.Y-1.5, float64(b.Width)+3, float64(b.Height)+3, color.White)
+		}
+	}
+
+	// 白色的子弹贴图按子弹颜色着色，并缩放到判定大小，保证看到的就是实际判定
+	var tint ebiten.ColorScale
+	tint.ScaleWithColor(bulletColor)
+	drawSprite(screen, "enemyBullet", &b.Body, tint)
+}
+
+// drawEnemyBullets 绘制所有敌机子弹
+func drawEnemyBullets(screen *ebiten.Image, bm *sim.EnemyBulletManager) {
+	for _, bullet := range bm.Bullets {
+		drawEnemyBullet(screen, bullet)
+	}
+}
+
+// drawPowerUps 绘制所有道具
+func drawPowerUps(screen *ebiten.Image, pm *sim.PowerUpManager) {
+	for _, powerUp := range pm.PowerUps {
+		drawPowerUp(screen, powerUp)
+	}
+}
+
+// drawPowerUp 绘制道具
+func drawPowerUp(screen *ebiten.Image, p *sim.PowerUp) {
+	// 根据道具类型选择不同的颜色
+	var powerUpColor color.RGBA
+	switch p.Kind {
+	case sim.MultiShot:
+		powerUpColor = color.RGBA{0, 255, 0, 255} // 绿色
+	case sim.ScreenShot:
+		powerUpColor = color.RGBA{0, 0, 255, 255} // 蓝色
+	case sim.AttackBoost:
+		powerUpColor = color.RGBA{128, 0, 128, 255} // 紫色
+	case sim.ClearBullets:
+		powerUpColor = color.RGBA{255, 165, 0, 255} // 橙色
+	}
+
+	// 白色的道具贴图按道具类型着色
+	var tint ebiten.ColorScale
+	tint.ScaleWithColor(powerUpColor)
+	drawSprite(screen, "powerUp", &p.Body, tint)
+}
+
+// drawBoss 绘制BOSS
+func drawBoss(screen *ebiten.Image, b *sim.Boss) {
+	// 按BOSS定义着色
+	var tint ebiten.ColorScale
+	if t := b.Def.Tint; t != nil {
+		tint.Scale(float32(t[0]), float32(t[1]), float32(t[2]), 1)
+	}
+
+	// 添加闪烁效果，开启减少闪烁时不闪烁
+	if !settings.ReduceFlashing && b.AnimTimer%10 < 5 && b.Phase > 1 && b.Phase >= len(b.Def.Phases)-1 {
+		tint.Scale(1.2, 1.2, 1.2, 1) // 最后两个阶段闪烁发亮
+	}
+
+	drawSprite(screen, b.Def.SpriteKey, &b.Body, tint)
+
+	// 绘制BOSS血条背景
+	bloodBarWidth := float64(b.Field.Width - 100)
+	const bloodBarHeight = 15.0
+	ebitenutil.DrawRect(screen, 50, 20, bloodBarWidth, bloodBarHeight, color.RGBA{100, 100, 100, 200})
+
+	// 计算当前血量比例
+	healthRatio := float64(b.Health) / float64(b.MaxHealth)
+	healthWidth := bloodBarWidth * healthRatio
+
+	// 根据健康比例变化颜色
+	var healthColor color.RGBA
+	if healthRatio > 0.75 {
+		// 高血量时显示绿色
+		healthColor = color.RGBA{0, 255, 0, 255}
+	} else if healthRatio > 0.5 {
+		// 中高血量显示黄色
+		healthColor = color.RGBA{255, 255, 0, 255}
+	} else if healthRatio > 0.25 {
+		// 中低血量显示橙色
+		healthColor = color.RGBA{255, 165, 0, 255}
+	} else {
+		// 低血量显示红色
+		healthColor = color.RGBA{255, 0, 0, 255}
+	}
+
+	// 绘制健康部分
+	ebitenutil.DrawRect(screen, 50, 20, healthWidth, bloodBarHeight, healthColor)
+
+	// 添加血条装饰效果
+	for i := 0; i < 10; i++ {
+		if float64(i)/10.0 <= healthRatio {
+			// 绘制分段标记
+			markerX := 50 + (bloodBarWidth/10.0)*float64(i)
+			ebitenutil.DrawRect(screen, markerX, 18, 2, bloodBarHeight+4, color.RGBA{255, 255, 255, 200})
+		}
+	}
+}
+
+// drawEffects 绘制所有视觉效果
+func drawEffects(screen *ebiten.Image, em *sim.EffectManager) {
+	for _, e := range em.Effects {
+		drawEffect(screen, e)
+	}
+}
+
+// drawEffect 播放效果所属精灵的爆炸动画，精灵没有爆炸动画时使用敌机的爆炸动画
+func drawEffect(screen *ebiten.Image, e *sim.Effect) {
+	_, t := e.Anim.Current()
+	drawFrame(screen, explodeAnimation(e.Sprite).Frame(t), &e.Body, ebiten.ColorScale{})
+}
@@ -5,5 +5,17 @@ import "embed"
 //go:embed resources/fonts/SourceHanSansCN-Regular.ttf
 var chineseFontFS embed.FS
 
-//go:embed resources/images/enemy.png resources/images/player.png
-var imagesFS embed.FS
+//go:embed resources/sprites/atlas.json resources/sprites/atlas.png
+var spritesFS embed.FS
+
+//go:embed resources/levels/levels.json
+var defaultLevelsJSON []byte
+
+//go:embed resources/patterns/boss.json
+var defaultPatternsJSON []byte
+
+//go:embed resources/bosses/bosses.json
+var defaultBossesJSON []byte
+
+//go:embed resources/audio
+var audioFS embed.FS
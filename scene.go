@@ -0,0 +1,175 @@
+package main
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/sim"
+)
+
+// Scene 表示一个可以压入场景栈的画面，例如菜单、对局、暂停和结算
+type Scene interface {
+	// Enter 在场景被压入栈时调用
+	Enter(g *Game)
+	// Exit 在场景从栈中移除时调用，被其他场景覆盖时不会调用；覆盖它的场景弹出时见resumeScene
+	Exit(g *Game)
+	// Update 只有栈顶场景会收到本帧输入
+	Update(g *Game, in sim.InputState) error
+	// Draw 绘制场景
+	Draw(g *Game, screen *ebiten.Image)
+}
+
+// overlayScene 标记需要绘制在下层场景之上的半透明场景
+type overlayScene interface {
+	isOverlay()
+}
+
+// overlay 嵌入到场景结构体中，使其作为覆盖层绘制
+type overlay struct{}
+
+func (overlay) isOverlay() {}
+
+// musicScene 由需要播放背景音乐的场景实现，没有实现的场景沿用下层场景的音乐
+type musicScene interface {
+	// Music 返回场景的背景音乐曲目，为空表示不播放音乐
+	Music() string
+}
+
+// resumeScene 由需要在重新回到栈顶时更新内容的场景实现，例如对局结束后刷新成绩的关卡选择菜单
+type resumeScene interface {
+	// Resume 在覆盖该场景的场景弹出、该场景重新成为栈顶时调用
+	Resume(g *Game)
+}
+
+// SceneStack 管理场景的压入和弹出
+type SceneStack struct {
+	scenes  []Scene
+	changed bool // 本帧栈顶是否发生变化
+}
+
+// NewSceneStack 创建一个空的场景栈
+func NewSceneStack() *SceneStack {
+	return &SceneStack{scenes: make([]Scene, 0)}
+}
+
+// Top 返回栈顶场景，栈为空时返回nil
+func (ss *SceneStack) Top() Scene {
+	if len(ss.scenes) == 0 {
+		return nil
+	}
+	return ss.scenes[len(ss.scenes)-1]
+}
+
+// Push 压入新场景
+func (ss *SceneStack) Push(g *Game, scene Scene) {
+	ss.scenes = append(ss.scenes, scene)
+	ss.changed = true
+	scene.Enter(g)
+}
+
+// Pop 弹出栈顶场景
+func (ss *SceneStack) Pop(g *Game) {
+	top := ss.Top()
+	if top == nil {
+		return
+	}
+	top.Exit(g)
+	ss.scenes[len(ss.scenes)-1] = nil
+	ss.scenes = ss.scenes[:len(ss.scenes)-1]
+	ss.changed = true
+	if s, ok := ss.Top().(resumeScene); ok {
+		s.Resume(g)
+	}
+}
+
+// Replace 用新场景替换栈顶场景
+func (ss *SceneStack) Replace(g *Game, scene Scene) {
+	if top := ss.Top(); top != nil {
+		top.Exit(g)
+		ss.scenes = ss.scenes[:len(ss.scenes)-1]
+	}
+	ss.scenes = append(ss.scenes, scene)
+	ss.changed = true
+	scene.Enter(g)
+}
+
+// Update 更新栈顶场景
+func (ss *SceneStack) Update(g *Game, in sim.InputState) error {
+	top := ss.Top()
+	if top == nil {
+		return nil
+	}
+	return top.Update(g, in)
+}
+
+// Music 返回从栈顶向下第一个实现了musicScene的场景的背景音乐
+func (ss *SceneStack) Music() string {
+	for i := len(ss.scenes) - 1; i >= 0; i-- {
+		if s, ok := ss.scenes[i].(musicScene); ok {
+			return s.Music()
+		}
+	}
+	return ""
+}
+
+// Draw 从最上层的不透明场景开始，依次向上绘制
+func (ss *SceneStack) Draw(g *Game, screen *ebiten.Image) {
+	start := len(ss.scenes) - 1
+	for start > 0 {
+		if _, ok := ss.scenes[start].(overlayScene); !ok {
+			break
+		}
+		start--
+	}
+	for i := max(start, 0); i < len(ss.scenes); i++ {
+		ss.scenes[i].Draw(g, screen)
+	}
+}
+
+// 菜单导航的按键重复
+const (
+	menuRepeatDelay    = 20  // 按住方向超过该帧数后开始重复移动
+	menuRepeatInterval = 10  // 重复移动的间隔帧数
+	menuAxisThreshold  = 0.5 // 摇杆偏移超过该值才算按下方向
+)
+
+// menuNav 把方向输入转换为菜单中的移动：只取偏移较大的一个轴，斜推摇杆时不会同时在两个方向上移动；
+// 按下的瞬间移动一次，按住超过menuRepeatDelay帧后每menuRepeatInterval帧再移动一次
+type menuNav struct {
+	dx, dy int // 上一帧按下的方向
+	held   int // 当前方向已经按住的帧数
+}
+
+// update 读取本帧的方向输入，返回本帧要移动的方向，最多一个轴不为0
+func (n *menuNav) update(in sim.InputState) (dx, dy int) {
+	if math.Abs(in.MoveY) > math.Abs(in.MoveX) {
+		dy = axisDirection(in.MoveY)
+	} else {
+		dx = axisDirection(in.MoveX)
+	}
+	if dx != n.dx || dy != n.dy {
+		n.dx, n.dy, n.held = dx, dy, 0
+	} else {
+		n.held++
+	}
+	if n.held == 0 || (n.held >= menuRepeatDelay && (n.held-menuRepeatDelay)%menuRepeatInterval == 0) {
+		return dx, dy
+	}
+	return 0, 0
+}
+
+// pressed 返回本帧是否刚按下一个方向，用于不应重复触发的操作，例如切换标签页
+func (n *menuNav) pressed() bool {
+	return n.held == 0 && (n.dx != 0 || n.dy != 0)
+}
+
+// axisDirection 把摇杆一个轴的偏移转换为-1、0或1
+func axisDirection(v float64) int {
+	switch {
+	case v >= menuAxisThreshold:
+		return 1
+	case v <= -menuAxisThreshold:
+		return -1
+	}
+	return 0
+}
@@ -0,0 +1,305 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"slices"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"go-play-plane/internal/sim"
+	"golang.org/x/image/font"
+)
+
+// 键位设置界面的布局
+const (
+	controlsRowHeight   = 26  // 每行的高度
+	controlsRowWidth    = 540 // 每行的宽度
+	controlsLabelWidth  = 110 // 操作名称列的宽度
+	controlsColumnWidth = 215 // 键盘和手柄两列各自的宽度
+)
+
+// maxBindingsPerAction 每个操作在键盘或手柄上最多绑定的按键数，绑定更多时替换最早的一个
+const maxBindingsPerAction = 3
+
+// 键位设置界面的两列
+const (
+	columnKeyboard = iota // 键盘按键
+	columnGamepad         // 手柄按钮
+	columnCount
+)
+
+// controlsButtons 键位列表下方的按钮
+var controlsButtons = []string{"恢复默认键位", "完成"}
+
+// ControlsScene 键位设置界面，覆盖在设置界面之上，直接修改设置界面的草稿，
+// 在设置界面中保存后才生效
+type ControlsScene struct {
+	overlay
+	draft     *Settings // 设置界面正在编辑的设置
+	row       int       // 当前选中的行，操作之后是按钮
+	column    int       // 当前选中的列
+	capturing bool      // 是否正在等待玩家按下新的按键
+	nav       menuNav   // 方向键和摇杆的导航状态
+	held      bool      // 上一帧是否按住确认、返回或鼠标，只在按下的瞬间响应
+	// 本帧刚按下的按键和手柄按钮，每帧复用
+	keys    []ebiten.Key
+	buttons []ebiten.StandardGamepadButton
+}
+
+// NewControlsScene 创建键位设置界面，编辑draft中的键位
+func NewControlsScene(draft *Settings) *ControlsScene {
+	return &ControlsScene{draft: draft}
+}
+
+// Enter 进入键位设置
+func (c *ControlsScene) Enter(g *Game) {}
+
+// Exit 离开键位设置
+func (c *ControlsScene) Exit(g *Game) {}
+
+// rowCount 返回总行数
+func (c *ControlsScene) rowCount() int {
+	return int(actionCount) + len(controlsButtons)
+}
+
+// rowY 返回第i行的顶部位置，所有行在画面中垂直居中
+func (c *ControlsScene) rowY(i int) int {
+	top := (screenHeight-c.rowCount()*controlsRowHeight)/2 + 10
+	return top + i*controlsRowHeight
+}
+
+// cellRect 返回第row行第column列的区域；按钮行只有一个占满整行的单元格，column被忽略
+func (c *ControlsScene) cellRect(row, column int) (x, y, width, height float64) {
+	x = float64(screenWidth/2 - controlsRowWidth/2)
+	y = float64(c.rowY(row))
+	height = controlsRowHeight - 2
+	if row >= int(actionCount) {
+		return x, y, controlsRowWidth, height
+	}
+	x += float64(controlsLabelWidth + column*controlsColumnWidth)
+	return x, y, controlsColumnWidth - 4, height
+}
+
+// names 返回第column列的键位，操作名称到按键名称列表
+func (c *ControlsScene) names(column int) map[string][]string {
+	if column == columnGamepad {
+		return c.draft.PadBindings
+	}
+	return c.draft.KeyBindings
+}
+
+// bind 为当前选中的操作添加一个按键，已经绑定时不重复添加
+func (c *ControlsScene) bind(name string) {
+	m := c.names(c.column)
+	action := actionNames[c.row]
+	if slices.Contains(m[action], name) {
+		return
+	}
+	list := append(slices.Clone(m[action]), name)
+	if len(list) > maxBindingsPerAction {
+		list = list[1:]
+	}
+	m[action] = list
+}
+
+// capture 检查本帧是否按下了新的按键或手柄按钮，返回是否结束捕获。按ESC取消捕获
+func (c *ControlsScene) capture() bool {
+	for _, key := range c.keys {
+		if key == ebiten.KeyEscape {
+			return true
+		}
+		if c.column == columnKeyboard {
+			c.bind(key.String())
+			return true
+		}
+	}
+	if c.column != columnGamepad {
+		return false
+	}
+	for _, button := range c.buttons {
+		if _, ok := padButtonNames[button]; ok {
+			c.bind(padButtonNames[button])
+			return true
+		}
+	}
+	return false
+}
+
+// pollPressed 读取本帧刚按下的按键和手柄按钮，不经过键位绑定
+func (c *ControlsScene) pollPressed(g *Game) {
+	c.keys, c.buttons = c.keys[:0], c.buttons[:0]
+	if g.keyboard != nil {
+		c.keys = g.keyboard.AppendJustPressedKeys(c.keys)
+	}
+	if g.gamepads != nil {
+		c.buttons = g.gamepads.AppendJustPressedButtons(c.buttons)
+	}
+}
+
+// activate 确认当前选中的行：操作行开始捕获按键，按钮行执行对应的动作
+func (c *ControlsScene) activate(g *Game) {
+	switch c.row - int(actionCount) {
+	case 0:
+		c.draft.KeyBindings = defaultBindings.keyNames()
+		c.draft.PadBindings = defaultBindings.buttonNames()
+	case 1:
+		g.scenes.Pop(g)
+	default:
+		c.capturing = true
+	}
+}
+
+// Update 处理选择、捕获和清除按键
+func (c *ControlsScene) Update(g *Game, in sim.InputState) error {
+	c.pollPressed(g)
+
+	held := in.Confirm || in.Back || in.Click
+	pressed := held && !c.held
+	c.held = held
+
+	// 捕获期间所有输入都交给capture，结束后等待按键松开，避免刚绑定的按键触发其他操作
+	if c.capturing {
+		if c.capture() {
+			c.capturing = false
+			c.held = true
+		}
+		return nil
+	}
+
+	// 上下键选择行，左右键切换键盘和手柄列
+	dx, dy := c.nav.update(in)
+	if dy != 0 {
+		c.row = cycle(c.row, c.rowCount(), dy)
+	}
+	if dx != 0 {
+		c.column = cycle(c.column, columnCount, dx)
+	}
+
+	// Delete或退格键清除选中单元格的所有按键
+	if c.row < int(actionCount) &&
+		(slices.Contains(c.keys, ebiten.KeyDelete) || slices.Contains(c.keys, ebiten.KeyBackspace)) {
+		c.names(c.column)[actionNames[c.row]] = []string{}
+	}
+
+	if !pressed {
+		return nil
+	}
+	if in.Click {
+		for row := 0; row < c.rowCount(); row++ {
+			for column := 0; column < columnCount; column++ {
+				if x, y, w, h := c.cellRect(row, column); checkCursorInArea(in, x, y, w, h) {
+					c.row, c.column = row, column
+					c.activate(g)
+					return nil
+				}
+			}
+		}
+		return nil
+	}
+	if in.Back {
+		g.scenes.Pop(g)
+		return nil
+	}
+	c.activate(g)
+	return nil
+}
+
+// problems 返回当前键位中的冲突和没有绑定任何按键的操作，以及涉及的操作
+func (c *ControlsScene) problems() ([]string, [actionCount]bool) {
+	var messages []string
+	var involved [actionCount]bool
+	b := c.draft.toBindings()
+	for _, conflict := range b.Conflicts() {
+		messages = append(messages, "冲突："+conflict.Error())
+		involved[conflict.a] = true
+		if !conflict.menuChoice {
+			involved[conflict.b] = true
+		}
+	}
+	for a := Action(0); a < actionCount; a++ {
+		if len(b.keys[a]) == 0 && len(b.buttons[a]) == 0 {
+			messages = append(messages, fmt.Sprintf("%s没有绑定任何按键", actionLabels[a]))
+			involved[a] = true
+		}
+	}
+	return messages, involved
+}
+
+// Draw 绘制键位设置界面
+func (c *ControlsScene) Draw(g *Game, screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 0, 0, float64(screenWidth), float64(screenHeight), color.RGBA{0, 0, 30, 240})
+
+	// 标题和列名
+	title := "键位设置"
+	titleX := screenWidth/2 - font.MeasureString(chineseFont, title).Round()/2
+	text.Draw(screen, title, chineseFont, titleX, c.rowY(0)-30, color.RGBA{255, 255, 255, 255})
+	for column, name := range []string{"键盘", "手柄"} {
+		x, _, _, _ := c.cellRect(0, column)
+		text.Draw(screen, name, smallFont, int(x)+8, c.rowY(0)-6, color.RGBA{180, 220, 255, 255})
+	}
+
+	messages, involved := c.problems()
+
+	// 操作行
+	for a := Action(0); a < actionCount; a++ {
+		row := int(a)
+		labelColor := color.RGBA{255, 255, 255, 255}
+		if involved[a] {
+			labelColor = color.RGBA{255, 100, 100, 255}
+		}
+		lx, ly, _, _ := c.cellRect(row, 0)
+		text.Draw(screen, actionLabels[a], smallFont, int(lx)-controlsLabelWidth+15, int(ly)+17, labelColor)
+
+		for column := 0; column < columnCount; column++ {
+			x, y, w, h := c.cellRect(row, column)
+			bgColor := color.RGBA{0, 0, 100, 150}
+			if row == c.row && column == c.column {
+				bgColor = color.RGBA{50, 50, 150, 220}
+			} else if checkCursorInArea(g.lastInput, x, y, w, h) {
+				bgColor = color.RGBA{30, 30, 130, 200}
+			}
+			ebitenutil.DrawRect(screen, x, y, w, h, bgColor)
+
+			value := strings.Join(c.names(column)[actionNames[a]], ", ")
+			valueColor := color.RGBA{220, 220, 220, 255}
+			if c.capturing && row == c.row && column == c.column {
+				value = "按下新的按键…"
+				valueColor = color.RGBA{255, 255, 0, 255}
+			}
+			text.Draw(screen, value, smallFont, int(x)+8, int(y)+17, valueColor)
+		}
+	}
+
+	// 按钮行
+	for i, label := range controlsButtons {
+		row := int(actionCount) + i
+		x, y, w, h := c.cellRect(row, 0)
+		bgColor := color.RGBA{0, 0, 100, 150}
+		if row == c.row {
+			bgColor = color.RGBA{50, 50, 150, 220}
+		} else if checkCursorInArea(g.lastInput, x, y, w, h) {
+			bgColor = color.RGBA{30, 30, 130, 200}
+		}
+		ebitenutil.DrawRect(screen, x, y, w, h, bgColor)
+		labelX := screenWidth/2 - font.MeasureString(smallFont, label).Round()/2
+		text.Draw(screen, label, smallFont, labelX, int(y)+17, color.RGBA{255, 255, 0, 255})
+	}
+
+	// 操作提示，键位有问题时显示第一个问题
+	hint := "确认键添加按键，Delete清除，ESC返回"
+	hintColor := color.RGBA{180, 180, 255, 255}
+	if c.capturing {
+		hint = "按下要绑定的按键，按ESC取消"
+	} else if len(messages) > 0 {
+		hint = messages[0]
+		if len(messages) > 1 {
+			hint += fmt.Sprintf("（另有%d处问题）", len(messages)-1)
+		}
+		hintColor = color.RGBA{255, 100, 100, 255}
+	}
+	hintX := screenWidth/2 - font.MeasureString(smallFont, hint).Round()/2
+	text.Draw(screen, hint, smallFont, hintX, c.rowY(c.rowCount())+20, hintColor)
+}
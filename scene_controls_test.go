@@ -0,0 +1,116 @@
+package main
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// controlsHarness 用虚拟键盘和虚拟手柄驱动键位设置界面
+type controlsHarness struct {
+	g    *Game
+	keys *VirtualKeyboard
+	pad  *VirtualGamepad
+	c    *ControlsScene
+}
+
+func newControlsHarness(t *testing.T) *controlsHarness {
+	useSettings(t, defaultSettings())
+	keys := NewVirtualKeyboard()
+	pads := NewVirtualGamepads()
+	h := &controlsHarness{keys: keys, pad: pads.Connect("手柄", "pad")}
+	h.g = &Game{
+		scenes:   NewSceneStack(),
+		keyboard: NewKeyboardInputFrom(keys),
+		gamepads: NewGamepadInputFrom(pads),
+	}
+	h.g.input = NewMultiInput(h.g.keyboard, h.g.gamepads)
+	draft := defaultSettings()
+	h.c = NewControlsScene(&draft)
+	h.g.scenes.Push(h.g, h.c)
+	return h
+}
+
+// step 推进一帧
+func (h *controlsHarness) step(t *testing.T) {
+	t.Helper()
+	if err := h.g.scenes.Update(h.g, h.g.input.Poll()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tap 按下并松开按键，各推进一帧
+func (h *controlsHarness) tap(t *testing.T, key ebiten.Key) {
+	t.Helper()
+	h.keys.Press(key)
+	h.step(t)
+	h.keys.Release(key)
+	h.step(t)
+}
+
+func TestControlsSceneCapturesKeysAndButtons(t *testing.T) {
+	h := newControlsHarness(t)
+	h.c.row = int(ActionBomb)
+
+	// 确认键开始捕获，下一个刚按下的按键绑定到选中的操作
+	h.tap(t, ebiten.KeyEnter)
+	if !h.c.capturing {
+		t.Fatal("按下确认键后没有开始捕获")
+	}
+	h.tap(t, ebiten.KeyB)
+	if h.c.capturing {
+		t.Fatal("按下新按键后仍在捕获")
+	}
+	if got, want := h.c.draft.KeyBindings["bomb"], []string{"X", "B"}; !slices.Equal(got, want) {
+		t.Errorf("炸弹的按键为%v，应为%v", got, want)
+	}
+
+	// 手柄列捕获手柄按钮
+	h.c.column = columnGamepad
+	h.tap(t, ebiten.KeyEnter)
+	h.pad.Press(ebiten.StandardGamepadButtonFrontTopLeft)
+	h.step(t)
+	h.pad.Release(ebiten.StandardGamepadButtonFrontTopLeft)
+	h.step(t)
+	if got, want := h.c.draft.PadBindings["bomb"], []string{"X", "LB"}; !slices.Equal(got, want) {
+		t.Errorf("炸弹的手柄按钮为%v，应为%v", got, want)
+	}
+
+	// Delete清除选中单元格的所有按钮
+	h.tap(t, ebiten.KeyDelete)
+	if got := h.c.draft.PadBindings["bomb"]; len(got) != 0 {
+		t.Errorf("按Delete后炸弹的手柄按钮为%v，应为空", got)
+	}
+}
+
+func TestControlsSceneEscapeCancelsCapture(t *testing.T) {
+	h := newControlsHarness(t)
+	h.c.row = int(ActionFire)
+	h.tap(t, ebiten.KeyEnter)
+	h.tap(t, ebiten.KeyEscape)
+	if h.c.capturing {
+		t.Fatal("按ESC后仍在捕获")
+	}
+	if got, want := h.c.draft.KeyBindings["fire"], []string{"Space"}; !slices.Equal(got, want) {
+		t.Errorf("取消捕获后开火的按键为%v，应为%v", got, want)
+	}
+	if h.g.scenes.Top() != h.c {
+		t.Error("取消捕获的ESC不应同时关闭键位设置界面")
+	}
+}
+
+func TestConflictsWithMenuChoiceKeys(t *testing.T) {
+	b := defaultBindings
+	b.keys[ActionUp] = []ebiten.Key{ebiten.Key1}
+	// 开火只在对局中使用，不读取数字键选择菜单项，不算冲突
+	b.keys[ActionFire] = []ebiten.Key{ebiten.Key2}
+
+	conflicts := b.Conflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("冲突为%v，应只有上移与菜单选择冲突", conflicts)
+	}
+	if c := conflicts[0]; !c.menuChoice || c.a != ActionUp || c.binding != ebiten.Key1.String() {
+		t.Errorf("冲突为%+v，应为上移绑定了%v", c, ebiten.Key1)
+	}
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"image/color"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"go-play-plane/internal/sim"
+)
+
+// MenuScene 主菜单场景，负责启动动画和模式选择
+type MenuScene struct {
+	animTimer      int          // 动画计时器
+	nav            menuNav      // 方向键和摇杆的导航状态
+	titleScale     float64      // 标题缩放
+	titleRotation  float64      // 标题旋转角度
+	titleAlpha     float64      // 标题透明度
+	menuItemsAlpha float64      // 菜单项透明度
+	starPositions  [][2]float64 // 背景星星位置，以画面宽高的比例表示，改变分辨率后仍然铺满画面
+	selected       int          // 当前选中的菜单项（从1开始），可以用方向键、摇杆或指针选择
+	cursorX        int          // 上一帧的指针位置，指针移动时才按指针位置选择菜单项
+	cursorY        int
+}
+
+// NewMenuScene 创建主菜单场景
+func NewMenuScene() *MenuScene {
+	// 创建随机星星背景，仅用于菜单装饰，不影响对局
+	starRng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	starPositions := make([][2]float64, 100)
+	for i := 0; i < 100; i++ {
+		starPositions[i] = [2]float64{starRng.Float64(), starRng.Float64()}
+	}
+
+	return &MenuScene{
+		animTimer:      0,
+		titleScale:     0.1,
+		titleRotation:  0.0,
+		titleAlpha:     0.0,
+		menuItemsAlpha: 0.0,
+		starPositions:  starPositions,
+		selected:       1,
+	}
+}
+
+// titleY 返回标题中心的Y坐标，菜单项都以它为基准向下排列
+func (ms *MenuScene) titleY() float64 {
+	return float64(screenHeight) / 4
+}
+
+// menuItem 主菜单中的一个选项
+type menuItem struct {
+	label string // 选项名称，带有对应的数字键
+	desc  string // 说明
+}
+
+// menuItems 主菜单选项，第i项（从1开始）对应数字键i
+var menuItems = []menuItem{
+	{"[1] 关卡模式", "逐级挑战，难度递增"},
+	{"[2] 无尽模式", "无限挑战，直到失败"},
+	{"[3] 设置", "调整画面和声音"},
+}
+
+// modeItemPos 返回第i个模式选项（从1开始）文字的位置
+func (ms *MenuScene) modeItemPos(i int) (int, int) {
+	return screenWidth/2 - 150, int(ms.titleY()) + 120 + 45*i
+}
+
+// modeItemRect 返回第i个模式选项的背景区域，绘制、悬停高亮和点击检测共用
+func (ms *MenuScene) modeItemRect(i int) (x, y, width, height float64) {
+	itemX, itemY := ms.modeItemPos(i)
+	return float64(itemX - 20), float64(itemY - 25), 340, 40
+}
+
+// Enter 进入主菜单
+func (ms *MenuScene) Enter(g *Game) {}
+
+// Exit 离开主菜单
+func (ms *MenuScene) Exit(g *Game) {}
+
+// Music 主菜单播放菜单音乐
+func (ms *MenuScene) Music() string {
+	return sim.MusicMenu
+}
+
+// Update 处理模式选择和动画效果
+func (ms *MenuScene) Update(g *Game, in sim.InputState) error {
+	// 更新动画计时器
+	ms.animTimer++
+
+	// 标题动画效果
+	if ms.titleScale < 1.0 {
+		ms.titleScale += 0.05
+		if ms.titleScale > 1.0 {
+			ms.titleScale = 1.0
+		}
+	}
+
+	// 标题旋转效果
+	if ms.animTimer < 30 {
+		ms.titleRotation = math.Sin(float64(ms.animTimer)/10) * 0.1
+	} else {
+		ms.titleRotation = math.Sin(float64(ms.animTimer)/100) * 0.03
+	}
+
+	// 标题透明度渐变
+	if ms.titleAlpha < 1.0 {
+		ms.titleAlpha += 0.03
+		if ms.titleAlpha > 1.0 {
+			ms.titleAlpha = 1.0
+		}
+	}
+
+	// 菜单项目透明度渐变，在标题出现后
+	if ms.titleAlpha >= 0.8 && ms.menuItemsAlpha < 1.0 {
+		ms.menuItemsAlpha += 0.03
+		if ms.menuItemsAlpha > 1.0 {
+			ms.menuItemsAlpha = 1.0
+		}
+	}
+
+	// 菜单项出现后，上下键或摇杆选择菜单项，确认键进入
+	if ms.menuItemsAlpha >= 0.9 {
+		if _, dy := ms.nav.update(in); dy != 0 {
+			ms.selected = cycle(ms.selected-1, len(menuItems), dy) + 1
+		}
+		if in.Confirm {
+			ms.choose(g, ms.selected)
+			return nil
+		}
+	}
+
+	// 指针移动到菜单项上时选中它
+	if in.HasCursor && (in.CursorX != ms.cursorX || in.CursorY != ms.cursorY) {
+		ms.cursorX, ms.cursorY = in.CursorX, in.CursorY
+		for i := 1; i <= len(menuItems); i++ {
+			if x, y, w, h := ms.modeItemRect(i); checkCursorInArea(in, x, y, w, h) {
+				ms.selected = i
+			}
+		}
+	}
+
+	// 按数字键或点击选择菜单项
+	for i := 1; i <= len(menuItems); i++ {
+		x, y, w, h := ms.modeItemRect(i)
+		if (in.MenuChoice == i && ms.menuItemsAlpha >= 0.9) || (in.Click && checkCursorInArea(in, x, y, w, h)) {
+			ms.choose(g, i)
+			return nil
+		}
+	}
+	return nil
+}
+
+// choose 进入第i个菜单项对应的场景
+func (ms *MenuScene) choose(g *Game, i int) {
+	switch i {
+	case 1:
+		// 进入关卡选择模式，关卡选择菜单只创建一次以保留选中状态
+		if g.levelSelectMenu == nil {
+			g.levelSelectMenu = NewLevelSelectMenu()
+		}
+		g.scenes.Push(g, g.levelSelectMenu)
+	case 2:
+		g.scenes.Push(g, NewPlayScene(sim.NewSimulation(levels, display.playfield, sim.ModeEndless, 1, newSeed())))
+	case 3:
+		g.scenes.Push(g, NewOptionsScene())
+	}
+}
+
+// Draw 绘制主菜单
+func (ms *MenuScene) Draw(g *Game, screen *ebiten.Image) {
+	// 绘制渐变背景
+	gradientTop := color.RGBA{10, 10, 50, 255}
+	gradientBottom := color.RGBA{30, 30, 80, 255}
+
+	// 绘制背景渐变色
+	for y := 0; y < screenHeight; y++ {
+		// 计算该行的渐变颜色
+		ratio := float64(y) / float64(screenHeight)
+		r := uint8(float64(gradientTop.R) + ratio*float64(gradientBottom.R-gradientTop.R))
+		g := uint8(float64(gradientTop.G) + ratio*float64(gradientBottom.G-gradientTop.G))
+		b := uint8(float64(gradientTop.B) + ratio*float64(gradientBottom.B-gradientTop.B))
+		ebitenutil.DrawRect(screen, 0, float64(y), float64(screenWidth), 1, color.RGBA{r, g, b, 255})
+	}
+
+	// 绘制动态星星背景
+	for i, star := range ms.starPositions {
+		// 星星大小和亮度随时间变化
+		starSize := 1.0 + math.Sin(float64(ms.animTimer)/20.0+float64(i))*0.5
+		starBrightness := 150.0 + math.Sin(float64(ms.animTimer)/15.0+float64(i))*50.0
+
+		// 让星星闪烁
+		starColor := color.RGBA{255, 255, 255, uint8(starBrightness)}
+		ebitenutil.DrawRect(screen, star[0]*float64(screenWidth), star[1]*float64(screenHeight), starSize, starSize, starColor)
+	}
+
+	// 绘制标题
+	titleMsg := "飞机大战"
+	// 计算文本宽度以便居中显示
+	titleWidth := len([]rune(titleMsg)) * 36 // 估计每个汉字宽度约36像素
+	//titleX := screenWidth/2 - titleWidth/2
+	titleOptions := &ebiten.DrawImageOptions{}
+	titleWidth = len([]rune(titleMsg)) * 36 // 估计每个汉字宽度
+
+	titleImgWidth := titleWidth + 60 // 两侧各留30像素空间
+
+	// 设置标题的变换
+	titleOptions.GeoM.Translate(-float64(titleImgWidth/2), -30)      // 调整旋转中心点到图像中心
+	titleOptions.GeoM.Rotate(ms.titleRotation)                       // 应用旋转
+	titleOptions.GeoM.Scale(ms.titleScale, ms.titleScale)            // 应用缩放
+	titleOptions.GeoM.Translate(float64(screenWidth/2), ms.titleY()) // 移动到屏幕中心
+	titleOptions.ColorM.Scale(1, 1, 1, ms.titleAlpha)                // 应用透明度
+	titleOptions.ColorM.Scale(1, 1, 1, ms.titleAlpha)                // 应用透明度
+
+	// 创建一个临时图像来渲染标题
+	titleImg := ebiten.NewImage(titleImgWidth, 60)
+
+	// 绘制闪亮的标题背景
+	backgroundWidth := float64(titleImgWidth - 20) // 两侧各留10像素
+	ebitenutil.DrawRect(titleImg, 10, 10, backgroundWidth, 50, color.RGBA{0, 0, 150, 200})
+
+	// 添加标题光晕效果
+	glowSize := 5.0 + math.Sin(float64(ms.animTimer)/10.0)*2.0
+	ebitenutil.DrawRect(titleImg, 10-glowSize, 10-glowSize, backgroundWidth+glowSize*2, 50+glowSize*2, color.RGBA{100, 100, 255, 50})
+
+	// 直接使用已有的中文字体渲染标题
+	// 计算文本在图像中的X位置，使其居中
+	textX := titleImgWidth/2 - titleWidth/2
+	// 绘制阴影效果
+	text.Draw(titleImg, titleMsg, chineseFont, textX+2, 47, color.RGBA{0, 0, 80, 255})
+	// 主文字
+	text.Draw(titleImg, titleMsg, chineseFont, textX, 45, color.RGBA{255, 255, 255, 255})
+	// 添加发光效果
+	text.Draw(titleImg, titleMsg, chineseFont, textX-1, 44, color.RGBA{100, 200, 255, 150})
+
+	// 将标题绘制到屏幕
+	screen.DrawImage(titleImg, titleOptions)
+
+	// 使用透明度来控制菜单项的显示
+	menuAlpha := uint8(ms.menuItemsAlpha * 255)
+
+	// 绘制模式选择说明
+	modeTitle := "- 选择游戏模式 -"
+	modeTitleX := screenWidth/2 - 100
+	modeTitleY := int(ms.titleY()) + 120
+
+	// 模式选择背景带有呼吸效果
+	pulseEffect := 0.7 + math.Sin(float64(ms.animTimer)/20.0)*0.3
+	bgWidth := 240.0 * pulseEffect
+	ebitenutil.DrawRect(screen, float64(modeTitleX-20)-(bgWidth-200)/2, float64(modeTitleY-25), bgWidth, 35,
+		color.RGBA{0, 50, 150, menuAlpha})
+	text.Draw(screen, modeTitle, chineseFont, modeTitleX, modeTitleY,
+		color.RGBA{220, 220, 255, menuAlpha})
+
+	// 绘制模式选项，选中的选项高亮
+	for i, item := range menuItems {
+		itemX, itemY := ms.modeItemPos(i + 1)
+		x, y, w, h := ms.modeItemRect(i + 1)
+		bgColor := color.RGBA{0, 0, 100, menuAlpha}
+		if i+1 == ms.selected {
+			bgColor = color.RGBA{50, 50, 150, menuAlpha}
+		}
+		ebitenutil.DrawRect(screen, x, y, w, h, bgColor)
+		text.Draw(screen, item.label, chineseFont, itemX, itemY, color.RGBA{255, 255, 0, menuAlpha})
+		text.Draw(screen, item.desc, chineseFont, itemX+140, itemY, color.RGBA{255, 255, 255, menuAlpha})
+	}
+
+	// 绘制飞机小图标在当前选择的模式旁边，随菜单项一起淡入
+	planeOptions := &ebiten.DrawImageOptions{}
+	itemX, itemY := ms.modeItemPos(ms.selected)
+	// 让飞机小图标左右摆动
+	planeX := float64(itemX-40) + math.Sin(float64(ms.animTimer)/10.0)*5.0
+	planeY := float64(itemY - 15)
+	planeOptions.GeoM.Scale(0.6, 0.6) // 缩小图标
+	planeOptions.GeoM.Translate(planeX, planeY)
+	planeOptions.ColorScale.ScaleAlpha(float32(ms.menuItemsAlpha))
+	screen.DrawImage(spriteIcon("player"), planeOptions)
+
+	// 绘制操作提示
+	hint := "方向键或数字键选择模式"
+	hintX := screenWidth/2 - 140
+	_, lastY := ms.modeItemPos(len(menuItems))
+	hintY := lastY + 45
+
+	// 提示背景带有呼吸效果
+	hintPulse := 0.8 + math.Sin(float64(ms.animTimer+30)/20.0)*0.2
+	hintBgWidth := 280.0 * hintPulse
+	ebitenutil.DrawRect(screen, float64(hintX-20)-(hintBgWidth-280)/2, float64(hintY-25), hintBgWidth, 35,
+		color.RGBA{0, 0, 100, uint8(float64(menuAlpha) * 0.7)})
+	text.Draw(screen, hint, chineseFont, hintX, hintY,
+		color.RGBA{180, 180, 255, menuAlpha})
+
+	// 添加版本信息和作者信息
+	versionText := "版本: v1.0.0"
+	authorText := "© 2025 飞机大战开发团队"
+	text.Draw(screen, versionText, chineseFont, 10, screenHeight-30,
+		color.RGBA{200, 200, 200, menuAlpha})
+	text.Draw(screen, authorText, chineseFont, screenWidth-240, screenHeight-30,
+		color.RGBA{200, 200, 200, menuAlpha})
+}
@@ -0,0 +1,281 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"go-play-plane/internal/sim"
+	"golang.org/x/image/font"
+)
+
+// 设置界面的布局
+const (
+	optionRowHeight  = 20  // 每行的高度
+	optionRowWidth   = 400 // 每行的宽度
+	optionValueWidth = 150 // 行右侧显示当前值的宽度
+)
+
+// 每次调整音量和手柄死区的幅度
+const (
+	volumeStep   = 10
+	deadzoneStep = 5
+)
+
+// playfieldLabels 场地类型在设置界面中显示的名称
+var playfieldLabels = [...]string{
+	sim.FieldStandard: "标准（4:3）",
+	sim.FieldVertical: "纵版（3:4）",
+}
+
+// optionItem 设置界面中的一行。有value的行显示当前值，按左右键或确认时用change修改；
+// 没有value的行是按钮，确认时执行action
+type optionItem struct {
+	label  string
+	value  func(s *Settings) string
+	change func(s *Settings, dir int) // dir为-1或1
+	action func(o *OptionsScene, g *Game)
+}
+
+// cycle 在n个选项中从i向dir方向移动一项，到头后回到另一端
+func cycle(i, n, dir int) int {
+	return ((i+dir)%n + n) % n
+}
+
+// onOff 返回开关的显示文字
+func onOff(b bool) string {
+	if b {
+		return "开"
+	}
+	return "关"
+}
+
+// toggleItem 创建一个开关设置项
+func toggleItem(label string, field func(s *Settings) *bool) optionItem {
+	return optionItem{
+		label:  label,
+		value:  func(s *Settings) string { return onOff(*field(s)) },
+		change: func(s *Settings, dir int) { *field(s) = !*field(s) },
+	}
+}
+
+// volumeItem 创建一个音量设置项
+func volumeItem(label string, field func(s *Settings) *int) optionItem {
+	return optionItem{
+		label: label,
+		value: func(s *Settings) string { return fmt.Sprintf("%d%%", *field(s)) },
+		change: func(s *Settings, dir int) {
+			*field(s) = max(0, min(100, *field(s)+dir*volumeStep))
+		},
+	}
+}
+
+// optionItems 设置界面中的所有设置项，按显示顺序排列
+var optionItems = []optionItem{
+	{
+		label: "内部分辨率",
+		value: func(s *Settings) string { return s.Resolution },
+		change: func(s *Settings, dir int) {
+			i, _ := findResolution(s.Resolution)
+			s.Resolution = resolutions[cycle(i, len(resolutions), dir)].name
+		},
+	},
+	{
+		label: "场地",
+		value: func(s *Settings) string {
+			kind, _ := sim.FindPlayfield(s.Playfield)
+			return playfieldLabels[kind]
+		},
+		change: func(s *Settings, dir int) {
+			kind, _ := sim.FindPlayfield(s.Playfield)
+			s.Playfield = sim.PlayfieldNames[cycle(int(kind), len(sim.PlayfieldNames), dir)]
+		},
+	},
+	toggleItem("整数倍缩放", func(s *Settings) *bool { return &s.PixelPerfect }),
+	{
+		label: "窗口大小",
+		value: func(s *Settings) string { return fmt.Sprintf("%d倍", s.WindowScale) },
+		change: func(s *Settings, dir int) {
+			s.WindowScale = cycle(s.WindowScale-1, maxWindowScale, dir) + 1
+		},
+	},
+	toggleItem("全屏", func(s *Settings) *bool { return &s.Fullscreen }),
+	toggleItem("垂直同步", func(s *Settings) *bool { return &s.VSync }),
+	volumeItem("总音量", func(s *Settings) *int { return &s.MasterVolume }),
+	volumeItem("音乐音量", func(s *Settings) *int { return &s.MusicVolume }),
+	volumeItem("音效音量", func(s *Settings) *int { return &s.SoundVolume }),
+	{
+		label: "手柄摇杆死区",
+		value: func(s *Settings) string { return fmt.Sprintf("%d%%", s.GamepadDeadzone) },
+		change: func(s *Settings, dir int) {
+			s.GamepadDeadzone = max(0, min(maxGamepadDeadzone, s.GamepadDeadzone+dir*deadzoneStep))
+		},
+	},
+	{
+		label: "语言",
+		value: func(s *Settings) string {
+			i, _ := findLanguage(s.Language)
+			return languages[i].name
+		},
+		change: func(s *Settings, dir int) {
+			i, _ := findLanguage(s.Language)
+			s.Language = languages[cycle(i, len(languages), dir)].code
+		},
+	},
+	toggleItem("鼠标拖动操作", func(s *Settings) *bool { return &s.MouseFollow }),
+	toggleItem("减少闪烁", func(s *Settings) *bool { return &s.ReduceFlashing }),
+	toggleItem("高对比度子弹", func(s *Settings) *bool { return &s.HighContrastBullets }),
+	{
+		label:  "键位设置",
+		action: func(o *OptionsScene, g *Game) { g.scenes.Push(g, NewControlsScene(&o.draft)) },
+	},
+	{
+		label:  "恢复默认设置",
+		action: func(o *OptionsScene, g *Game) { o.draft = defaultSettings() },
+	},
+	{
+		label:  "保存并返回",
+		action: (*OptionsScene).save,
+	},
+	{
+		label:  "放弃修改并返回",
+		action: func(o *OptionsScene, g *Game) { g.scenes.Pop(g) },
+	},
+}
+
+// OptionsScene 设置界面，覆盖在主菜单之上。修改只作用于草稿，保存后才生效并写入设置文件
+type OptionsScene struct {
+	overlay
+	draft    Settings // 正在编辑的设置
+	selected int      // 当前选中的行
+	nav      menuNav  // 方向键和摇杆的导航状态
+	pressed  bool     // 上一帧是否按住确认或鼠标，只在按下的瞬间响应
+	message  string   // 保存失败等提示信息
+}
+
+// NewOptionsScene 创建设置界面，以当前设置为草稿
+func NewOptionsScene() *OptionsScene {
+	return &OptionsScene{draft: settings.clone()}
+}
+
+// Enter 进入设置界面
+func (o *OptionsScene) Enter(g *Game) {}
+
+// Exit 离开设置界面
+func (o *OptionsScene) Exit(g *Game) {}
+
+// rowY 返回第i行的顶部位置，所有行在画面中垂直居中
+func (o *OptionsScene) rowY(i int) int {
+	top := (screenHeight-len(optionItems)*optionRowHeight)/2 + 20
+	return top + i*optionRowHeight
+}
+
+// rowRect 返回第i行的区域，绘制和点击检测共用
+func (o *OptionsScene) rowRect(i int) (x, y, width, height float64) {
+	return float64(screenWidth/2 - optionRowWidth/2), float64(o.rowY(i)), optionRowWidth, optionRowHeight - 2
+}
+
+// activate 确认第i行：按钮执行动作，设置项切换到下一个值
+func (o *OptionsScene) activate(g *Game, i int) {
+	item := optionItems[i]
+	if item.action != nil {
+		item.action(o, g)
+		return
+	}
+	item.change(&o.draft, 1)
+}
+
+// save 应用草稿并写入设置文件，成功后返回上一级
+func (o *OptionsScene) save(g *Game) {
+	applySettings(o.draft)
+	if g.settingsPath == "" {
+		g.scenes.Pop(g)
+		return
+	}
+	if err := o.draft.Save(g.settingsPath); err != nil {
+		log.Printf("保存设置失败: %v", err)
+		o.message = "保存设置失败，设置只在本次运行中生效"
+		return
+	}
+	g.scenes.Pop(g)
+}
+
+// Update 处理选择和修改设置
+func (o *OptionsScene) Update(g *Game, in sim.InputState) error {
+	// 上下键选择，左右键修改当前设置项
+	dx, dy := o.nav.update(in)
+	if dy != 0 {
+		o.selected = cycle(o.selected, len(optionItems), dy)
+	}
+	if item := optionItems[o.selected]; dx != 0 && item.change != nil {
+		item.change(&o.draft, dx)
+	}
+
+	// 确认键或鼠标点击，只在按下的瞬间响应一次
+	pressed := in.Confirm || in.Click
+	if pressed && !o.pressed {
+		if in.Click {
+			for i := range optionItems {
+				if x, y, w, h := o.rowRect(i); checkCursorInArea(in, x, y, w, h) {
+					o.selected = i
+					o.activate(g, i)
+					break
+				}
+			}
+		} else {
+			o.activate(g, o.selected)
+		}
+	}
+	o.pressed = pressed
+
+	// ESC键放弃修改并返回
+	if in.Back && g.scenes.Top() == o {
+		g.scenes.Pop(g)
+	}
+	return nil
+}
+
+// Draw 绘制设置界面
+func (o *OptionsScene) Draw(g *Game, screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 0, 0, float64(screenWidth), float64(screenHeight), color.RGBA{0, 0, 30, 220})
+
+	// 标题
+	title := "设置"
+	titleX := screenWidth/2 - font.MeasureString(chineseFont, title).Round()/2
+	text.Draw(screen, title, chineseFont, titleX, o.rowY(0)-20, color.RGBA{255, 255, 255, 255})
+
+	for i, item := range optionItems {
+		x, y, w, h := o.rowRect(i)
+		bgColor := color.RGBA{0, 0, 100, 150}
+		if i == o.selected {
+			bgColor = color.RGBA{50, 50, 150, 220}
+		} else if checkCursorInArea(g.lastInput, x, y, w, h) {
+			bgColor = color.RGBA{30, 30, 130, 200}
+		}
+		ebitenutil.DrawRect(screen, x, y, w, h, bgColor)
+
+		baseline := int(y) + 14
+		labelColor := color.RGBA{255, 255, 255, 255}
+		if item.value == nil {
+			labelColor = color.RGBA{255, 255, 0, 255}
+		}
+		text.Draw(screen, item.label, smallFont, int(x)+15, baseline, labelColor)
+		if item.value != nil {
+			value := "< " + item.value(&o.draft) + " >"
+			text.Draw(screen, value, smallFont, int(x+w)-optionValueWidth, baseline, color.RGBA{180, 220, 255, 255})
+		}
+	}
+
+	// 操作提示或错误信息
+	hint := "上下键选择，左右键修改，确认键执行，ESC放弃修改"
+	hintColor := color.RGBA{180, 180, 255, 255}
+	if o.message != "" {
+		hint = o.message
+		hintColor = color.RGBA{255, 100, 100, 255}
+	}
+	hintX := screenWidth/2 - font.MeasureString(smallFont, hint).Round()/2
+	text.Draw(screen, hint, smallFont, hintX, o.rowY(len(optionItems))+20, hintColor)
+}
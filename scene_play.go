@@ -0,0 +1,432 @@
+package main
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/text"
+	"go-play-plane/internal/sim"
+)
+
+// PlayScene 对局场景，负责推进模拟、录像和回放校验
+type PlayScene struct {
+	sim      *sim.Simulation     // 当前对局的模拟状态
+	recorder *sim.ReplayRecorder // 当前对局的录像记录器
+	replay   *sim.ReplayInput    // 正在回放的录像
+	desynced bool                // 回放是否已检测到不同步
+	field    *ebiten.Image       // 按场地大小绘制的对局画面
+	follow   followControl       // 触摸和鼠标拖动操作
+	// 当前关卡开始时的分数和帧数，用于计算本关的得分和通关用时
+	levelScore int
+	levelTick  int
+}
+
+// NewPlayScene 创建对局场景
+func NewPlayScene(state *sim.Simulation) *PlayScene {
+	ps := &PlayScene{}
+	ps.begin(state)
+	return ps
+}
+
+// NewReplayScene 创建回放录像的对局场景，ls为录像使用的关卡包，录像播放完毕后由实时输入接管
+func NewReplayScene(replay *sim.Replay, ls *sim.LevelSet) *PlayScene {
+	ps := &PlayScene{}
+	ps.begin(replay.NewSimulation(ls))
+	ps.replay = sim.NewReplayInput(replay)
+	return ps
+}
+
+// begin 切换到一局新的对局，并在需要时开始录像
+func (ps *PlayScene) begin(state *sim.Simulation) {
+	ps.finishRecording()
+	ps.sim = state
+	ps.replay = nil
+	ps.desynced = false
+	ps.follow = followControl{}
+	ps.levelScore = 0
+	ps.levelTick = 0
+	if *recordFlag != "" {
+		ps.recorder = sim.NewReplayRecorder(state)
+	}
+}
+
+// Restart 以新的随机种子重新开始当前模式和关卡
+func (ps *PlayScene) Restart() {
+	ps.begin(sim.NewSimulation(ps.sim.Levels, ps.sim.Field.Kind, ps.sim.Mode, ps.sim.CurrentLevel, newSeed()))
+}
+
+// finishRecording 保存当前对局的录像
+func (ps *PlayScene) finishRecording() {
+	if ps.recorder == nil {
+		return
+	}
+	replay := ps.recorder.Replay()
+	ps.recorder = nil
+	if replay.Len() == 0 {
+		return
+	}
+	name := fmt.Sprintf("replay_%s_%d.gpr", time.Now().Format("20060102_150405"), replay.Seed())
+	path := filepath.Join(*recordFlag, name)
+	if err := os.MkdirAll(*recordFlag, 0o755); err != nil {
+		log.Printf("创建录像目录失败: %v", err)
+		return
+	}
+	if err := replay.Save(path); err != nil {
+		log.Printf("保存录像失败: %v", err)
+		return
+	}
+	log.Printf("录像已保存: %s", path)
+}
+
+// Enter 进入或返回对局
+func (ps *PlayScene) Enter(g *Game) {}
+
+// Music 关卡模式播放当前关卡的音乐，无尽模式播放无尽模式的音乐
+func (ps *PlayScene) Music() string {
+	if ps.sim.Mode == sim.ModeEndless {
+		return sim.MusicEndless
+	}
+	return ps.sim.Levels.Level(ps.sim.CurrentLevel).Music
+}
+
+// Exit 离开对局时保存尚未写出的录像
+func (ps *PlayScene) Exit(g *Game) {
+	ps.finishRecording()
+}
+
+// Update 以固定步长推进模拟
+func (ps *PlayScene) Update(g *Game, in sim.InputState) error {
+	// 暂停不计入录像，回放期间也可以暂停
+	if in.Pause {
+		g.scenes.Push(g, NewPauseScene())
+		return nil
+	}
+
+	// 拖动操作转换为普通的移动和开火输入
+	in = ps.follow.apply(in, ps.sim)
+
+	// 回放时使用录像中的输入，播放完毕后由实时输入接管
+	if ps.replay != nil && !ps.replay.Done() {
+		in = ps.replay.Poll()
+	}
+
+	// 录像时记录本帧输入，并使用量化后的输入保证回放一致
+	if ps.recorder != nil {
+		in = ps.recorder.Record(in)
+	}
+
+	level, cleared := ps.sim.CurrentLevel, ps.sim.AllCleared
+	ps.sim.Step(in)
+	g.audio.PlayAll(ps.sim.Sounds)
+
+	// 击败BOSS后模拟直接切换到下一关或通关，在这里记录被击败的关卡
+	if ps.sim.CurrentLevel != level || ps.sim.AllCleared != cleared {
+		ps.recordLevel(g, level, true)
+	}
+
+	if ps.recorder != nil {
+		ps.recorder.Checkpoint(ps.sim)
+	}
+	if ps.replay != nil && !ps.desynced {
+		if err := ps.replay.Verify(ps.sim); err != nil {
+			log.Print(err)
+			ps.desynced = true
+		}
+	}
+
+	if ps.sim.IsGameOver {
+		ps.finishRecording()
+		ps.recordLevel(g, ps.sim.CurrentLevel, false)
+		g.scenes.Push(g, NewGameOverScene(ps))
+	} else if ps.sim.AllCleared {
+		ps.finishRecording()
+		g.scenes.Push(g, NewResultsScene(ps))
+	}
+	return nil
+}
+
+// recordLevel 记录关卡模式下第level关的成绩并保存进度，cleared表示是否击败了本关BOSS。
+// 回放的对局不计入进度
+func (ps *PlayScene) recordLevel(g *Game, level int, cleared bool) {
+	state := ps.sim
+	score, ticks := state.Score-ps.levelScore, state.Tick-ps.levelTick
+	ps.levelScore, ps.levelTick = state.Score, state.Tick
+	if state.Mode != sim.ModePlaying || ps.replay != nil {
+		return
+	}
+	if !progress.AddResult(levelKey(state.Levels, state.Levels.Level(level)), score, ticks, cleared) {
+		return
+	}
+	if g.progressPath == "" {
+		return
+	}
+	if err := progress.Save(g.progressPath); err != nil {
+		log.Printf("保存进度失败: %v", err)
+	}
+}
+
+// Draw 在场地画面上绘制对局，再把场地缩放到画面中并绘制HUD
+func (ps *PlayScene) Draw(g *Game, screen *ebiten.Image) {
+	state := ps.sim
+	if ps.field == nil || ps.field.Bounds().Dx() != state.Field.Width || ps.field.Bounds().Dy() != state.Field.Height {
+		ps.field = ebiten.NewImage(state.Field.Width, state.Field.Height)
+	}
+	ps.field.Clear()
+	ps.drawField(ps.field)
+
+	// 场地以外的区域用深色填充，与场地区分开
+	layout := newPlayLayout(state.Field)
+	screen.Fill(color.RGBA{20, 20, 40, 255})
+	drawView(screen, ps.field, layout.field)
+	if !layout.panel.Empty() {
+		panel := layout.panel
+		ebitenutil.DrawRect(screen, float64(panel.Min.X), float64(panel.Min.Y), float64(panel.Dx()), float64(panel.Dy()), color.RGBA{0, 0, 60, 255})
+	}
+	ps.drawHUD(screen, layout)
+}
+
+// drawField 按场地坐标绘制所有实体
+func (ps *PlayScene) drawField(screen *ebiten.Image) {
+	state := ps.sim
+
+	// 绘制玩家，被击毁后只显示爆炸效果
+	if !state.IsGameOver {
+		drawPlayer(screen, state.Player)
+	}
+
+	// 只有在BOSS没有出现时才绘制普通敌机
+	if !state.BossActive {
+		// 绘制敌机
+		drawEnemies(screen, state.EnemyManager)
+	} else if state.Boss != nil && state.Boss.Active() {
+		// 绘制BOSS
+		drawBoss(screen, state.Boss)
+	}
+
+	// 绘制子弹
+	drawBullets(screen, state.BulletManager)
+
+	// 绘制敌机子弹
+	drawEnemyBullets(screen, state.EnemyBulletManager)
+
+	// 绘制道具
+	drawPowerUps(screen, state.PowerUpManager)
+
+	// 绘制爆炸等视觉效果
+	drawEffects(screen, state.EffectManager)
+
+	// 使用炸弹后整个场地闪白并逐渐消失，开启减少闪烁时不闪光
+	if state.BombFlash > 0 && !settings.ReduceFlashing {
+		alpha := uint8(160 * state.BombFlash / sim.BombFlashTicks)
+		ebitenutil.DrawRect(screen, 0, 0, float64(state.Field.Width), float64(state.Field.Height), color.RGBA{alpha, alpha, alpha, alpha})
+	}
+}
+
+// drawHUD 在侧边栏或场地左上角绘制分数和关卡信息
+func (ps *PlayScene) drawHUD(screen *ebiten.Image, layout playLayout) {
+	state := ps.sim
+	hudX, hudY := layout.hudOrigin()
+
+	// 绘制分数
+	scoreText := fmt.Sprintf("得分: %d", state.Score)
+	scoreX := hudX
+	scoreY := hudY
+	// 分数背景
+	ebitenutil.DrawRect(screen, float64(scoreX-10), float64(scoreY-25), 150, 35, color.RGBA{0, 0, 100, 150})
+	text.Draw(screen, scoreText, chineseFont, scoreX, scoreY, color.RGBA{255, 255, 0, 255})
+
+	// 在关卡模式下显示当前关卡和目标分数
+	if state.Mode == sim.ModePlaying {
+		levelText := fmt.Sprintf("当前关卡: %d", state.CurrentLevel)
+		levelX := hudX
+		levelY := hudY + 40
+		// 关卡背景
+		ebitenutil.DrawRect(screen, float64(levelX-10), float64(levelY-25), 150, 35, color.RGBA{0, 0, 100, 150})
+		text.Draw(screen, levelText, chineseFont, levelX, levelY, color.RGBA{255, 255, 0, 255})
+
+		targetText := fmt.Sprintf("目标分数: %d", state.TargetScore)
+		targetX := hudX
+		targetY := hudY + 80
+		// 目标分数背景
+		ebitenutil.DrawRect(screen, float64(targetX-10), float64(targetY-25), 150, 35, color.RGBA{0, 0, 100, 150})
+		text.Draw(screen, targetText, chineseFont, targetX, targetY, color.RGBA{255, 255, 0, 255})
+	}
+
+	// 绘制剩余炸弹数，显示在其他信息下方
+	bombText := fmt.Sprintf("炸弹: %d", state.Player.Bombs)
+	bombX := hudX
+	bombY := hudY + 40
+	if state.Mode == sim.ModePlaying {
+		bombY = hudY + 120
+	}
+	ebitenutil.DrawRect(screen, float64(bombX-10), float64(bombY-25), 150, 35, color.RGBA{0, 0, 100, 150})
+	text.Draw(screen, bombText, chineseFont, bombX, bombY, color.RGBA{255, 255, 0, 255})
+}
+
+// PauseScene 暂停场景，覆盖在对局之上
+type PauseScene struct {
+	overlay
+}
+
+// NewPauseScene 创建暂停场景
+func NewPauseScene() *PauseScene {
+	return &PauseScene{}
+}
+
+// Enter 进入暂停
+func (s *PauseScene) Enter(g *Game) {}
+
+// Exit 退出暂停
+func (s *PauseScene) Exit(g *Game) {}
+
+// Update 再次按下暂停或返回键，或者点击画面时继续游戏
+func (s *PauseScene) Update(g *Game, in sim.InputState) error {
+	if in.Pause || in.Back || in.Click {
+		g.scenes.Pop(g)
+	}
+	return nil
+}
+
+// Draw 绘制暂停提示
+func (s *PauseScene) Draw(g *Game, screen *ebiten.Image) {
+	ebitenutil.DrawRect(screen, 0, 0, float64(screenWidth), float64(screenHeight), color.RGBA{0, 0, 0, 120})
+	pauseMsg := fmt.Sprintf("暂停中，按%s继续", bindingLabel(ActionPause))
+	pauseX := screenWidth/2 - 100
+	pauseY := screenHeight / 2
+	ebitenutil.DrawRect(screen, float64(pauseX-20), float64(pauseY-30), 240, 45, color.RGBA{0, 0, 100, 200})
+	text.Draw(screen, pauseMsg, chineseFont, pauseX, pauseY, color.RGBA{255, 255, 255, 255})
+}
+
+// GameOverScene 游戏结束场景，覆盖在对局之上
+type GameOverScene struct {
+	overlay
+	play *PlayScene // 结束的对局
+}
+
+// NewGameOverScene 创建游戏结束场景
+func NewGameOverScene(play *PlayScene) *GameOverScene {
+	return &GameOverScene{play: play}
+}
+
+// Enter 进入游戏结束画面
+func (gs *GameOverScene) Enter(g *Game) {}
+
+// Exit 离开游戏结束画面
+func (gs *GameOverScene) Exit(g *Game) {}
+
+// Music 游戏结束时停止音乐
+func (gs *GameOverScene) Music() string {
+	return ""
+}
+
+// Update 处理重新开始或返回菜单的输入
+func (gs *GameOverScene) Update(g *Game, in sim.InputState) error {
+	// 按R键或点击画面重新开始当前模式
+	if in.Restart || in.Click {
+		g.scenes.Pop(g)
+		gs.play.Restart()
+		return nil
+	}
+	// 按ESC键返回上一级菜单：关卡模式返回关卡选择，无尽模式返回主菜单
+	if in.Back {
+		g.scenes.Pop(g)
+		g.scenes.Pop(g)
+	}
+	return nil
+}
+
+// Draw 绘制游戏结束信息
+func (gs *GameOverScene) Draw(g *Game, screen *ebiten.Image) {
+	// 绘制半透明背景
+	ebitenutil.DrawRect(screen, 0, 0, float64(screenWidth), float64(screenHeight), color.RGBA{0, 0, 0, 180})
+
+	// 绘制游戏结束标题
+	gameOverMsg := "游戏结束！"
+	gameOverX := screenWidth/2 - 80
+	gameOverY := screenHeight/2 - 50
+	// 标题背景
+	ebitenutil.DrawRect(screen, float64(gameOverX-20), float64(gameOverY-35), 200, 45, color.RGBA{0, 0, 100, 200})
+	text.Draw(screen, gameOverMsg, chineseFont, gameOverX, gameOverY, color.RGBA{255, 50, 50, 255})
+
+	// 绘制最终得分
+	scoreMsg := fmt.Sprintf("最终得分：%d", gs.play.sim.Score)
+	scoreX := screenWidth/2 - 80
+	scoreY := screenHeight / 2
+	// 得分背景
+	ebitenutil.DrawRect(screen, float64(scoreX-20), float64(scoreY-25), 200, 35, color.RGBA{0, 0, 100, 150})
+	text.Draw(screen, scoreMsg, chineseFont, scoreX, scoreY, color.RGBA{255, 255, 0, 255})
+
+	// 绘制操作提示
+	restartMsg := fmt.Sprintf("按%s或点击重新开始", bindingLabel(ActionRestart))
+	restartX := screenWidth/2 - 150
+	restartY := screenHeight/2 + 50
+	menuMsg := fmt.Sprintf("按%s返回模式选择", bindingLabel(ActionBack))
+	menuX := screenWidth/2 - 100
+	menuY := screenHeight/2 + 90
+
+	// 提示背景
+	ebitenutil.DrawRect(screen, float64(restartX-10), float64(restartY-25), 320, 35, color.RGBA{0, 0, 100, 150})
+	ebitenutil.DrawRect(screen, float64(menuX-10), float64(menuY-25), 220, 35, color.RGBA{0, 0, 100, 150})
+
+	text.Draw(screen, restartMsg, chineseFont, restartX, restartY, color.RGBA{200, 200, 255, 255})
+	text.Draw(screen, menuMsg, chineseFont, menuX, menuY, color.RGBA{200, 200, 255, 255})
+}
+
+// ResultsScene 通关结算场景，覆盖在对局之上
+type ResultsScene struct {
+	overlay
+	play *PlayScene // 通关的对局
+}
+
+// NewResultsScene 创建通关结算场景
+func NewResultsScene(play *PlayScene) *ResultsScene {
+	return &ResultsScene{play: play}
+}
+
+// Enter 进入结算画面
+func (rs *ResultsScene) Enter(g *Game) {}
+
+// Exit 离开结算画面
+func (rs *ResultsScene) Exit(g *Game) {}
+
+// Update 确认或返回后回到关卡选择
+func (rs *ResultsScene) Update(g *Game, in sim.InputState) error {
+	if in.Confirm || in.Back || in.Click {
+		g.scenes.Pop(g)
+		g.scenes.Pop(g)
+	}
+	return nil
+}
+
+// Draw 绘制通关结算信息
+func (rs *ResultsScene) Draw(g *Game, screen *ebiten.Image) {
+	// 绘制半透明背景
+	ebitenutil.DrawRect(screen, 0, 0, float64(screenWidth), float64(screenHeight), color.RGBA{0, 0, 0, 180})
+
+	// 绘制通关标题
+	clearMsg := "恭喜通关！"
+	clearX := screenWidth/2 - 80
+	clearY := screenHeight/2 - 50
+	ebitenutil.DrawRect(screen, float64(clearX-20), float64(clearY-35), 200, 45, color.RGBA{0, 0, 100, 200})
+	text.Draw(screen, clearMsg, chineseFont, clearX, clearY, color.RGBA{255, 215, 0, 255})
+
+	// 绘制最终得分
+	scoreMsg := fmt.Sprintf("最终得分：%d", rs.play.sim.Score)
+	scoreX := screenWidth/2 - 80
+	scoreY := screenHeight / 2
+	ebitenutil.DrawRect(screen, float64(scoreX-20), float64(scoreY-25), 200, 35, color.RGBA{0, 0, 100, 150})
+	text.Draw(screen, scoreMsg, chineseFont, scoreX, scoreY, color.RGBA{255, 255, 0, 255})
+
+	// 绘制操作提示
+	hintMsg := fmt.Sprintf("按%s返回关卡选择", bindingLabel(ActionConfirm))
+	hintX := screenWidth/2 - 120
+	hintY := screenHeight/2 + 50
+	ebitenutil.DrawRect(screen, float64(hintX-10), float64(hintY-25), 260, 35, color.RGBA{0, 0, 100, 150})
+	text.Draw(screen, hintMsg, chineseFont, hintX, hintY, color.RGBA{200, 200, 255, 255})
+}
@@ -168,11 +168,9 @@ build_macos() {
     cp "dist/${GAME_NAME}_macos" "${MACOS_DIR}/${GAME_NAME}"
     chmod +x "${MACOS_DIR}/${GAME_NAME}"
 
-    # 创建图标文件（如果存在player.png，使用它作为图标）
-    if [ -f "resources/images/player.png" ]; then
-        cp "resources/images/player.png" "${RESOURCES_DIR}/icon.png"
-    elif [ -f "resources/images/enemy.png" ]; then
-        cp "resources/images/enemy.png" "${RESOURCES_DIR}/icon.png"
+    # 创建图标文件（如果存在icon.png，使用它作为图标）
+    if [ -f "resources/icon.png" ]; then
+        cp "resources/icon.png" "${RESOURCES_DIR}/icon.png"
     fi
 
     # 创建Info.plist文件
@@ -40,9 +40,9 @@ if not "%PUREGO_VERSION%"=="v0.8.3" (
 if not exist dist mkdir dist
 
 :: 游戏图标
-set ICON_PATH=resources\images\player.png
+set ICON_PATH=resources\icon.png
 if not exist "%ICON_PATH%" (
-    echo 警告: 未找到玩家图标，将使用默认图标
+    echo 警告: 未找到游戏图标，将使用默认图标
     set ICON_PATH=
 )
 
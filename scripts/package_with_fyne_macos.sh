@@ -54,9 +54,9 @@ fi
 mkdir -p dist
 
 # 游戏图标
-ICON_PATH="resources/images/player.png"
+ICON_PATH="resources/icon.png"
 if [ ! -f "$ICON_PATH" ]; then
-    echo -e "${YELLOW}警告: 未找到玩家图标，将使用默认图标${NC}"
+    echo -e "${YELLOW}警告: 未找到游戏图标，将使用默认图标${NC}"
     ICON_PATH=""
 fi
 
@@ -0,0 +1,381 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/jsondata"
+	"go-play-plane/internal/sim"
+)
+
+// settingsVersion 设置文件的当前版本，格式不兼容地改变时递增
+const settingsVersion = 1
+
+// 设置文件在用户配置目录中的位置
+const (
+	settingsDirName  = "go-play-plane"
+	settingsFileName = "settings.json"
+)
+
+// maxWindowScale 窗口倍数的上限
+const maxWindowScale = 4
+
+// Language 表示一种界面语言
+type Language struct {
+	code string // 设置文件中的语言代码
+	name string // 在设置界面中显示的名称
+}
+
+// languages 可选的界面语言
+var languages = []Language{
+	{"zh-CN", "简体中文"},
+}
+
+// findLanguage 按语言代码查找界面语言，返回其在languages中的下标
+func findLanguage(code string) (int, bool) {
+	for i, l := range languages {
+		if l.code == code {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// Settings 玩家设置，以JSON格式保存在用户配置目录中，启动时读取
+type Settings struct {
+	Version int `json:"version"` // 设置文件版本
+	// 画面
+	Resolution   string `json:"resolution"`   // 内部分辨率名称，见resolutions
+	Playfield    string `json:"playfield"`    // 场地类型名称，见sim.PlayfieldNames
+	PixelPerfect bool   `json:"pixelPerfect"` // 只按整数倍缩放
+	WindowScale  int    `json:"windowScale"`  // 窗口相对内部分辨率的倍数
+	Fullscreen   bool   `json:"fullscreen"`   // 全屏
+	VSync        bool   `json:"vsync"`        // 垂直同步
+	// 音量，0到100
+	MasterVolume int `json:"masterVolume"` // 总音量
+	MusicVolume  int `json:"musicVolume"`  // 音乐音量
+	SoundVolume  int `json:"soundVolume"`  // 音效音量
+	// 界面语言代码，见languages
+	Language string `json:"language"`
+	// 键位，操作名称到按键名称列表的映射。键盘按键名称与ebiten.Key的文本形式相同，
+	// 手柄按钮名称见padButtonNames
+	KeyBindings map[string][]string `json:"keyBindings"`
+	PadBindings map[string][]string `json:"padBindings"`
+	// 手柄摇杆死区（百分比），padDeadzones按手柄型号的SDL GUID单独指定，其余手柄使用gamepadDeadzone
+	GamepadDeadzone int            `json:"gamepadDeadzone"`
+	PadDeadzones    map[string]int `json:"padDeadzones"`
+	// 按住鼠标左键拖动飞机并自动开火，触摸屏总是使用拖动操作
+	MouseFollow bool `json:"mouseFollow"`
+	// 辅助功能
+	ReduceFlashing      bool `json:"reduceFlashing"`      // 关闭受击闪烁和BOSS闪烁
+	HighContrastBullets bool `json:"highContrastBullets"` // 敌机子弹加白色描边
+}
+
+// settings 当前生效的设置，修改后调用applySettings生效
+var settings = defaultSettings()
+
+// defaultSettings 返回默认设置
+func defaultSettings() Settings {
+	return Settings{
+		Version:         settingsVersion,
+		Resolution:      resolutions[0].name,
+		Playfield:       sim.PlayfieldNames[sim.FieldStandard],
+		WindowScale:     1,
+		VSync:           true,
+		MasterVolume:    100,
+		MusicVolume:     80,
+		SoundVolume:     80,
+		Language:        languages[0].code,
+		KeyBindings:     defaultBindings.keyNames(),
+		PadBindings:     defaultBindings.buttonNames(),
+		GamepadDeadzone: defaultGamepadDeadzone,
+		PadDeadzones:    map[string]int{},
+	}
+}
+
+// keyNames 将键盘键位转换为设置文件中的形式
+func (b *Bindings) keyNames() map[string][]string {
+	names := make(map[string][]string, actionCount)
+	for a, keys := range b.keys {
+		list := make([]string, 0, len(keys))
+		for _, key := range keys {
+			list = append(list, key.String())
+		}
+		names[actionNames[a]] = list
+	}
+	return names
+}
+
+// buttonNames 将手柄键位转换为设置文件中的形式
+func (b *Bindings) buttonNames() map[string][]string {
+	names := make(map[string][]string, actionCount)
+	for a, buttons := range b.buttons {
+		list := make([]string, 0, len(buttons))
+		for _, button := range buttons {
+			list = append(list, padButtonNames[button])
+		}
+		names[actionNames[a]] = list
+	}
+	return names
+}
+
+// parseKeyName 解析键盘按键名称，返回规范的名称
+func parseKeyName(name string) (string, bool) {
+	var k ebiten.Key
+	if err := k.UnmarshalText([]byte(name)); err != nil {
+		return "", false
+	}
+	return k.String(), true
+}
+
+// parseButtonName 解析手柄按钮名称，返回规范的名称
+func parseButtonName(name string) (string, bool) {
+	b, ok := findPadButton(name)
+	if !ok {
+		return "", false
+	}
+	return padButtonNames[b], true
+}
+
+// settingsPath 返回设置文件的路径
+func settingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, settingsDirName, settingsFileName), nil
+}
+
+// LoadSettings 读取设置文件，文件不存在或无法使用时使用默认设置，见loadJSONFile；
+// 个别设置项无效时只有该项恢复默认值。第二个返回值表示之后能否把设置保存回path
+func LoadSettings(path string) (Settings, bool) {
+	// 在默认设置上解析，文件中省略的设置项保持默认值
+	s := defaultSettings()
+	if loaded, ok := loadJSONFile(path, "设置文件", &s, &s.Version, settingsVersion); !loaded {
+		return defaultSettings(), ok
+	}
+	for _, err := range s.sanitize() {
+		log.Printf("设置文件%s: %v", path, err)
+	}
+	// 键位冲突只提示，由玩家在键位设置中修改
+	b := s.toBindings()
+	for _, c := range b.Conflicts() {
+		log.Printf("设置文件%s: 键位冲突: %v", path, c)
+	}
+	s.Version = settingsVersion
+	return s, true
+}
+
+// sanitize 将无效的设置项恢复为默认值，返回每个被恢复的设置项对应的错误
+func (s *Settings) sanitize() []error {
+	var errs []error
+	fail := func(format string, args ...any) {
+		errs = append(errs, fmt.Errorf(format+"，已恢复默认值", args...))
+	}
+	def := defaultSettings()
+
+	if _, ok := findResolution(s.Resolution); !ok {
+		fail("未知的内部分辨率%q", s.Resolution)
+		s.Resolution = def.Resolution
+	}
+	if _, ok := sim.FindPlayfield(s.Playfield); !ok {
+		fail("未知的场地类型%q", s.Playfield)
+		s.Playfield = def.Playfield
+	}
+	if s.WindowScale < 1 || s.WindowScale > maxWindowScale {
+		fail("windowScale必须在1到%d之间", maxWindowScale)
+		s.WindowScale = def.WindowScale
+	}
+	for _, v := range []struct {
+		name  string
+		value *int
+		def   int
+	}{
+		{"masterVolume", &s.MasterVolume, def.MasterVolume},
+		{"musicVolume", &s.MusicVolume, def.MusicVolume},
+		{"soundVolume", &s.SoundVolume, def.SoundVolume},
+	} {
+		if *v.value < 0 || *v.value > 100 {
+			fail("%s必须在0到100之间", v.name)
+			*v.value = v.def
+		}
+	}
+	if _, ok := findLanguage(s.Language); !ok {
+		fail("未知的语言%q", s.Language)
+		s.Language = def.Language
+	}
+
+	if s.GamepadDeadzone < 0 || s.GamepadDeadzone > maxGamepadDeadzone {
+		fail("gamepadDeadzone必须在0到%d之间", maxGamepadDeadzone)
+		s.GamepadDeadzone = def.GamepadDeadzone
+	}
+	for _, id := range slices.Sorted(maps.Keys(s.PadDeadzones)) {
+		if dz := s.PadDeadzones[id]; dz < 0 || dz > maxGamepadDeadzone {
+			errs = append(errs, fmt.Errorf("padDeadzones: 手柄%s的死区必须在0到%d之间，已忽略", id, maxGamepadDeadzone))
+			delete(s.PadDeadzones, id)
+		}
+	}
+	if s.PadDeadzones == nil {
+		s.PadDeadzones = map[string]int{}
+	}
+
+	var keyErrs, padErrs []error
+	s.KeyBindings, keyErrs = sanitizeBindings("keyBindings", s.KeyBindings, def.KeyBindings, parseKeyName)
+	s.PadBindings, padErrs = sanitizeBindings("padBindings", s.PadBindings, def.PadBindings, parseButtonName)
+	errs = append(errs, keyErrs...)
+	return append(errs, padErrs...)
+}
+
+// sanitizeBindings 检查设置文件中的一组键位：去掉未知的操作和按键，把按键名称规范化，
+// 文件中省略的操作使用默认键位。列出但没有任何按键的操作表示玩家有意解除绑定，保持为空
+func sanitizeBindings(field string, names, defaults map[string][]string, parse func(string) (string, bool)) (map[string][]string, []error) {
+	var errs []error
+	result := make(map[string][]string, actionCount)
+	for _, action := range slices.Sorted(maps.Keys(names)) {
+		if _, ok := findAction(action); !ok {
+			errs = append(errs, fmt.Errorf("%s: 未知的操作%q，已忽略", field, action))
+			continue
+		}
+		valid := make([]string, 0, len(names[action]))
+		for _, name := range names[action] {
+			canonical, ok := parse(name)
+			if !ok {
+				errs = append(errs, fmt.Errorf("%s: 操作%q: 未知的按键%q，已忽略", field, action, name))
+				continue
+			}
+			if !slices.Contains(valid, canonical) {
+				valid = append(valid, canonical)
+			}
+		}
+		result[action] = valid
+	}
+	for _, action := range actionNames {
+		if _, ok := result[action]; !ok {
+			result[action] = defaults[action]
+		}
+	}
+	return result, errs
+}
+
+// Save 将设置写入path，目录不存在时自动创建
+func (s *Settings) Save(path string) error {
+	return writeJSONFile(path, s)
+}
+
+// loadJSONFile 把what（例如"设置文件"）path中的JSON解析到v，version指向v中的版本号，
+// 必须在1到maxVersion之间。返回是否成功解析，以及之后能否把数据保存回path：
+// 文件不存在时可以保存；读取失败时不能保存，避免覆盖无法读取的文件；
+// 文件损坏或版本不受支持时见quarantineCorrupt。解析失败时v的内容不完整，调用方应改用默认值
+func loadJSONFile(path, what string, v any, version *int, maxVersion int) (loaded, ok bool) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, true
+	}
+	if err != nil {
+		log.Printf("读取%s失败，本次运行中不会保存到该文件: %v", what, err)
+		return false, false
+	}
+	if err := jsondata.Decode(data, v); err != nil {
+		return false, quarantineCorrupt(path, fmt.Errorf("%s%s已损坏: %w", what, path, err))
+	}
+	if *version < 1 || *version > maxVersion {
+		return false, quarantineCorrupt(path, fmt.Errorf("%s%s的版本%d不受支持", what, path, *version))
+	}
+	return true, true
+}
+
+// quarantineCorrupt 把因err无法使用的文件path改名保留下来并在日志中警告，之后保存时不会覆盖它。
+// 第一个备份为path.corrupt，已有备份时依次使用path.corrupt.1、path.corrupt.2……，不会覆盖更早的备份。
+// 返回能否把数据保存回path，改名失败时为false
+func quarantineCorrupt(path string, err error) bool {
+	backup := path + ".corrupt"
+	for i := 1; ; i++ {
+		if _, statErr := os.Lstat(backup); statErr != nil {
+			break
+		}
+		backup = fmt.Sprintf("%s.corrupt.%d", path, i)
+	}
+	if renameErr := os.Rename(path, backup); renameErr != nil {
+		log.Printf("%v，无法改名保留，本次运行中不会保存到该文件: %v", err, renameErr)
+		return false
+	}
+	log.Printf("%v，已改名为%s", err, backup)
+	return true
+}
+
+// writeJSONFile 将v以缩进的JSON格式写入path，目录不存在时自动创建。先写入临时文件再替换，
+// 避免写到一半时退出留下损坏的文件
+func writeJSONFile(path string, v any) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// clone 返回设置的副本，键位连同每个操作的按键列表一起复制，修改副本不会影响原设置
+func (s Settings) clone() Settings {
+	s.KeyBindings = cloneBindingNames(s.KeyBindings)
+	s.PadBindings = cloneBindingNames(s.PadBindings)
+	s.PadDeadzones = maps.Clone(s.PadDeadzones)
+	return s
+}
+
+// cloneBindingNames 返回操作名称到按键名称列表的映射的深拷贝
+func cloneBindingNames(names map[string][]string) map[string][]string {
+	if names == nil {
+		return nil
+	}
+	out := make(map[string][]string, len(names))
+	for action, list := range names {
+		out[action] = slices.Clone(list)
+	}
+	return out
+}
+
+// toBindings 返回设置中的键位，设置必须已经过sanitize
+func (s *Settings) toBindings() Bindings {
+	var b Bindings
+	for a, action := range actionNames {
+		for _, name := range s.KeyBindings[action] {
+			var k ebiten.Key
+			if k.UnmarshalText([]byte(name)) == nil {
+				b.keys[a] = append(b.keys[a], k)
+			}
+		}
+		for _, name := range s.PadBindings[action] {
+			if button, ok := findPadButton(name); ok {
+				b.buttons[a] = append(b.buttons[a], button)
+			}
+		}
+	}
+	return b
+}
+
+// applySettings 使设置s生效，设置必须已经过sanitize
+func applySettings(s Settings) {
+	settings = s
+	display.resolution, _ = findResolution(s.Resolution)
+	display.playfield, _ = sim.FindPlayfield(s.Playfield)
+	display.pixelPerfect = s.PixelPerfect
+	display.windowScale = s.WindowScale
+	applyDisplay()
+	ebiten.SetFullscreen(s.Fullscreen)
+	ebiten.SetVsyncEnabled(s.VSync)
+	bindings = s.toBindings()
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSettingsCloneIsDeep(t *testing.T) {
+	orig := defaultSettings()
+	action := actionNames[ActionFire]
+	keys := slices.Clone(orig.KeyBindings[action])
+	pads := slices.Clone(orig.PadBindings[action])
+
+	// 键位界面会原地修改某个操作的按键列表，不能写回原设置
+	c := orig.clone()
+	c.KeyBindings[action][0] = "Z"
+	c.PadBindings[action][0] = "Y"
+	c.KeyBindings[action] = append(c.KeyBindings[action], "X")
+	c.PadDeadzones["pad"] = 40
+
+	if !slices.Equal(orig.KeyBindings[action], keys) {
+		t.Errorf("修改副本的键盘按键后原设置变为%v，应为%v", orig.KeyBindings[action], keys)
+	}
+	if !slices.Equal(orig.PadBindings[action], pads) {
+		t.Errorf("修改副本的手柄按键后原设置变为%v，应为%v", orig.PadBindings[action], pads)
+	}
+	if _, ok := orig.PadDeadzones["pad"]; ok {
+		t.Error("修改副本的手柄死区后原设置也被修改")
+	}
+}
+
+func TestLoadSettingsKeepsCorruptFile(t *testing.T) {
+	for name, data := range map[string]string{
+		"corrupt": "{\"version\": 1, \"masterVolume\": ",
+		"newer":   "{\"version\": 999}",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), settingsFileName)
+			if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			s, ok := LoadSettings(path)
+			if !ok {
+				t.Fatal("改名成功后应当可以保存设置")
+			}
+			if s.Version != settingsVersion {
+				t.Errorf("版本为%d，应使用默认设置", s.Version)
+			}
+			// 原文件改名保留，之后保存不会覆盖它
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Errorf("无法读取的设置文件仍在原处: %v", err)
+			}
+			got, err := os.ReadFile(path + ".corrupt")
+			if err != nil || string(got) != data {
+				t.Errorf("备份文件内容为%q (%v)，应为%q", got, err, data)
+			}
+		})
+	}
+}
+
+func TestQuarantineCorruptKeepsEarlierBackups(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settingsFileName)
+	// 每次损坏都改名保留，之前的备份不会被覆盖
+	for i, data := range []string{"first", "second", "third"} {
+		if err := os.WriteFile(path, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := LoadSettings(path); !ok {
+			t.Fatalf("第%d次改名失败", i+1)
+		}
+	}
+	for backup, want := range map[string]string{
+		path + ".corrupt":   "first",
+		path + ".corrupt.1": "second",
+		path + ".corrupt.2": "third",
+	} {
+		if got, err := os.ReadFile(backup); err != nil || string(got) != want {
+			t.Errorf("备份%s的内容为%q (%v)，应为%q", filepath.Base(backup), got, err, want)
+		}
+	}
+}
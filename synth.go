@@ -0,0 +1,81 @@
+package main
+
+import (
+	"go-play-plane/internal/synth"
+
+	"go-play-plane/internal/sim"
+)
+
+// synthPresets 每种音效的音色
+var synthPresets = [sim.SoundCount]synth.Preset{
+	sim.SoundShot: {
+		{Wave: synth.Square, Duty: 0.25, Freq: 1200, Slide: 500, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.02, Release: 0.05}, Volume: 0.2},
+	},
+	sim.SoundEnemyHit: {
+		{Wave: synth.Noise, Freq: 9000, Slide: 4000, Env: synth.Envelope{Attack: 0.001, Sustain: 1, Hold: 0.01, Release: 0.04}, Volume: 0.2},
+		{Wave: synth.Square, Duty: 0.5, Freq: 600, Slide: 300, Env: synth.Envelope{Attack: 0.001, Sustain: 1, Hold: 0.01, Release: 0.03}, Volume: 0.12},
+	},
+	sim.SoundEnemyDestroyed: {
+		{Wave: synth.Noise, Freq: 4000, Slide: 300, Env: synth.Envelope{Attack: 0.002, Decay: 0.1, Sustain: 0.5, Hold: 0.05, Release: 0.25}, Volume: 0.45},
+		{Wave: synth.Triangle, Freq: 200, Slide: 50, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.05, Release: 0.2}, Volume: 0.4},
+	},
+	sim.SoundBossDestroyed: {
+		{Wave: synth.Noise, Freq: 3000, Slide: 100, Env: synth.Envelope{Attack: 0.005, Decay: 0.2, Sustain: 0.6, Hold: 0.3, Release: 0.8}, Volume: 0.4},
+		{Wave: synth.Triangle, Freq: 150, Slide: 30, Env: synth.Envelope{Attack: 0.005, Sustain: 1, Hold: 0.4, Release: 0.6}, Volume: 0.35},
+		{Wave: synth.Noise, Freq: 6000, Slide: 500, Delay: 0.25, Env: synth.Envelope{Attack: 0.002, Decay: 0.1, Sustain: 0.4, Hold: 0.1, Release: 0.4}, Volume: 0.3},
+	},
+	sim.SoundPlayerDeath: {
+		{Wave: synth.Noise, Freq: 5000, Slide: 150, Env: synth.Envelope{Attack: 0.002, Decay: 0.15, Sustain: 0.5, Hold: 0.3, Release: 0.5}, Volume: 0.5},
+		{Wave: synth.Square, Duty: 0.5, Freq: 880, Slide: 55, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.5, Release: 0.4}, Volume: 0.25},
+	},
+	// 道具：上行琶音，不同道具使用不同的音阶和波形
+	sim.SoundPowerUpMultiShot: {
+		synth.Blip(523.25, 0, 0.04, 0.25),
+		synth.Blip(659.25, 0.06, 0.04, 0.25),
+		synth.Blip(783.99, 0.12, 0.04, 0.25),
+		synth.Blip(1046.5, 0.18, 0.08, 0.25),
+	},
+	sim.SoundPowerUpScreenShot: {
+		{Wave: synth.Square, Duty: 0.125, Freq: 400, Slide: 1600, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.2, Release: 0.1}, Volume: 0.2},
+		synth.Blip(1567.98, 0.22, 0.1, 0.2),
+	},
+	sim.SoundPowerUpAttack: {
+		{Wave: synth.Triangle, Freq: 261.63, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.06, Release: 0.03}, Volume: 0.4},
+		{Wave: synth.Triangle, Freq: 392, Delay: 0.08, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.06, Release: 0.03}, Volume: 0.4},
+		{Wave: synth.Square, Duty: 0.25, Freq: 523.25, Slide: 540, Delay: 0.16, Env: synth.Envelope{Attack: 0.01, Decay: 0.1, Sustain: 0.6, Hold: 0.2, Release: 0.15}, Volume: 0.22},
+	},
+	sim.SoundPowerUpClear: {
+		{Wave: synth.Noise, Freq: 1000, Slide: 8000, Env: synth.Envelope{Attack: 0.1, Sustain: 1, Hold: 0.1, Release: 0.2}, Volume: 0.3},
+		{Wave: synth.Triangle, Freq: 300, Slide: 900, Env: synth.Envelope{Attack: 0.05, Sustain: 1, Hold: 0.15, Release: 0.2}, Volume: 0.35},
+	},
+	// BOSS出现：两个音高交替的警报
+	sim.SoundBossWarning: {
+		synth.Blip(440, 0, 0.25, 0.3),
+		synth.Blip(330, 0.3, 0.25, 0.3),
+		synth.Blip(440, 0.6, 0.25, 0.3),
+		synth.Blip(330, 0.9, 0.25, 0.3),
+	},
+	sim.SoundBossPhase: {
+		{Wave: synth.Square, Duty: 0.125, Freq: 900, Slide: 120, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.3, Release: 0.3}, Volume: 0.3},
+		{Wave: synth.Noise, Freq: 3000, Slide: 200, Env: synth.Envelope{Attack: 0.002, Decay: 0.1, Sustain: 0.5, Hold: 0.1, Release: 0.3}, Volume: 0.3},
+	},
+	// BOSS弹幕：很短，每次齐射只播放一次
+	sim.SoundVolley: {
+		{Wave: synth.Square, Duty: 0.5, Freq: 300, Slide: 150, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.03, Release: 0.08}, Volume: 0.2},
+	},
+	sim.SoundVolleyRing: {
+		{Wave: synth.Triangle, Freq: 700, Slide: 350, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.05, Release: 0.1}, Volume: 0.35},
+		{Wave: synth.Square, Duty: 0.25, Freq: 1400, Slide: 700, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.02, Release: 0.06}, Volume: 0.1},
+	},
+	sim.SoundVolleyCross: {
+		{Wave: synth.Square, Duty: 0.5, Freq: 500, Slide: 400, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.04, Release: 0.06}, Volume: 0.15},
+		{Wave: synth.Square, Duty: 0.5, Freq: 750, Slide: 600, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.04, Release: 0.06}, Volume: 0.15},
+	},
+	sim.SoundVolleyHoming: {
+		{Wave: synth.Square, Duty: 0.125, Freq: 300, Slide: 900, Env: synth.Envelope{Attack: 0.01, Sustain: 1, Hold: 0.08, Release: 0.08}, Volume: 0.2},
+	},
+	sim.SoundVolleyChaos: {
+		{Wave: synth.Noise, Freq: 2000, Slide: 6000, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.04, Release: 0.08}, Volume: 0.2},
+		{Wave: synth.Square, Duty: 0.25, Freq: 200, Slide: 800, Env: synth.Envelope{Attack: 0.002, Sustain: 1, Hold: 0.06, Release: 0.06}, Volume: 0.15},
+	},
+}
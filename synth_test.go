@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"go-play-plane/internal/sim"
+)
+
+// 每种音效以44100Hz渲染的PCM数据的SHA-256。有意修改音色后，按测试失败时输出的新值更新此表
+var synthGoldenHashes = []struct {
+	name  string
+	sound sim.Sound
+	hash  string
+}{
+	{"shot", sim.SoundShot, "3730efb7867790ff0d6c1a897c908ca67f1ceee7a0092acd3648fb9d1efbaeb3"},
+	{"enemyHit", sim.SoundEnemyHit, "084668c7938f68b2bb6fb13595480b29bca0f0c9db7fc4775eee58dca7fc645d"},
+	{"enemyDestroyed", sim.SoundEnemyDestroyed, "71b100487f7d22b852c1e173c6a05ecc8edd21dd0fe88143ce9df2e420fd6746"},
+	{"bossDestroyed", sim.SoundBossDestroyed, "d807a15eba700e4c777c49484bad63bc8e5ba8d181aa33e1b2d685a6468c792d"},
+	{"playerDeath", sim.SoundPlayerDeath, "54d3c191a4bd8e47e93d9f6015fc5aa7fdc2f50aa21bf2595f3787875dcd5723"},
+	{"powerUpMultiShot", sim.SoundPowerUpMultiShot, "d676a5d10a6090fb7c05db3061d924e5b25277cd663a3803560a7fc2b39677a5"},
+	{"powerUpScreenShot", sim.SoundPowerUpScreenShot, "d5fca7290cfaec7d4e5ce330a76deaab7b68242ea7716bd2e62f940622598ab8"},
+	{"powerUpAttack", sim.SoundPowerUpAttack, "07ecd4a039b33c43467583a9cbce0dbd96ae9d4f77a12043b70779627d7a33d6"},
+	{"powerUpClear", sim.SoundPowerUpClear, "c0189e74d3becc67b16a293504c5fb7d46e766070e41f2eff4b7a73a782a3305"},
+	{"bossWarning", sim.SoundBossWarning, "9045a10ecb74bf34cfd7bee0f4f9900c05e65e454b238bbfc277479391a81594"},
+	{"bossPhase", sim.SoundBossPhase, "f2512484b6541e6c5097db0e2201b0745bd0fd0ddf7f20072f965a4fcd9f74e4"},
+	{"volley", sim.SoundVolley, "d6133b5a18c5606aab3b77a58d960de41f8325a7d52446ce3ea720cfa94b6636"},
+	{"volleyRing", sim.SoundVolleyRing, "5b4a5b084f83bd87c8f54da5f60b221ff74a8d20f663508fa115bb00922fecf4"},
+	{"volleyCross", sim.SoundVolleyCross, "48f4423346d13197e597f466ac050d66d61b52a73221bf07fe70bc5a09aab0be"},
+	{"volleyHoming", sim.SoundVolleyHoming, "f2cfe008013d25cdd0e8750d8955846920e7d40136dcd0e2e139e5ced3e1a53f"},
+	{"volleyChaos", sim.SoundVolleyChaos, "de7583ed6918c69782c8d45f3235a6ca6b5025c94f79d1c5952b02461b441252"},
+}
+
+func TestSynthPresetsGolden(t *testing.T) {
+	if len(synthGoldenHashes) != int(sim.SoundCount) {
+		t.Fatalf("哈希表有%d种音效，synthPresets有%d种", len(synthGoldenHashes), sim.SoundCount)
+	}
+	for i, tt := range synthGoldenHashes {
+		if tt.sound != sim.Sound(i) {
+			t.Fatalf("第%d项为音效%d，哈希表应按Sound的顺序列出每种音效", i, tt.sound)
+		}
+	}
+	for _, tt := range synthGoldenHashes {
+		t.Run(tt.name, func(t *testing.T) {
+			pcm := synthPresets[tt.sound].Render(audioSampleRate)
+			if len(pcm) == 0 {
+				t.Fatal("渲染结果为空")
+			}
+			sum := sha256.Sum256(pcm)
+			if got := hex.EncodeToString(sum[:]); got != tt.hash {
+				t.Errorf("渲染%d字节，SHA-256为%s，应为%s", len(pcm), got, tt.hash)
+			}
+			// 渲染不使用随机数，再次渲染必须得到相同的数据
+			if again := sha256.Sum256(synthPresets[tt.sound].Render(audioSampleRate)); again != sum {
+				t.Error("两次渲染的数据不同")
+			}
+		})
+	}
+}
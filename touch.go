@@ -0,0 +1,182 @@
+package main
+
+import (
+	"math"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/sim"
+)
+
+// TouchBackend 提供触摸点的原始状态。TouchInput通过它读取触摸，
+// 在没有触摸屏的桌面上可以换成mouseTouches或SimulatedTouches
+type TouchBackend interface {
+	// AppendTouchIDs 将所有正在触摸的触摸点ID追加到ids后返回
+	AppendTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID
+	// TouchPosition 返回触摸点在窗口中的位置
+	TouchPosition(id ebiten.TouchID) (int, int)
+}
+
+// ebitenTouches 通过Ebiten读取真实的触摸屏
+type ebitenTouches struct{}
+
+func (ebitenTouches) AppendTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID {
+	return ebiten.AppendTouchIDs(ids)
+}
+
+func (ebitenTouches) TouchPosition(id ebiten.TouchID) (int, int) {
+	return ebiten.TouchPosition(id)
+}
+
+// mouseTouches 把按住的鼠标左键当作一根手指，用于在桌面上测试触摸操作
+type mouseTouches struct{}
+
+func (mouseTouches) AppendTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID {
+	if ebiten.IsMouseButtonPressed(ebiten.MouseButtonLeft) {
+		ids = append(ids, 0)
+	}
+	return ids
+}
+
+func (mouseTouches) TouchPosition(id ebiten.TouchID) (int, int) {
+	return ebiten.CursorPosition()
+}
+
+// SimulatedTouches 是TouchBackend的内存实现，可以按下、移动和抬起任意多根手指，
+// 用于在测试和机器人中代替触摸屏
+type SimulatedTouches struct {
+	touches []simulatedTouch // 按按下的先后排列
+}
+
+// simulatedTouch 一根模拟的手指
+type simulatedTouch struct {
+	id   ebiten.TouchID
+	x, y int
+}
+
+// NewSimulatedTouches 创建没有任何手指按下的模拟触摸屏
+func NewSimulatedTouches() *SimulatedTouches {
+	return &SimulatedTouches{}
+}
+
+// Press 在窗口坐标(x, y)按下手指id，手指已经按下时移动到该位置
+func (s *SimulatedTouches) Press(id ebiten.TouchID, x, y int) {
+	for i := range s.touches {
+		if s.touches[i].id == id {
+			s.touches[i].x, s.touches[i].y = x, y
+			return
+		}
+	}
+	s.touches = append(s.touches, simulatedTouch{id, x, y})
+}
+
+// Move 将已按下的手指id移动到(x, y)
+func (s *SimulatedTouches) Move(id ebiten.TouchID, x, y int) {
+	for i := range s.touches {
+		if s.touches[i].id == id {
+			s.touches[i].x, s.touches[i].y = x, y
+		}
+	}
+}
+
+// Release 抬起手指id
+func (s *SimulatedTouches) Release(id ebiten.TouchID) {
+	s.touches = slices.DeleteFunc(s.touches, func(t simulatedTouch) bool { return t.id == id })
+}
+
+func (s *SimulatedTouches) AppendTouchIDs(ids []ebiten.TouchID) []ebiten.TouchID {
+	for _, t := range s.touches {
+		ids = append(ids, t.id)
+	}
+	return ids
+}
+
+func (s *SimulatedTouches) TouchPosition(id ebiten.TouchID) (int, int) {
+	for _, t := range s.touches {
+		if t.id == id {
+			return t.x, t.y
+		}
+	}
+	return 0, 0
+}
+
+// TouchInput 从触摸屏读取输入。第一根手指作为指针，在菜单中相当于鼠标点击，
+// 在对局中拖动飞机；对局中再用第二根手指点按使用炸弹
+type TouchInput struct {
+	backend TouchBackend
+	ids     []ebiten.TouchID
+	primary ebiten.TouchID // 作为指针的手指
+	second  bool           // 上一帧是否有第二根手指
+}
+
+// NewTouchInput 创建一个读取真实触摸屏的输入源
+func NewTouchInput() *TouchInput {
+	return NewTouchInputFrom(ebitenTouches{})
+}
+
+// NewTouchInputFrom 创建一个从backend读取触摸的输入源
+func NewTouchInputFrom(backend TouchBackend) *TouchInput {
+	return &TouchInput{backend: backend, primary: -1}
+}
+
+// Poll 读取当前帧的触摸状态
+func (t *TouchInput) Poll() sim.InputState {
+	var in sim.InputState
+	t.ids = t.backend.AppendTouchIDs(t.ids[:0])
+	if len(t.ids) == 0 {
+		t.primary = -1
+		t.second = false
+		return in
+	}
+
+	// 作为指针的手指抬起后，由最早按下的另一根手指接替
+	if !slices.Contains(t.ids, t.primary) {
+		t.primary = t.ids[0]
+	}
+	in.HasCursor = true
+	in.CursorX, in.CursorY = t.backend.TouchPosition(t.primary)
+	in.Click = true
+	in.Touch = true
+
+	second := len(t.ids) > 1
+	in.Bomb = second && !t.second
+	t.second = second
+	return in
+}
+
+// followControl 把对局中的拖动转换为移动输入：手指按下时记住飞机相对手指的位置，
+// 之后飞机朝保持该相对位置的目标点移动，手指不会挡住飞机。拖动时自动开火。
+// 转换在录像之前完成，录像中记录的是转换后的普通移动输入
+type followControl struct {
+	dragging bool    // 上一帧是否在拖动
+	offsetX  float64 // 飞机中心相对手指的位置，场地坐标
+	offsetY  float64
+}
+
+// apply 拖动时用朝目标点的移动和开火替换in中的对应输入。触摸总是拖动飞机，
+// 鼠标只在设置中开启了鼠标拖动操作时拖动飞机
+func (f *followControl) apply(in sim.InputState, state *sim.Simulation) sim.InputState {
+	if !in.Click || !(in.Touch || settings.MouseFollow) {
+		f.dragging = false
+		return in
+	}
+
+	// 指针坐标从内部画面换算到场地
+	layout := newPlayLayout(state.Field)
+	x, y := layout.field.toLocal(in.CursorX, in.CursorY)
+	p := state.Player
+	cx := p.X + float64(p.Width)/2
+	cy := p.Y + float64(p.Height)/2
+	if !f.dragging {
+		f.dragging = true
+		f.offsetX, f.offsetY = cx-float64(x), cy-float64(y)
+	}
+
+	// 以玩家的最大速度朝目标点移动，距离小于一帧的移动量时按比例减速，避免来回抖动
+	dx := float64(x) + f.offsetX - cx
+	dy := float64(y) + f.offsetY - cy
+	in.MoveX = math.Max(-1, math.Min(1, dx/p.Speed))
+	in.MoveY = math.Max(-1, math.Min(1, dy/p.Speed))
+	in.Fire = true
+	return in
+}
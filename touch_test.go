@@ -0,0 +1,134 @@
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"go-play-plane/internal/sim"
+)
+
+// touchPlay 用模拟触摸屏操作一局对局，输入与PlayScene一样先经过followControl再推进模拟
+type touchPlay struct {
+	sim     *sim.Simulation
+	touches *SimulatedTouches
+	input   *TouchInput
+	follow  followControl
+	layout  playLayout
+}
+
+func newTouchPlay() *touchPlay {
+	state := sim.NewSimulation(levels, sim.FieldStandard, sim.ModePlaying, 1, 1)
+	touches := NewSimulatedTouches()
+	return &touchPlay{
+		sim:     state,
+		touches: touches,
+		input:   NewTouchInputFrom(touches),
+		layout:  newPlayLayout(state.Field),
+	}
+}
+
+// press 在场地坐标(x, y)按下手指id，手指已经按下时移动到该位置
+func (tp *touchPlay) press(id ebiten.TouchID, x, y float64) {
+	v := tp.layout.field
+	tp.touches.Press(id, int(math.Round(x*v.scale+v.offsetX)), int(math.Round(y*v.scale+v.offsetY)))
+}
+
+// step 读取触摸并推进模拟一帧，返回转换后的输入
+func (tp *touchPlay) step() sim.InputState {
+	in := tp.follow.apply(tp.input.Poll(), tp.sim)
+	tp.sim.Step(in)
+	return in
+}
+
+// playerCenter 返回飞机中心的场地坐标
+func (tp *touchPlay) playerCenter() (float64, float64) {
+	p := tp.sim.Player
+	return p.X + float64(p.Width)/2, p.Y + float64(p.Height)/2
+}
+
+func TestTouchDragMovesByOffset(t *testing.T) {
+	tp := newTouchPlay()
+	startX, startY := tp.playerCenter()
+
+	// 在飞机左上方远处按下手指，飞机不应跳到手指下面
+	fingerX, fingerY := startX-150, startY-120
+	tp.press(0, fingerX, fingerY)
+	tp.step()
+	if x, y := tp.playerCenter(); math.Abs(x-startX) > 1 || math.Abs(y-startY) > 1 {
+		t.Fatalf("按下手指后飞机从(%v, %v)移动到(%v, %v)，不应朝手指移动", startX, startY, x, y)
+	}
+
+	// 手指拖动(60, -40)后，飞机移动相同的距离，保持与手指的相对位置
+	const dx, dy = 60, -40
+	tp.press(0, fingerX+dx, fingerY+dy)
+	for i := 0; i < 60; i++ {
+		tp.step()
+	}
+	tolerance := 1 / tp.layout.field.scale
+	if x, y := tp.playerCenter(); math.Abs(x-(startX+dx)) > tolerance+1 || math.Abs(y-(startY+dy)) > tolerance+1 {
+		t.Errorf("拖动(%d, %d)后飞机中心在(%v, %v)，应在(%v, %v)", dx, dy, x, y, startX+dx, startY+dy)
+	}
+}
+
+func TestTouchDragFiresAutomatically(t *testing.T) {
+	tp := newTouchPlay()
+	x, y := tp.playerCenter()
+	for i := 0; i < 30; i++ {
+		if in := tp.step(); in.Fire {
+			t.Fatal("没有触摸时不应开火")
+		}
+	}
+	if n := len(tp.sim.BulletManager.Bullets); n != 0 {
+		t.Fatalf("没有触摸时发射了%d颗子弹", n)
+	}
+
+	tp.press(0, x, y+60)
+	for i := 0; i < 30; i++ {
+		if in := tp.step(); !in.Fire {
+			t.Fatalf("拖动第%d帧没有自动开火", i+1)
+		}
+	}
+	if len(tp.sim.BulletManager.Bullets) == 0 {
+		t.Error("拖动时没有发射子弹")
+	}
+
+	tp.touches.Release(0)
+	if in := tp.step(); in.Fire {
+		t.Error("抬起手指后仍在开火")
+	}
+}
+
+func TestTouchSecondFingerBombs(t *testing.T) {
+	tp := newTouchPlay()
+	x, y := tp.playerCenter()
+	tp.press(0, x, y+60)
+	tp.step()
+	bombs := tp.sim.Player.Bombs
+
+	// 第二根手指按下的那一帧使用炸弹，按住不放不会连续使用
+	tp.press(1, x+200, y)
+	for frame, want := range []bool{true, false, false} {
+		in := tp.step()
+		if in.Bomb != want {
+			t.Errorf("第二根手指按下后第%d帧bomb=%v，应为%v", frame+1, in.Bomb, want)
+		}
+		if !in.Fire {
+			t.Errorf("第二根手指按下后第%d帧第一根手指不再拖动飞机", frame+1)
+		}
+	}
+	if got := tp.sim.Player.Bombs; got != bombs-1 {
+		t.Errorf("使用炸弹后剩余%d个，应为%d个", got, bombs-1)
+	}
+
+	// 抬起后再次点按，再使用一个炸弹
+	tp.touches.Release(1)
+	tp.step()
+	tp.press(1, x+200, y)
+	if in := tp.step(); !in.Bomb {
+		t.Error("第二根手指再次点按没有使用炸弹")
+	}
+	if got := tp.sim.Player.Bombs; got != bombs-2 {
+		t.Errorf("再次使用炸弹后剩余%d个，应为%d个", got, bombs-2)
+	}
+}